@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/config"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+)
+
+const bootstrapUsage = "usage: api [flags] bootstrap EMAIL"
+
+// bootstrapCountry is the user-assigned ISO 3166 code of the service account, it belongs to no country
+const bootstrapCountry = "ZZ"
+
+// runBootstrap creates a service account with the email of the args and an API key carrying every scope, admin included, and prints the key.
+// Every route of the API but the public ones requires a key, so it is how the first one is made, the others are created through the API with it.
+func runBootstrap(ctx context.Context, cfg config.Postgres, args []string, out io.Writer) error {
+	if cfg.URL == "" {
+		return errors.New("postgres.url is required")
+	}
+	if len(args) != 1 || args[0] == "" {
+		return errors.New(bootstrapUsage)
+	}
+
+	db, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	defer db.Close()
+	userRepo := repositories.NewUserRepo(db)
+
+	// A service account never logs in, its password is only there to fill the column
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return fmt.Errorf("password generation failed: %w", err)
+	}
+	user, err := userRepo.CreateUser(ctx, &models.User{
+		FirstName:      "Bootstrap",
+		LastName:       "Admin",
+		Nickname:       args[0],
+		Password:       hex.EncodeToString(password),
+		Email:          args[0],
+		Country:        bootstrapCountry,
+		ServiceAccount: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	key, prefix, hash, err := apikeys.Generate()
+	if err != nil {
+		return err
+	}
+	apiKey, err := userRepo.CreateAPIKey(ctx, &models.APIKey{
+		UserID: user.ID,
+		Name:   "bootstrap",
+		Prefix: prefix,
+		Hash:   hash,
+		Scopes: models.Scopes,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "user %s\napi key %s %s\n", user.ID, apiKey.ID, key)
+	return nil
+}
@@ -3,30 +3,49 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/config"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/healthz"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/idempotency"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/lockout"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/mailer"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/messages"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/metrics"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/mfa"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/migrator"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/password"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/purger"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/ratelimit"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/secrets"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/routes"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/verification"
 )
 
 type Todo struct {
@@ -53,19 +72,53 @@ func main() {
 		server.Logger.Warn("Error loading .env file") // May warn running inside docker
 	}
 
+	// Loading the config from the defaults, the YAML file, the env variables and the flags
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		server.Logger.Fatal("config loading failed", zap.Error(err))
+	}
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(context.Background(), cfg.Postgres, args[1:], os.Stdout); err != nil {
+				server.Logger.Fatal("migrate failed", zap.Error(err))
+			}
+		case "bootstrap":
+			if err := runBootstrap(context.Background(), cfg.Postgres, args[1:], os.Stdout); err != nil {
+				server.Logger.Fatal("bootstrap failed", zap.Error(err))
+			}
+		default:
+			server.Logger.Fatal("unknown command, the commands are migrate and bootstrap", zap.String("command", args[0]))
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		server.Logger.Fatal("config validation failed", zap.Error(err))
+	}
+	server.Logger.Info("config loaded", zap.Any("config", cfg.Redacted()))
+
 	// Database Connection
-	db, err := sql.Open("postgres", os.Getenv("MANAGE_USER_GO_POSTGRES"))
+	db, err := sql.Open("postgres", cfg.Postgres.URL)
 	if err != nil {
 		server.Logger.Fatal("database connection failed", zap.Error(err))
 	}
 	defer db.Close()
+	db.SetMaxOpenConns(cfg.Postgres.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Postgres.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Postgres.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Postgres.ConnMaxIdleTime)
 	server.Logger.Info("Database connected")
 
-	// Instantiating a new UserRepository
-	userRepo := repositories.NewUserRepo(db)
+	// Instantiating a new UserRepository and the other user stores with the driver of the config
+	userRepo, txManager, closeUserRepo, err := newUserRepo(context.Background(), cfg.Postgres, db)
+	if err != nil {
+		server.Logger.Fatal("user repository initialization failed", zap.Error(err))
+	}
+	defer closeUserRepo()
+	server.TxManager = txManager
 
 	// Connecting to RabbitMQ
-	conn, err := amqp.Dial(os.Getenv("MANAGE_USER_GO_RABBITMQ"))
+	conn, err := amqp.Dial(cfg.RabbitMQ.URL)
 	if err != nil {
 		server.Logger.Fatal("rabbitmq connection failed", zap.Error(err))
 	}
@@ -80,66 +133,317 @@ func main() {
 
 	server.Logger.Info("Messaging service connected")
 
-	// Assign Chained tester with active connections to Server
-	server.ConnectionTester = &healthz.ChainedTester{
-		Testers: []healthz.ConnectionTester{
-			&healthz.DBTester{DB: db},
-			&healthz.AmqpTester{Conn: conn},
-		},
+	// The DB must be at least at the version of the last migration the binary knows
+	migrationVersion, err := migrator.LatestVersion(cfg.Postgres.Migrations)
+	if err != nil {
+		server.Logger.Fatal("migrations version failed", zap.Error(err))
 	}
 
-	// Instantiating a new UserEvents wrapping UserRepository
-	wrappedRepo := messages.NewUserEvents(server.Logger, ch, userRepo)
+	// Assign the health Checker of the active connections to Server, the probes run its checks concurrently
+	server.HealthChecker = healthz.NewChecker(cfg.Healthz.CacheTTL,
+		healthz.Check{Name: "postgres", Critical: true, Timeout: cfg.Healthz.DBCheckTimeout, Tester: &healthz.DBTester{DB: db}},
+		healthz.Check{Name: "migrations", Critical: true, Timeout: cfg.Healthz.DBCheckTimeout, Tester: &healthz.MigrationTester{DB: db, Version: migrationVersion}},
+		healthz.Check{Name: "rabbitmq", Critical: cfg.Healthz.AmqpCritical, Timeout: cfg.Healthz.AmqpCheckTimeout, Tester: &healthz.AmqpTester{Conn: conn, Queue: messages.UsersQueue}},
+	)
 
-	// Assigning wrapped-UserRepository to Server
-	server.UserRepository = wrappedRepo
+	// The pool stats of the DB and the health checks are exposed in /metrics
+	prometheus.MustRegister(
+		collectors.NewDBStatsCollector(db, "postgres"),
+		metrics.NewHealthCollector(server.HealthChecker),
+	)
 
-	// Initial migrations if not yet exists
-	if err := migrator.MigrateDB(os.Getenv("MANAGE_USER_GO_POSTGRES")); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			server.Logger.Info("migrations unchanged", zap.Error(err))
-		} else {
-			server.Logger.Fatal("migrations failed", zap.Error(err))
-		}
-	} else {
+	// Instantiating a new UserRepoMetrics wrapping UserRepository, so the latency measured is the one of the DB alone
+	measuredRepo := metrics.NewUserRepoMetrics(userRepo)
+
+	// Instantiating a new UserEvents wrapping UserRepoMetrics
+	wrappedRepo := messages.NewUserEvents(server.Logger, ch, cfg.RabbitMQ.PublishTimeout, measuredRepo)
+
+	// Email verification and password reset tokens are signed with the secret, so they can't be forged even without reaching the DB
+	signer, err := tokens.NewSigner([]byte(cfg.Tokens.Secret))
+	if err != nil {
+		server.Logger.Fatal("invalid token secret", zap.Error(err))
+	}
+	userMailer, err := newMailer(cfg.Mailer, server.Logger)
+	if err != nil {
+		server.Logger.Fatal("mailer initialization failed", zap.Error(err))
+	}
+
+	// Instantiating a new UserVerifier wrapping UserEvents, so the emails are only sent for changes already broadcast
+	verifiedRepo := verification.NewUserVerifier(server.Logger, server.TxManager, signer, userMailer, verification.Links{
+		VerifyEmailURL:   cfg.Tokens.VerifyEmailURL,
+		VerifyEmailTTL:   cfg.Tokens.EmailVerificationTTL,
+		PasswordResetURL: cfg.Tokens.PasswordResetURL,
+		PasswordResetTTL: cfg.Tokens.PasswordResetTTL,
+	}, metrics.NewUserTokenRepoMetrics(userRepo), wrappedRepo)
+
+	// Instantiating a new UserPolicy wrapping UserVerifier, so a password breaking the policy is rejected before anything else.
+	// The reuse of a previous password is checked against the PasswordHistoryRepository.
+	passwordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		server.Logger.Fatal("invalid password policy", zap.Error(err))
+	}
+	policyRepo := password.NewUserPolicy(passwordPolicy, metrics.NewPasswordHistoryRepoMetrics(userRepo), verifiedRepo)
+
+	// TOTP secrets are encrypted at rest with the MFA key
+	mfaKey, err := base64.StdEncoding.DecodeString(cfg.MFA.Key)
+	if err != nil {
+		server.Logger.Fatal("invalid mfa key encoding", zap.Error(err))
+	}
+	mfaBox, err := secrets.NewBox(mfaKey)
+	if err != nil {
+		server.Logger.Fatal("invalid mfa key", zap.Error(err))
+	}
+
+	// Assigning wrapped-UserRepository to Server, the UserVerifier also sends the password reset links
+	server.UserRepository = policyRepo
+	server.AccountService = verifiedRepo
+	server.MFAService = mfa.NewService(cfg.MFA.Issuer, mfaBox, metrics.NewMFARepoMetrics(userRepo), policyRepo)
+
+	// The API keys and the login lockouts are stores of their own, only the locks and unlocks are broadcast with the User events
+	server.APIKeyRepository = metrics.NewAPIKeyRepoMetrics(userRepo)
+	server.LoginLockoutRepository = messages.NewLoginLockoutEvents(wrappedRepo, metrics.NewLoginLockoutRepoMetrics(userRepo))
+
+	loginPolicy := newLoginPolicy(cfg.Login)
+	if err := loginPolicy.Validate(); err != nil {
+		server.Logger.Fatal("invalid login policy", zap.Error(err))
+	}
+	server.LoginGuard = lockout.NewGuard(loginPolicy, server.LoginLockoutRepository)
+
+	// Pending migrations are applied at boot, one replica at a time. A DB left dirty by a failed migration is reported
+	// but does not stop the service, it is fixed by hand and then with the migrate force command.
+	var dirty migrate.ErrDirty
+	switch err := migrator.MigrateDB(context.Background(), db, cfg.Postgres.Migrations); {
+	case err == nil:
 		server.Logger.Info("migrations done")
+	case errors.Is(err, migrate.ErrNoChange):
+		server.Logger.Info("migrations unchanged")
+	case errors.As(err, &dirty):
+		server.Logger.Error("database is dirty, migrations skipped", zap.Int("version", dirty.Version), zap.Error(err))
+	default:
+		server.Logger.Fatal("migrations failed", zap.Error(err))
 	}
 
+	// Purger hard-deletes the Users soft-deleted for longer than the retention window
+	userPurger := &purger.Purger{
+		Logger:         server.Logger,
+		UserRepository: server.UserRepository,
+		Retention:      cfg.Purge.Retention,
+		Interval:       cfg.Purge.Interval,
+	}
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	go userPurger.Run(purgerCtx)
+
 	// Instantiating Echo
 	e := echo.New()
 	e.AcquireContext()
 
+	// IPExtractor sets the source IP of the requests, used by the login lockout, the rate limits and the audit entries
+	ipExtractor, err := newIPExtractor(cfg.HTTP)
+	if err != nil {
+		server.Logger.Fatal("invalid trusted proxies", zap.Error(err))
+	}
+	e.IPExtractor = ipExtractor
+
+	// RequestID middleware, the ID is logged and stored in the audit entries
+	e.Use(middlewares.RequestID())
+
+	// Metrics middleware records the latency of the requests by route and status
+	e.Use(middlewares.Metrics())
+
 	// Logger middleware
 	e.Use(middlewares.Logger(server.Logger))
 
 	// Recover middleware
 	e.Use(middlewares.Recover())
 
+	// Load the probes and the metrics, outside the /api path group
+	routes.LoadProbes(e, server)
+
 	// creating /api path group
 	api := e.Group("/api")
 
 	// Cors Middleware
 	api.Use(middlewares.Cors())
 
+	// RateLimit middleware limits the requests of every source IP per route, before the authentication so the keys can't be guessed unthrottled
+	rateLimitStore, rateLimits, err := newRateLimiter(cfg.RateLimit, db)
+	if err != nil {
+		server.Logger.Fatal("invalid rate limits", zap.Error(err))
+	}
+	api.Use(middlewares.RateLimit(server.Logger, rateLimitStore, rateLimits))
+
+	// Authenticate middleware checks the API key of the request and its scopes
+	api.Use(middlewares.Authenticate(server.Logger, server.APIKeyRepository))
+
+	// Audit middleware attributes the mutations to the actor of the request
+	api.Use(middlewares.Audit())
+
+	// Idempotency middleware replays the stored response to the retries of a request sent with an Idempotency-Key
+	idempotencyStore, err := newIdempotencyStore(cfg.Idempotency, db)
+	if err != nil {
+		server.Logger.Fatal("idempotency store initialization failed", zap.Error(err))
+	}
+	api.Use(middlewares.Idempotency(server.Logger, idempotencyStore, cfg.Idempotency.TTL, "POST /api/users"))
+
 	// Load Routes
 	routes.LoadRoutes(api, server)
 
 	// Start server
 	go func() {
-		if err := e.Start(":3000"); err != nil && err != http.ErrServerClosed {
+		if err := e.Start(cfg.HTTP.Addr); err != nil && err != http.ErrServerClosed {
 			e.Logger.Fatal("shutting down the server")
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with a timeout of 10 seconds.
+	// Wait for interrupt signal to gracefully shutdown the server within the shutdown timeout.
 	// Use a buffered channel to avoid missing signals as recommended for signal.Notify
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	e.Logger.Info("gracefully shutting down...")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
 		log.Println("graceful shutdown failed: %w", err)
 	}
 }
+
+// newMailer returns the Mailer chosen by the config: smtp, file or log (default)
+func newMailer(cfg config.Mailer, logger *zap.Logger) (mailer.Mailer, error) {
+	switch cfg.Kind {
+	case "smtp":
+		return &mailer.SMTPMailer{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}, nil
+	case "file":
+		if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+			return nil, err
+		}
+		return &mailer.FileMailer{Dir: cfg.Dir}, nil
+	case "log":
+		return &mailer.LogMailer{Logger: logger}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Kind)
+	}
+}
+
+// newPasswordPolicy returns the password Policy of the config
+func newPasswordPolicy(cfg config.Password) (*password.Policy, error) {
+	requireClasses, err := password.ParseClasses(cfg.Classes)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &password.Policy{
+		MinLength:      cfg.MinLength,
+		RequireClasses: requireClasses,
+		HistorySize:    cfg.History,
+	}
+	if cfg.BreachedFile != "" {
+		if policy.Breached, err = password.LoadBreachedList(cfg.BreachedFile); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// newLoginPolicy returns the brute-force protection of the login of the config
+func newLoginPolicy(cfg config.Login) lockout.Policy {
+	return lockout.Policy{
+		MaxAttempts:   cfg.MaxAttempts,
+		LockDuration:  cfg.LockDuration,
+		BaseDelay:     cfg.BaseDelay,
+		MaxDelay:      cfg.MaxDelay,
+		IPMaxAttempts: cfg.IPMaxAttempts,
+		IPWindow:      cfg.IPWindow,
+	}
+}
+
+// userStores are the stores of the Users and their credentials, both drivers implement all of them on the same connections
+type userStores interface {
+	models.UserRepository
+	models.UserTokenRepository
+	models.PasswordHistoryRepository
+	models.MFARepository
+	models.LoginLockoutRepository
+	models.APIKeyRepository
+}
+
+// newUserRepo returns the userStores of the driver of the config, the TxManager whose transactions they join and the func releasing them.
+// The pgx one has a pool of its own sized like the database/sql one, which the other components keep using.
+func newUserRepo(ctx context.Context, cfg config.Postgres, db *sql.DB) (userStores, models.TxManager, func(), error) {
+	switch cfg.Driver {
+	case "", "pq":
+		userRepo := repositories.NewUserRepo(db)
+		return userRepo, transaction.NewTxManager(db), func() { userRepo.Close() }, nil
+	case "pgx":
+		poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("pgx pool config parsing failed: %w", err)
+		}
+		if cfg.MaxOpenConns > 0 {
+			poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+		}
+		poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+		poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("pgx pool creation failed: %w", err)
+		}
+		return repositories.NewPgxUserRepo(pool), transaction.NewPgxTxManager(pool), pool.Close, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown postgres driver %q", cfg.Driver)
+	}
+}
+
+// newIdempotencyStore returns the store of the idempotency keys of the config
+func newIdempotencyStore(cfg config.Idempotency, db *sql.DB) (idempotency.Store, error) {
+	switch cfg.Store {
+	case "", "postgres":
+		return idempotency.NewPostgresStore(db, cfg.LockTTL), nil
+	case "memory":
+		return idempotency.NewMemoryStore(cfg.LockTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q", cfg.Store)
+	}
+}
+
+// newIPExtractor returns the address of the connection as the source IP, or the X-Forwarded-For one when the request comes through a trusted proxy.
+// Only the configured proxies are trusted, a client could otherwise send any X-Forwarded-For to dodge the limits by IP.
+func newIPExtractor(cfg config.HTTP) (echo.IPExtractor, error) {
+	if strings.TrimSpace(cfg.TrustedProxies) == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, value := range strings.Split(cfg.TrustedProxies, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// newRateLimiter parses the limits per route and returns the store of the rate limit of the config
+func newRateLimiter(cfg config.RateLimit, db *sql.DB) (ratelimit.Store, map[string]ratelimit.Limit, error) {
+	limits, err := ratelimit.ParseLimits(cfg.Limits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch cfg.Store {
+	case "", "memory":
+		return ratelimit.NewMemoryStore(), limits, nil
+	case "postgres":
+		return ratelimit.NewPostgresStore(db), limits, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
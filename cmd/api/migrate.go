@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/config"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/migrator"
+)
+
+const migrateUsage = "usage: api [flags] migrate up | down N | goto VERSION | force VERSION | version"
+
+// runMigrate runs the migrate command of the args and prints the version the DB is left at
+func runMigrate(ctx context.Context, cfg config.Postgres, args []string, out io.Writer) error {
+	if cfg.URL == "" {
+		return errors.New("postgres.url is required")
+	}
+	command, err := parseMigrateCommand(args)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	defer db.Close()
+
+	m, err := migrator.New(ctx, db, cfg.Migrations)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := command(m); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(out, "no migration applied")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("version failed: %w", err)
+	}
+	if dirty {
+		fmt.Fprintf(out, "version %d (dirty)\n", version)
+		return nil
+	}
+	fmt.Fprintf(out, "version %d\n", version)
+	return nil
+}
+
+// parseMigrateCommand returns the command of the args, checked before connecting to the DB
+func parseMigrateCommand(args []string) (func(m *migrator.Migrator) error, error) {
+	if len(args) == 0 {
+		return nil, errors.New(migrateUsage)
+	}
+
+	command, params := args[0], args[1:]
+	switch command {
+	case "up":
+		if len(params) != 0 {
+			return nil, errors.New(migrateUsage)
+		}
+		return (*migrator.Migrator).Up, nil
+	case "down":
+		// The number of migrations is required, so a bare down can't revert every one of them
+		n, err := intParam(params)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, errors.New("the number of migrations to revert must be positive")
+		}
+		return func(m *migrator.Migrator) error { return m.Down(n) }, nil
+	case "goto":
+		version, err := intParam(params)
+		if err != nil {
+			return nil, err
+		}
+		if version < 0 {
+			return nil, errors.New("the version must not be negative")
+		}
+		return func(m *migrator.Migrator) error { return m.Goto(uint(version)) }, nil
+	case "force":
+		version, err := intParam(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *migrator.Migrator) error { return m.Force(version) }, nil
+	case "version":
+		if len(params) != 0 {
+			return nil, errors.New(migrateUsage)
+		}
+		return func(m *migrator.Migrator) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unknown migrate command %q, %s", command, migrateUsage)
+	}
+}
+
+// intParam parses the single param of a command
+func intParam(params []string) (int, error) {
+	if len(params) != 1 {
+		return 0, errors.New(migrateUsage)
+	}
+	n, err := strconv.Atoi(params[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", params[0], err)
+	}
+	return n, nil
+}
@@ -0,0 +1,37 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+const (
+	// Marker starts every API key, so they are told apart from the other bearer tokens and found by secret scanners
+	Marker     = "mug_"
+	keySize    = 32 // Random bytes of a key
+	prefixSize = len(Marker) + 8
+)
+
+// Generate returns a new API key, the prefix shown to tell it apart and the hash stored in its place
+func Generate() (key, prefix, hash string, err error) {
+	random := make([]byte, keySize)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", fmt.Errorf("api key generation failed: %w", err)
+	}
+	key = Marker + base64.RawURLEncoding.EncodeToString(random)
+	return key, key[:prefixSize], Hash(key), nil
+}
+
+// Hash returns the hash an API key is stored and looked up by, the keys are random enough not to need a slow hash
+func Hash(key string) string {
+	return tokens.Hash(key)
+}
+
+// IsAPIKey returns whether the bearer token looks like an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, Marker)
+}
@@ -0,0 +1,47 @@
+package audit
+
+import "context"
+
+// SystemActor is the actor of the mutations not triggered by a request, like the purger
+const SystemActor = "system"
+
+// Lengths of the columns of an Actor in the audit entries, longer values are truncated
+const (
+	MaxNameLength      = 100
+	MaxRequestIDLength = 100
+	MaxSourceIPLength  = 45
+)
+
+// Actor identifies who triggered a mutation, it is stored in every audit entry
+type Actor struct {
+	Name      string
+	RequestID string
+	SourceIP  string
+}
+
+type actorKey struct{}
+
+// NewContext returns a copy of ctx carrying the actor, its fields truncated to the length of their columns
+func NewContext(ctx context.Context, actor Actor) context.Context {
+	actor.Name = truncate(actor.Name, MaxNameLength)
+	actor.RequestID = truncate(actor.RequestID, MaxRequestIDLength)
+	actor.SourceIP = truncate(actor.SourceIP, MaxSourceIPLength)
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// FromContext returns the actor stored in ctx, or the SystemActor when there is none
+func FromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Name: SystemActor}
+}
+
+// truncate cuts s to max characters, as counted by a VARCHAR column
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
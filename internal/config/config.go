@@ -0,0 +1,203 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config is the whole configuration of the service. Every field is tagged with its YAML key, which is also the name of its flag
+// prefixed by the keys of its sections (e.g. -http.addr), its env variable, its default and whether it is a secret.
+type Config struct {
+	HTTP        HTTP        `yaml:"http"`
+	Postgres    Postgres    `yaml:"postgres"`
+	RabbitMQ    RabbitMQ    `yaml:"rabbitmq"`
+	Healthz     Healthz     `yaml:"healthz"`
+	Tokens      Tokens      `yaml:"tokens"`
+	Mailer      Mailer      `yaml:"mailer"`
+	Password    Password    `yaml:"password"`
+	MFA         MFA         `yaml:"mfa"`
+	Login       Login       `yaml:"login"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Purge       Purge       `yaml:"purge"`
+}
+
+// HTTP TrustedProxies is the comma separated CIDRs of the proxies whose X-Forwarded-For is trusted,
+// when it is empty the source IP of a request is the address of its connection.
+type HTTP struct {
+	Addr            string        `yaml:"addr" env:"MANAGE_USER_GO_HTTP_ADDR" default:":3000"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"MANAGE_USER_GO_SHUTDOWN_TIMEOUT" default:"10s"`
+	TrustedProxies  string        `yaml:"trusted_proxies" env:"MANAGE_USER_GO_TRUSTED_PROXIES"`
+}
+
+// Postgres pool keeps at most MaxOpenConns connections (0 is unlimited), MaxIdleConns of them idle between requests,
+// and replaces them after ConnMaxLifetime, or ConnMaxIdleTime unused, so they are spread again after a failover.
+// Driver selects the UserRepository implementation, lib/pq ("pq") or the pgx native pool ("pgx").
+// Migrations is the source URL of the migrations (e.g. file://./migrations), the ones embedded in the binary are used when it is empty.
+type Postgres struct {
+	URL             string        `yaml:"url" env:"MANAGE_USER_GO_POSTGRES" secret:"true"`
+	Driver          string        `yaml:"driver" env:"MANAGE_USER_GO_POSTGRES_DRIVER" default:"pq"`
+	Migrations      string        `yaml:"migrations" env:"MANAGE_USER_GO_MIGRATIONS"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MANAGE_USER_GO_POSTGRES_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MANAGE_USER_GO_POSTGRES_MAX_IDLE_CONNS" default:"25"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"MANAGE_USER_GO_POSTGRES_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"MANAGE_USER_GO_POSTGRES_CONN_MAX_IDLE_TIME" default:"5m"`
+}
+
+type RabbitMQ struct {
+	URL            string        `yaml:"url" env:"MANAGE_USER_GO_RABBITMQ" secret:"true"`
+	PublishTimeout time.Duration `yaml:"publish_timeout" env:"MANAGE_USER_GO_PUBLISH_TIMEOUT" default:"2s"`
+}
+
+// Healthz tests the dependencies within their timeouts, RabbitMQ is not critical unless AmqpCritical is set,
+// so it being down only degrades the readiness. The results are reused for the CacheTTL.
+type Healthz struct {
+	DBCheckTimeout   time.Duration `yaml:"db_check_timeout" env:"MANAGE_USER_GO_DB_CHECK_TIMEOUT" default:"2s"`
+	AmqpCheckTimeout time.Duration `yaml:"amqp_check_timeout" env:"MANAGE_USER_GO_AMQP_CHECK_TIMEOUT" default:"1s"`
+	AmqpCritical     bool          `yaml:"amqp_critical" env:"MANAGE_USER_GO_AMQP_CRITICAL" default:"false"`
+	CacheTTL         time.Duration `yaml:"cache_ttl" env:"MANAGE_USER_GO_HEALTHZ_CACHE_TTL" default:"1s"`
+}
+
+// Tokens signs the email verification and password reset links
+type Tokens struct {
+	Secret               string        `yaml:"secret" env:"MANAGE_USER_GO_TOKEN_SECRET" secret:"true"`
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env:"MANAGE_USER_GO_EMAIL_VERIFICATION_TTL" default:"24h"`
+	VerifyEmailURL       string        `yaml:"verify_email_url" env:"MANAGE_USER_GO_VERIFY_EMAIL_URL"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl" env:"MANAGE_USER_GO_PASSWORD_RESET_TTL" default:"1h"`
+	PasswordResetURL     string        `yaml:"password_reset_url" env:"MANAGE_USER_GO_PASSWORD_RESET_URL"`
+}
+
+type Mailer struct {
+	Kind         string `yaml:"kind" env:"MANAGE_USER_GO_MAILER"` // Required, so a deployment never falls back to logging the emails
+	Dir          string `yaml:"dir" env:"MANAGE_USER_GO_MAIL_DIR"`
+	From         string `yaml:"from" env:"MANAGE_USER_GO_MAIL_FROM"`
+	SMTPAddr     string `yaml:"smtp_addr" env:"MANAGE_USER_GO_SMTP_ADDR"`
+	SMTPUsername string `yaml:"smtp_username" env:"MANAGE_USER_GO_SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"MANAGE_USER_GO_SMTP_PASSWORD" secret:"true"`
+}
+
+type Password struct {
+	MinLength    int    `yaml:"min_length" env:"MANAGE_USER_GO_PASSWORD_MIN_LENGTH" default:"8"`
+	Classes      string `yaml:"classes" env:"MANAGE_USER_GO_PASSWORD_CLASSES" default:"lower,upper,digit"`
+	History      int    `yaml:"history" env:"MANAGE_USER_GO_PASSWORD_HISTORY" default:"5"`
+	BreachedFile string `yaml:"breached_file" env:"MANAGE_USER_GO_BREACHED_PASSWORDS_FILE"`
+}
+
+// MFA Key encrypts the TOTP secrets at rest, a base64 encoded 32 bytes key (e.g. openssl rand -base64 32)
+type MFA struct {
+	Key    string `yaml:"key" env:"MANAGE_USER_GO_MFA_KEY" secret:"true"`
+	Issuer string `yaml:"issuer" env:"MANAGE_USER_GO_MFA_ISSUER" default:"manage_user_go"`
+}
+
+type Login struct {
+	MaxAttempts   int           `yaml:"max_attempts" env:"MANAGE_USER_GO_LOGIN_MAX_ATTEMPTS" default:"5"`
+	LockDuration  time.Duration `yaml:"lock_duration" env:"MANAGE_USER_GO_LOGIN_LOCK_DURATION" default:"15m"`
+	BaseDelay     time.Duration `yaml:"base_delay" env:"MANAGE_USER_GO_LOGIN_BASE_DELAY" default:"1s"`
+	MaxDelay      time.Duration `yaml:"max_delay" env:"MANAGE_USER_GO_LOGIN_MAX_DELAY" default:"30s"`
+	IPMaxAttempts int           `yaml:"ip_max_attempts" env:"MANAGE_USER_GO_LOGIN_IP_MAX_ATTEMPTS" default:"20"`
+	IPWindow      time.Duration `yaml:"ip_window" env:"MANAGE_USER_GO_LOGIN_IP_WINDOW" default:"15m"`
+}
+
+type RateLimit struct {
+	Limits string `yaml:"limits" env:"MANAGE_USER_GO_RATE_LIMITS" default:"*=50/1s:100,GET /api/users=10/1s:20"`
+	Store  string `yaml:"store" env:"MANAGE_USER_GO_RATE_LIMIT_STORE" default:"memory"`
+}
+
+type Idempotency struct {
+	TTL     time.Duration `yaml:"ttl" env:"MANAGE_USER_GO_IDEMPOTENCY_TTL" default:"24h"`
+	LockTTL time.Duration `yaml:"lock_ttl" env:"MANAGE_USER_GO_IDEMPOTENCY_LOCK_TTL" default:"1m"` // How long a running request holds its key at most
+	Store   string        `yaml:"store" env:"MANAGE_USER_GO_IDEMPOTENCY_STORE" default:"postgres"`
+}
+
+// Purge hard-deletes the Users soft-deleted for longer than the Retention
+type Purge struct {
+	Retention time.Duration `yaml:"retention" env:"MANAGE_USER_GO_PURGE_RETENTION" default:"720h"`
+	Interval  time.Duration `yaml:"interval" env:"MANAGE_USER_GO_PURGE_INTERVAL" default:"1h"`
+}
+
+// Validate checks the values the service can't start without, every error is returned at once
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	positive := func(name string, value time.Duration) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %v, got %q", name, allowed, value))
+	}
+
+	required("http.addr", c.HTTP.Addr)
+	positive("http.shutdown_timeout", c.HTTP.ShutdownTimeout)
+	required("postgres.url", c.Postgres.URL)
+	oneOf("postgres.driver", c.Postgres.Driver, "pq", "pgx")
+	// A statement is prepared on a connection of its own while a transaction holds another one
+	if c.Postgres.MaxOpenConns == 1 || c.Postgres.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("postgres.max_open_conns must be 0 (unlimited) or at least 2"))
+	}
+	if c.Postgres.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("postgres.max_idle_conns must not be negative"))
+	}
+	positive("postgres.conn_max_lifetime", c.Postgres.ConnMaxLifetime)
+	positive("postgres.conn_max_idle_time", c.Postgres.ConnMaxIdleTime)
+	required("rabbitmq.url", c.RabbitMQ.URL)
+	positive("rabbitmq.publish_timeout", c.RabbitMQ.PublishTimeout)
+	positive("healthz.db_check_timeout", c.Healthz.DBCheckTimeout)
+	positive("healthz.amqp_check_timeout", c.Healthz.AmqpCheckTimeout)
+	if c.Healthz.CacheTTL < 0 {
+		errs = append(errs, errors.New("healthz.cache_ttl must not be negative"))
+	}
+	if len(c.Tokens.Secret) < 32 {
+		errs = append(errs, errors.New("tokens.secret must have at least 32 bytes"))
+	}
+	positive("tokens.email_verification_ttl", c.Tokens.EmailVerificationTTL)
+	positive("tokens.password_reset_ttl", c.Tokens.PasswordResetTTL)
+	if c.Mailer.Kind == "" {
+		required("mailer.kind", c.Mailer.Kind)
+	} else {
+		oneOf("mailer.kind", c.Mailer.Kind, "log", "file", "smtp")
+	}
+	if c.Mailer.Kind == "file" {
+		required("mailer.dir", c.Mailer.Dir)
+	}
+	if c.Mailer.Kind == "smtp" {
+		required("mailer.smtp_addr", c.Mailer.SMTPAddr)
+		required("mailer.from", c.Mailer.From)
+	}
+	if key, err := base64.StdEncoding.DecodeString(c.MFA.Key); err != nil || len(key) != 32 {
+		errs = append(errs, errors.New("mfa.key must be a base64 encoded 32 bytes key"))
+	}
+	required("mfa.issuer", c.MFA.Issuer)
+	oneOf("rate_limit.store", c.RateLimit.Store, "", "memory", "postgres")
+	positive("idempotency.ttl", c.Idempotency.TTL)
+	positive("idempotency.lock_ttl", c.Idempotency.LockTTL)
+	oneOf("idempotency.store", c.Idempotency.Store, "", "memory", "postgres")
+	positive("purge.retention", c.Purge.Retention)
+	positive("purge.interval", c.Purge.Interval)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", joinErrors(errs))
+	}
+	return nil
+}
+
+// joinErrors merges the errors in a single one, errors.Join only exists since Go 1.20
+func joinErrors(errs []error) error {
+	msg := errs[0].Error()
+	for _, err := range errs[1:] {
+		msg += "; " + err.Error()
+	}
+	return errors.New(msg)
+}
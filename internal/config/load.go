@@ -0,0 +1,159 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the env variable of the YAML file, the -config flag takes precedence over it
+const FileEnv = "MANAGE_USER_GO_CONFIG"
+
+// redacted replaces the secrets set in the printed config
+const redacted = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a configurable value of the Config, found by walking its struct tags
+type field struct {
+	name   string // dotted path of the YAML keys, also the name of the flag
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+// Load builds the Config from, in increasing precedence, the defaults, the YAML file, the env variables and the flags in args
+// (usually os.Args[1:]). An env variable set to an empty value only overrides the text values, so it can blank them.
+// The Config is not validated, call Validate before using it. The args left after the flags (e.g. a subcommand) are returned with it.
+func Load(args []string) (*Config, []string, error) {
+	cfg := &Config{}
+	fields := fieldsOf(reflect.ValueOf(cfg).Elem(), "")
+
+	// The flags are parsed first because the -config one points to the file, but they are only applied at the end
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(FileEnv), "path of the YAML config file (env "+FileEnv+")")
+	flagged := map[string]string{}
+	for _, f := range fields {
+		name := f.name
+		fs.Func(name, fmt.Sprintf("%s (env %s, default %q)", name, f.env, f.def), func(value string) error {
+			flagged[name] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := f.set(f.def); err != nil {
+			return nil, nil, fmt.Errorf("invalid default of %s: %w", f.name, err)
+		}
+	}
+
+	if *file != "" {
+		content, err := os.ReadFile(*file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config file failed: %w", err)
+		}
+		if err := yaml.Unmarshal(content, cfg); err != nil {
+			return nil, nil, fmt.Errorf("parse config file failed: %w", err)
+		}
+	}
+
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.env)
+		if !ok || (value == "" && f.value.Kind() != reflect.String) {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", f.env, err)
+		}
+	}
+
+	for _, f := range fields {
+		value, ok := flagged[f.name]
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid -%s: %w", f.name, err)
+		}
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// Redacted returns the effective Config keyed like the YAML file, with the secrets that are set replaced, so it can be logged
+func (c *Config) Redacted() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, f := range fieldsOf(reflect.ValueOf(c).Elem(), "") {
+		var value interface{} = f.value.Interface()
+		if f.value.Type() == durationType {
+			value = f.value.Interface().(time.Duration).String()
+		}
+		if f.secret && !f.value.IsZero() {
+			value = redacted
+		}
+		out[f.name] = value
+	}
+	return out
+}
+
+// fieldsOf lists the configurable values of the struct v, descending into its sections
+func fieldsOf(v reflect.Value, prefix string) []*field {
+	var fields []*field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		name := prefix + sf.Tag.Get("yaml")
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			fields = append(fields, fieldsOf(v.Field(i), name+".")...)
+			continue
+		}
+		fields = append(fields, &field{
+			name:   name,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+// set parses the text value into the field according to its type
+func (f *field) set(value string) error {
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(value)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported config type %s", f.value.Type())
+	}
+	return nil
+}
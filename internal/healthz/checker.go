@@ -0,0 +1,155 @@
+package healthz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of a Report and of its checks
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Only non-critical dependencies are down, the service still serves its requests
+	StatusDown     = "down"
+)
+
+// ErrTimeout is the error of a check that did not answer within its Timeout
+var ErrTimeout = errors.New("check timed out")
+
+// Check is a dependency tested by the Checker. The service can't serve without a Critical dependency,
+// while a non-critical one (e.g. RabbitMQ) being down only degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Tester   ConnectionTester
+}
+
+// CheckResult is the outcome of a single Check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every Check, in the order they were given
+type Report struct {
+	Status string         `json:"status"`
+	Checks []*CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs its Checks concurrently, each one within its own Timeout. A Report is reused for the CacheTTL,
+// so frequent probes, from every kubelet and load balancer, don't hit the dependencies each time.
+type Checker struct {
+	Checks   []Check
+	CacheTTL time.Duration
+	started  int32
+
+	mu       sync.Mutex
+	cached   *Report
+	cachedAt time.Time
+}
+
+func NewChecker(cacheTTL time.Duration, checks ...Check) *Checker {
+	return &Checker{Checks: checks, CacheTTL: cacheTTL}
+}
+
+// Run tests every dependency and returns the Report, which is down when a critical one is down
+// and degraded when only non-critical ones are. Concurrent calls wait for the same run.
+// The run is shared and cached, so it is detached from the cancellation of ctx: a probe client that hangs up
+// must not report every dependency as timed out to the other probes. Only the Timeout of each Check bounds it.
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.cachedAt) < c.CacheTTL {
+		return c.cached
+	}
+	c.cached, c.cachedAt = c.run(detachedContext{ctx}), time.Now()
+	return c.cached
+}
+
+// detachedContext keeps the values of its parent but never expires nor is canceled with it
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// run tests every dependency concurrently
+func (c *Checker) run(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Checks: make([]*CheckResult, len(c.Checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range c.Checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// Startup runs the Checks until the critical dependencies are up once, from then on the service is started and they are not run again
+func (c *Checker) Startup(ctx context.Context) *Report {
+	if atomic.LoadInt32(&c.started) == 1 {
+		return &Report{Status: StatusOK}
+	}
+	report := c.Run(ctx)
+	if report.Status != StatusDown {
+		atomic.StoreInt32(&c.started, 1)
+	}
+	return report
+}
+
+// runCheck tests a single dependency, a tester ignoring the context is given up on once the Timeout is reached
+func runCheck(ctx context.Context, check Check) *CheckResult {
+	result := &CheckResult{Name: check.Name, Status: StatusOK, Critical: check.Critical}
+
+	if check.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1) // Buffered so a late tester does not block forever
+	go func() {
+		done <- check.Tester.TestConnection(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = ErrTimeout
+		}
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -3,58 +3,93 @@ package healthz
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// The Check timeout was set to 2 sec as it seems more than enough time to the DB to answer
-const DBCheckTimeout = 2 * time.Second
-
 // ConnectionTester has the method TestConnection that may be used for any external service dependency
 type ConnectionTester interface {
 	TestConnection(ctx context.Context) error
 }
 
-// AmqpTester is the Database ConnectionTester implementation
+// TesterFunc turns a function into a ConnectionTester
+type TesterFunc func(ctx context.Context) error
+
+func (f TesterFunc) TestConnection(ctx context.Context) error {
+	return f(ctx)
+}
+
+// DBTester is the Database ConnectionTester implementation, it reads the USERS table, so a missing schema or revoked grant
+// is caught where a ping would pass, and fails on a read-only server (e.g. a replica the URL points to after a failover)
 type DBTester struct {
 	DB *sql.DB
 }
 
 func (s *DBTester) TestConnection(ctx context.Context) error {
-	ctx, cancel := context.WithTimeout(ctx, DBCheckTimeout)
-	defer cancel()
-	if err := s.DB.PingContext(ctx); err != nil {
+	var one int
+	err := s.DB.QueryRowContext(ctx, "SELECT 1 FROM U1.USERS LIMIT 1").Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("test db connection failed: %w", err)
 	}
+
+	var readOnly string
+	if err := s.DB.QueryRowContext(ctx, "SHOW transaction_read_only").Scan(&readOnly); err != nil {
+		return fmt.Errorf("test db read only failed: %w", err)
+	}
+	if readOnly == "on" {
+		return errors.New("test db connection failed: the database is read only")
+	}
 	return nil
 }
 
-// AmqpTester is the RabbitMQ ConnectionTester implementation
-type AmqpTester struct {
-	Conn *amqp.Connection
+// MigrationTester checks the migrations of the DB are at least at the Version of the binary and did not fail half way.
+// A DB ahead of the Version is fine, a newer replica migrated it during a rollout.
+type MigrationTester struct {
+	DB      *sql.DB
+	Version uint
 }
 
-func (s *AmqpTester) TestConnection(ctx context.Context) error {
-	if s.Conn.IsClosed() {
-		return fmt.Errorf("test amqp connection failed")
+func (s *MigrationTester) TestConnection(ctx context.Context) error {
+	var version int64
+	var dirty bool
+	err := s.DB.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("test migrations failed: no migration applied, expected version %d", s.Version)
+		}
+		return fmt.Errorf("test migrations failed: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("test migrations failed: version %d is dirty", version)
+	}
+	if version < int64(s.Version) {
+		return fmt.Errorf("test migrations failed: version %d, expected %d", version, s.Version)
 	}
 	return nil
 }
 
-// ChainedTester stores all ConnectionTesters in a list
-type ChainedTester struct {
-	Testers []ConnectionTester
+// AmqpTester is the RabbitMQ ConnectionTester implementation, it opens a channel and passively declares the Queue,
+// so a broker refusing channels or a deleted queue is caught while the connection still looks open
+type AmqpTester struct {
+	Conn  *amqp.Connection
+	Queue string
 }
 
-// TestConnection is a method from ChainedTester that executes all ConnectionTesters in its list
-func (s *ChainedTester) TestConnection(ctx context.Context) error {
-	for _, tester := range s.Testers {
-		err := tester.TestConnection(ctx)
-		if err != nil {
-			return err
-		}
+func (s *AmqpTester) TestConnection(ctx context.Context) error {
+	if s.Conn.IsClosed() {
+		return fmt.Errorf("test amqp connection failed")
+	}
+	ch, err := s.Conn.Channel()
+	if err != nil {
+		return fmt.Errorf("test amqp channel failed: %w", err)
+	}
+	defer ch.Close()
+
+	// A passive declare only checks the queue exists, the channel is closed by the broker when it does not
+	if _, err := ch.QueueDeclarePassive(s.Queue, false, false, false, false, nil); err != nil {
+		return fmt.Errorf("test amqp queue failed: %w", err)
 	}
 	return nil
 }
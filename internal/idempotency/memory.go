@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// entry is the record of a key, pending until the response of its request is saved
+type entry struct {
+	record  *Record
+	pending bool
+	done    chan struct{} // Closed once the pending entry is saved or released
+}
+
+// MemoryStore keeps the records in memory, so each replica only serializes its own requests
+type MemoryStore struct {
+	lockTTL   time.Duration
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// NewMemoryStore returns a MemoryStore whose leases are taken over once they are held for longer than the lockTTL
+func NewMemoryStore(lockTTL time.Duration) *MemoryStore {
+	return &MemoryStore{lockTTL: lockTTL, entries: map[string]*entry{}}
+}
+
+func (s *MemoryStore) Acquire(ctx context.Context, key, fingerprint string) (*Record, Lease, error) {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		s.sweep(now)
+
+		e, ok := s.entries[key]
+		if !ok || !now.Before(e.record.ExpiresAt) {
+			e = &entry{
+				record:  &Record{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(s.lockTTL)},
+				pending: true,
+				done:    make(chan struct{}),
+			}
+			s.entries[key] = e
+			s.mu.Unlock()
+			return nil, &memoryLease{store: s, key: key, entry: e}, nil
+		}
+		if !e.pending || e.record.Fingerprint != fingerprint {
+			record := *e.record
+			s.mu.Unlock()
+			return &record, nil, nil
+		}
+		done, expiresAt := e.done, e.record.ExpiresAt
+		s.mu.Unlock()
+
+		// The request with the same key is waited for, until it ends or its lease expires
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("idempotency wait failed: %w", ctx.Err())
+		case <-done:
+		case <-time.After(time.Until(expiresAt)):
+		}
+	}
+}
+
+// sweep drops the expired entries once per sweepInterval, it must be called with the lock held
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, e := range s.entries {
+		if !e.pending && !now.Before(e.record.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// memoryLease is the pending entry of a key, only changed while it is still the entry of the key
+type memoryLease struct {
+	store *MemoryStore
+	key   string
+	entry *entry
+}
+
+func (l *memoryLease) Save(ctx context.Context, record *Record) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	if l.store.entries[l.key] != l.entry || !l.entry.pending {
+		return fmt.Errorf("idempotency save failed: %w", ErrLeaseLost)
+	}
+	saved := *record
+	l.entry.record, l.entry.pending = &saved, false
+	close(l.entry.done)
+	return nil
+}
+
+func (l *memoryLease) Release() error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	if l.store.entries[l.key] == l.entry && l.entry.pending {
+		delete(l.store.entries, l.key)
+		l.entry.pending = false
+		close(l.entry.done)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sweepInterval  = time.Minute           // How often the expired records are deleted
+	pollInterval   = 50 * time.Millisecond // How often a request waiting for the same key checks it again
+	releaseTimeout = 5 * time.Second       // Bounds the release, which runs even when the request was cancelled
+)
+
+// ErrLeaseLost is returned when the lock ttl of a Lease passed and another request took the key over
+var ErrLeaseLost = errors.New("idempotency lease lost")
+
+// PostgresStore keeps the records in U1.IDEMPOTENCY_KEYS, so the requests with the same key are serialized across every replica.
+// A request takes a key by inserting a pending row holding its lease, each statement commits on its own, so no lock nor connection
+// is held while the request runs. The requests with the same key poll the row until the response is saved.
+type PostgresStore struct {
+	db        *sql.DB
+	lockTTL   time.Duration
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresStore returns a PostgresStore whose leases are taken over once they are held for longer than the lockTTL,
+// which should be above the time a request may run
+func NewPostgresStore(db *sql.DB, lockTTL time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, lockTTL: lockTTL}
+}
+
+func (s *PostgresStore) Acquire(ctx context.Context, key, fingerprint string) (*Record, Lease, error) {
+	s.sweep(ctx)
+
+	lease := uuid.New()
+	for {
+		taken, err := s.take(ctx, key, fingerprint, lease)
+		if err != nil {
+			return nil, nil, err
+		}
+		if taken {
+			return nil, &postgresLease{db: s.db, key: key, lease: lease}, nil
+		}
+
+		record, pending, err := s.find(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if record != nil && (!pending || record.Fingerprint != fingerprint) {
+			return record, nil, nil
+		}
+		if record == nil {
+			continue // The row was released or expired in between, the key is taken again
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("idempotency wait failed: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// take inserts the pending row of the key, or replaces an expired one, and returns whether the key was taken
+func (s *PostgresStore) take(ctx context.Context, key, fingerprint string, lease uuid.UUID) (bool, error) {
+	query := `INSERT INTO U1.IDEMPOTENCY_KEYS (IDEMPOTENCY_KEY, FINGERPRINT, STATUS_CODE, CONTENT_TYPE, BODY, EXPIRES_AT, LEASE)
+	VALUES ($1, $2, 0, '', '', $3, $4)
+	ON CONFLICT (IDEMPOTENCY_KEY) DO UPDATE SET
+		FINGERPRINT = EXCLUDED.FINGERPRINT,
+		STATUS_CODE = EXCLUDED.STATUS_CODE,
+		CONTENT_TYPE = EXCLUDED.CONTENT_TYPE,
+		BODY = EXCLUDED.BODY,
+		EXPIRES_AT = EXCLUDED.EXPIRES_AT,
+		LEASE = EXCLUDED.LEASE,
+		CREATED_AT = now()
+	WHERE U1.IDEMPOTENCY_KEYS.EXPIRES_AT <= now()`
+
+	result, err := s.db.ExecContext(ctx, query, key, fingerprint, time.Now().Add(s.lockTTL).UTC(), lease)
+	if err != nil {
+		return false, fmt.Errorf("idempotency acquire failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("idempotency acquire failed: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// find returns the unexpired record of the key and whether its request is still running, nil when there is none
+func (s *PostgresStore) find(ctx context.Context, key string) (*Record, bool, error) {
+	query := `SELECT IDEMPOTENCY_KEY, FINGERPRINT, STATUS_CODE, CONTENT_TYPE, BODY, EXPIRES_AT, LEASE IS NOT NULL
+	FROM U1.IDEMPOTENCY_KEYS
+	WHERE IDEMPOTENCY_KEY = $1 AND EXPIRES_AT > now()`
+
+	record := &Record{}
+	var pending bool
+	err := s.db.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+		&record.ExpiresAt,
+		&pending,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("idempotency find failed: %w", err)
+	}
+	return record, pending, nil
+}
+
+// sweep deletes the expired records once per sweepInterval, an expired record is ignored anyway
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	// Best effort, a failed sweep is retried on the next interval
+	_, _ = s.db.ExecContext(ctx, "DELETE FROM U1.IDEMPOTENCY_KEYS WHERE EXPIRES_AT < now()")
+}
+
+// postgresLease is the pending row of a key, only changed while it still holds the lease
+type postgresLease struct {
+	db    *sql.DB
+	key   string
+	lease uuid.UUID
+}
+
+func (l *postgresLease) Save(ctx context.Context, record *Record) error {
+	query := `UPDATE U1.IDEMPOTENCY_KEYS SET
+		STATUS_CODE = $3,
+		CONTENT_TYPE = $4,
+		BODY = $5,
+		EXPIRES_AT = $6,
+		LEASE = NULL
+	WHERE IDEMPOTENCY_KEY = $1 AND LEASE = $2`
+
+	result, err := l.db.ExecContext(ctx, query,
+		l.key,
+		l.lease,
+		record.StatusCode,
+		record.ContentType,
+		record.Body,
+		record.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("idempotency save failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("idempotency save failed: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("idempotency save failed: %w", ErrLeaseLost)
+	}
+	return nil
+}
+
+func (l *postgresLease) Release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	// Once saved the row holds no lease anymore and is kept
+	if _, err := l.db.ExecContext(ctx, "DELETE FROM U1.IDEMPOTENCY_KEYS WHERE IDEMPOTENCY_KEY = $1 AND LEASE = $2", l.key, l.lease); err != nil {
+		return fmt.Errorf("idempotency release failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is the response stored for an idempotency key, along with the fingerprint of the request it answered
+type Record struct {
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Store keeps the responses of the idempotent requests
+type Store interface {
+	// Acquire takes the key for the request with the fingerprint and returns the Lease that saves its response,
+	// or returns the Record of the key when another request has it: its stored response, or only its Fingerprint
+	// when that request has another fingerprint and is still running. Exactly one of them is returned.
+	// A request with the same fingerprint still running is waited for, without holding a lock nor a connection meanwhile.
+	Acquire(ctx context.Context, key, fingerprint string) (*Record, Lease, error)
+}
+
+// Lease is the hold of a request on an idempotency key, it expires after the lock ttl of the Store
+// so a request that never released it (e.g. its replica died) does not hold the key forever
+type Lease interface {
+	// Save stores the response of the request for the key
+	Save(ctx context.Context, record *Record) error
+	// Release frees the key when no response was saved, so the request can be retried. It must always be called.
+	Release() error
+}
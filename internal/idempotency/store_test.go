@@ -0,0 +1,175 @@
+package idempotency_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/idempotency"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/migrator"
+)
+
+// postgresEnv is the URL of the local Postgres the PostgresStore is tested against, the same one as the repository benchmarks
+const postgresEnv = "MANAGE_USER_GO_BENCH_POSTGRES"
+
+const lockTTL = 200 * time.Millisecond
+
+// stores returns every Store implementation, the PostgresStore only when postgresEnv is set
+func stores(t *testing.T) map[string]idempotency.Store {
+	stores := map[string]idempotency.Store{"memory": idempotency.NewMemoryStore(lockTTL)}
+
+	url := os.Getenv(postgresEnv)
+	if url == "" {
+		t.Logf("%s is not set, the PostgresStore is skipped", postgresEnv)
+		return stores
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrator.MigrateDB(context.Background(), db, ""); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatal(err)
+	}
+	// A single connection, a request waiting for a key must not need a second one
+	db.SetMaxOpenConns(1)
+	stores["postgres"] = idempotency.NewPostgresStore(db, lockTTL)
+	return stores
+}
+
+func response(key, fingerprint string) *idempotency.Record {
+	return &idempotency.Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		StatusCode:  201,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":"47678967-346e-46be-b5da-0ead3e080c74"}`),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+}
+
+// acquired is the outcome of an Acquire run in the background
+type acquired struct {
+	record *idempotency.Record
+	lease  idempotency.Lease
+	err    error
+}
+
+func acquireAsync(store idempotency.Store, key, fingerprint string) chan acquired {
+	ch := make(chan acquired, 1)
+	go func() {
+		record, lease, err := store.Acquire(context.Background(), key, fingerprint)
+		ch <- acquired{record, lease, err}
+	}()
+	return ch
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range stores(t) {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			t.Run("a duplicate waits for the response of the first request", func(t *testing.T) {
+				key := uuid.NewString()
+				record, lease, err := store.Acquire(ctx, key, "a")
+				assert.NoError(t, err)
+				assert.Nil(t, record)
+
+				duplicate := acquireAsync(store, key, "a")
+				select {
+				case <-duplicate:
+					t.Fatal("the duplicate did not wait for the first request")
+				case <-time.After(100 * time.Millisecond):
+				}
+
+				assert.NoError(t, lease.Save(ctx, response(key, "a")))
+				assert.NoError(t, lease.Release())
+
+				result := <-duplicate
+				assert.NoError(t, result.err)
+				assert.Nil(t, result.lease)
+				if assert.NotNil(t, result.record) {
+					assert.Equal(t, 201, result.record.StatusCode)
+					assert.Equal(t, "a", result.record.Fingerprint)
+				}
+			})
+
+			t.Run("another body is refused while the first request runs", func(t *testing.T) {
+				key := uuid.NewString()
+				_, lease, err := store.Acquire(ctx, key, "a")
+				assert.NoError(t, err)
+				defer lease.Release()
+
+				select {
+				case result := <-acquireAsync(store, key, "b"):
+					assert.NoError(t, result.err)
+					assert.Nil(t, result.lease)
+					if assert.NotNil(t, result.record) {
+						assert.Equal(t, "a", result.record.Fingerprint)
+					}
+				case <-time.After(100 * time.Millisecond):
+					t.Fatal("another body waited for the first request")
+				}
+			})
+
+			t.Run("a release without response lets the duplicate run", func(t *testing.T) {
+				key := uuid.NewString()
+				_, lease, err := store.Acquire(ctx, key, "a")
+				assert.NoError(t, err)
+
+				duplicate := acquireAsync(store, key, "a")
+				assert.NoError(t, lease.Release())
+
+				result := <-duplicate
+				assert.NoError(t, result.err)
+				assert.Nil(t, result.record)
+				if assert.NotNil(t, result.lease) {
+					assert.NoError(t, result.lease.Release())
+				}
+			})
+
+			t.Run("an expired lease is taken over", func(t *testing.T) {
+				key := uuid.NewString()
+				_, lease, err := store.Acquire(ctx, key, "a")
+				assert.NoError(t, err)
+
+				result := <-acquireAsync(store, key, "a")
+				assert.NoError(t, result.err)
+				assert.Nil(t, result.record)
+				if assert.NotNil(t, result.lease) {
+					defer result.lease.Release()
+				}
+
+				// The first request can no longer save its response nor free the key of the second one
+				assert.ErrorIs(t, lease.Save(ctx, response(key, "a")), idempotency.ErrLeaseLost)
+				assert.NoError(t, lease.Release())
+				select {
+				case <-acquireAsync(store, key, "b"):
+				case <-time.After(100 * time.Millisecond):
+					t.Fatal("another body waited for the first request")
+				}
+			})
+
+			t.Run("a waiting request gives up with its context", func(t *testing.T) {
+				key := uuid.NewString()
+				_, lease, err := store.Acquire(ctx, key, "a")
+				assert.NoError(t, err)
+				defer lease.Release()
+
+				ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+				defer cancel()
+				_, _, err = store.Acquire(ctx, key, "a")
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			})
+		})
+	}
+}
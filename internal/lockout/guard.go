@@ -0,0 +1,112 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// Policy configures how failed logins are throttled. A zero MaxAttempts, BaseDelay or IPMaxAttempts disables that protection.
+type Policy struct {
+	MaxAttempts   int           // Failed logins in a row that lock the User
+	LockDuration  time.Duration // How long the User stays locked
+	BaseDelay     time.Duration // Wait after the first failed login, doubled after each new one
+	MaxDelay      time.Duration // Upper bound of the wait between failed logins
+	IPMaxAttempts int           // Failed logins from a single IP allowed per IPWindow
+	IPWindow      time.Duration
+}
+
+// Validate returns an error describing the first invalid setting
+func (p *Policy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0 || p.IPMaxAttempts < 0:
+		return errors.New("max attempts can't be negative")
+	case p.MaxAttempts > 0 && p.LockDuration <= 0:
+		return errors.New("lock duration must be positive")
+	case p.BaseDelay < 0 || p.MaxDelay < p.BaseDelay:
+		return errors.New("max delay must not be lower than the base delay")
+	case p.IPMaxAttempts > 0 && p.IPWindow <= 0:
+		return errors.New("ip window must be positive")
+	}
+	return nil
+}
+
+// delay returns the wait required after the given number of failed logins in a row
+func (p *Policy) delay(failures int) time.Duration {
+	if failures <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Guard implements models.LoginGuard. The failures of each User are kept in the LoginLockoutRepository, so the locks are shared by every replica,
+// while the failures of each IP are kept in memory.
+type Guard struct {
+	lockoutRepository models.LoginLockoutRepository
+	policy            Policy
+	ips               *ipAttempts
+	now               func() time.Time
+}
+
+func NewGuard(policy Policy, lockoutRepo models.LoginLockoutRepository) *Guard {
+	return &Guard{
+		lockoutRepository: lockoutRepo,
+		policy:            policy,
+		ips:               newIPAttempts(policy.IPMaxAttempts, policy.IPWindow),
+		now:               time.Now,
+	}
+}
+
+func (g *Guard) Check(ctx context.Context, email string, ip string) error {
+	now := g.now()
+	if wait := g.ips.retryAfter(ip, now); wait > 0 {
+		return &models.ThrottledError{Reason: "too many failed logins from this address", RetryAfter: wait}
+	}
+
+	lockout, err := g.lockoutRepository.FindLoginLockout(ctx, email)
+	if err != nil {
+		return fmt.Errorf("login check failed: %w", err)
+	}
+	if lockout.LockedUntil != nil && lockout.LockedUntil.After(now) {
+		return &models.ThrottledError{Reason: "account locked", RetryAfter: lockout.LockedUntil.Sub(now)}
+	}
+	if lockout.LastFailedAt != nil {
+		next := lockout.LastFailedAt.Add(g.policy.delay(lockout.FailedAttempts))
+		if next.After(now) {
+			return &models.ThrottledError{Reason: "too many failed logins", RetryAfter: next.Sub(now)}
+		}
+	}
+	return nil
+}
+
+func (g *Guard) Fail(ctx context.Context, email string, ip string) error {
+	g.ips.fail(ip, g.now())
+
+	maxAttempts := g.policy.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = math.MaxInt32
+	}
+	_, err := g.lockoutRepository.RecordLoginFailure(ctx, email, maxAttempts, g.policy.LockDuration)
+	if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return fmt.Errorf("record login failure failed: %w", err)
+	}
+	// An unknown email has no account to lock, only its IP is throttled
+	return nil
+}
+
+func (g *Guard) Succeed(ctx context.Context, id uuid.UUID) error {
+	return g.lockoutRepository.ResetLoginFailures(ctx, id)
+}
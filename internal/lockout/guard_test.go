@@ -0,0 +1,161 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+)
+
+var testPolicy = Policy{
+	MaxAttempts:   5,
+	LockDuration:  15 * time.Minute,
+	BaseDelay:     time.Second,
+	MaxDelay:      30 * time.Second,
+	IPMaxAttempts: 3,
+	IPWindow:      time.Minute,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	tt := []struct {
+		name     string
+		policy   Policy
+		failures int
+		delay    time.Duration
+	}{
+		{name: "no failure", policy: testPolicy, failures: 0, delay: 0},
+		{name: "first failure", policy: testPolicy, failures: 1, delay: time.Second},
+		{name: "second failure doubles", policy: testPolicy, failures: 2, delay: 2 * time.Second},
+		{name: "third failure doubles again", policy: testPolicy, failures: 3, delay: 4 * time.Second},
+		{name: "fifth failure", policy: testPolicy, failures: 5, delay: 16 * time.Second},
+		{name: "sixth failure is capped", policy: testPolicy, failures: 6, delay: 30 * time.Second},
+		{name: "many failures stay capped", policy: testPolicy, failures: 1000, delay: 30 * time.Second},
+		{name: "disabled without base delay", policy: Policy{MaxDelay: 30 * time.Second}, failures: 3, delay: 0},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.delay, test.policy.delay(test.failures))
+		})
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockLoginLockoutRepository(ctrl)
+
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	in := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tt := []struct {
+		name       string
+		lockout    *models.LoginLockout
+		retryAfter time.Duration // Zero when the login is allowed
+	}{
+		{
+			name:    "allowed without failures",
+			lockout: &models.LoginLockout{},
+		},
+		{
+			name:       "throttled right after the first failure",
+			lockout:    &models.LoginLockout{FailedAttempts: 1, LastFailedAt: ago(0)},
+			retryAfter: time.Second,
+		},
+		{
+			name:    "allowed once the first delay passed",
+			lockout: &models.LoginLockout{FailedAttempts: 1, LastFailedAt: ago(time.Second)},
+		},
+		{
+			name:       "throttled for the rest of the third delay",
+			lockout:    &models.LoginLockout{FailedAttempts: 3, LastFailedAt: ago(time.Second)},
+			retryAfter: 3 * time.Second,
+		},
+		{
+			name:    "allowed once the third delay passed",
+			lockout: &models.LoginLockout{FailedAttempts: 3, LastFailedAt: ago(4 * time.Second)},
+		},
+		{
+			name:       "throttled for the max delay",
+			lockout:    &models.LoginLockout{FailedAttempts: 10, LastFailedAt: ago(0)},
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:       "locked until the lock expires",
+			lockout:    &models.LoginLockout{FailedAttempts: 5, LastFailedAt: ago(time.Hour), LockedUntil: in(10 * time.Minute)},
+			retryAfter: 10 * time.Minute,
+		},
+		{
+			name:    "allowed once the lock expired",
+			lockout: &models.LoginLockout{FailedAttempts: 5, LastFailedAt: ago(time.Hour), LockedUntil: ago(time.Minute)},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			guard := NewGuard(testPolicy, mockedRepo)
+			guard.now = func() time.Time { return now }
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().FindLoginLockout(gomock.Any(), "john.tester@email.com").Return(test.lockout, nil)
+
+			// Assertions
+			err := guard.Check(context.Background(), "john.tester@email.com", "192.0.2.1")
+			if test.retryAfter == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var throttled *models.ThrottledError
+			if assert.True(t, errors.As(err, &throttled)) {
+				assert.Equal(t, test.retryAfter, throttled.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestGuardCheckIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockLoginLockoutRepository(ctrl)
+
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewGuard(testPolicy, mockedRepo)
+	guard.now = func() time.Time { return now }
+
+	// The failures of unknown emails are only counted by IP
+	mockedRepo.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any(), testPolicy.MaxAttempts, testPolicy.LockDuration).
+		Times(testPolicy.IPMaxAttempts).Return(nil, models.ErrUserNotFound)
+	for i := 0; i < testPolicy.IPMaxAttempts; i++ {
+		assert.NoError(t, guard.Fail(context.Background(), "unknown@email.com", "192.0.2.1"))
+		now = now.Add(10 * time.Second)
+	}
+
+	// The IP is refused whatever the email, until its window ends
+	var throttled *models.ThrottledError
+	err := guard.Check(context.Background(), "john.tester@email.com", "192.0.2.1")
+	if assert.True(t, errors.As(err, &throttled)) {
+		assert.Equal(t, 30*time.Second, throttled.RetryAfter)
+	}
+
+	// Another IP is not
+	mockedRepo.EXPECT().FindLoginLockout(gomock.Any(), "john.tester@email.com").Times(2).Return(&models.LoginLockout{}, nil)
+	assert.NoError(t, guard.Check(context.Background(), "john.tester@email.com", "192.0.2.2"))
+
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, guard.Check(context.Background(), "john.tester@email.com", "192.0.2.1"))
+}
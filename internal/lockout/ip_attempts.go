@@ -0,0 +1,72 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// ipWindow counts the failed logins from an IP since start
+type ipWindow struct {
+	start    time.Time
+	failures int
+}
+
+// ipAttempts counts the failed logins per source IP in fixed windows, in memory, so each replica limits on its own
+type ipAttempts struct {
+	mu        sync.Mutex
+	windows   map[string]*ipWindow
+	window    time.Duration
+	max       int
+	lastSweep time.Time
+}
+
+func newIPAttempts(max int, window time.Duration) *ipAttempts {
+	return &ipAttempts{
+		windows: map[string]*ipWindow{},
+		window:  window,
+		max:     max,
+	}
+}
+
+// retryAfter returns how long the IP must wait before trying again, zero when it may try now
+func (a *ipAttempts) retryAfter(ip string, now time.Time) time.Duration {
+	if a.max <= 0 {
+		return 0
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	w, ok := a.windows[ip]
+	if !ok || w.failures < a.max {
+		return 0
+	}
+	return w.start.Add(a.window).Sub(now)
+}
+
+// fail counts a failed login from the IP
+func (a *ipAttempts) fail(ip string, now time.Time) {
+	if a.max <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The expired windows are dropped once per window, so the map only holds the IPs that failed recently
+	if now.Sub(a.lastSweep) > a.window {
+		for key, w := range a.windows {
+			if now.Sub(w.start) >= a.window {
+				delete(a.windows, key)
+			}
+		}
+		a.lastSweep = now
+	}
+
+	w, ok := a.windows[ip]
+	if !ok || now.Sub(w.start) >= a.window {
+		w = &ipWindow{start: now}
+		a.windows[ip] = w
+	}
+	w.failures++
+}
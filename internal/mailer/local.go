@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tokenParam matches the value of the token query param of the links of an email
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// LogMailer is the Mailer implementation for local runs, the emails are only logged.
+// The tokens of the links are redacted so the logs can't be used to verify an email or reset a password, the FileMailer keeps them.
+type LogMailer struct {
+	Logger *zap.Logger
+}
+
+func (s *LogMailer) Send(ctx context.Context, msg *Message) error {
+	s.Logger.Info("email not sent, logged instead",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", RedactTokens(msg.Body)),
+	)
+	return nil
+}
+
+// RedactTokens replaces the value of the token query params of the links in the text
+func RedactTokens(text string) string {
+	return tokenParam.ReplaceAllString(text, "${1}[redacted]")
+}
+
+// FileMailer is the Mailer implementation for local runs and tests, every email is written to its own file in Dir
+type FileMailer struct {
+	Dir string
+}
+
+func (s *FileMailer) Send(ctx context.Context, msg *Message) error {
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(filepath.Join(s.Dir, name), []byte(content), 0o600); err != nil {
+		return fmt.Errorf("file mailer write failed: %w", err)
+	}
+	return nil
+}
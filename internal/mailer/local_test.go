@@ -0,0 +1,39 @@
+package mailer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/mailer"
+)
+
+func TestRedactTokens(t *testing.T) {
+	tt := []struct {
+		name     string
+		text     string
+		redacted string
+	}{
+		{
+			name:     "link with a token",
+			text:     "Hi John,\nhttps://app.example.com/verify-email?token=ZW1haWx8NDc2.3x2fY0t%2B\nThanks",
+			redacted: "Hi John,\nhttps://app.example.com/verify-email?token=[redacted]\nThanks",
+		},
+		{
+			name:     "token among other params",
+			text:     "https://app.example.com/reset?lang=en&token=abc.def&next=/home",
+			redacted: "https://app.example.com/reset?lang=en&token=[redacted]&next=/home",
+		},
+		{
+			name:     "text without a token",
+			text:     "Your password was changed",
+			redacted: "Your password was changed",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.redacted, mailer.RedactTokens(test.text))
+		})
+	}
+}
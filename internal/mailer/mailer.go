@@ -0,0 +1,17 @@
+package mailer
+
+import (
+	"context"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer has the method Send that may be implemented by any email delivery service
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
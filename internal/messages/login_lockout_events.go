@@ -0,0 +1,81 @@
+package messages
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewLoginLockoutEvents instantiate a LoginLockoutEvents publishing to the same queue as the UserEvents
+func NewLoginLockoutEvents(events *UserEvents, lockoutRepo models.LoginLockoutRepository) *LoginLockoutEvents {
+	return &LoginLockoutEvents{
+		events:            events,
+		lockoutRepository: lockoutRepo,
+	}
+}
+
+// LoginLockoutEvents implements models.LoginLockoutRepository and works as a wrapper broadcasting the locks and unlocks of the Users.
+// The failed logins themselves are never broadcast.
+type LoginLockoutEvents struct {
+	events            *UserEvents
+	lockoutRepository models.LoginLockoutRepository
+}
+
+// RecordLoginFailure is a method from LoginLockoutEvents sends a user.locked every time a failed login locks a User in the DB.
+func (s *LoginLockoutEvents) RecordLoginFailure(ctx context.Context, email string, maxAttempts int, lockFor time.Duration) (*models.LoginLockout, error) {
+	result, err := s.lockoutRepository.RecordLoginFailure(ctx, email, maxAttempts, lockFor)
+	if err != nil || !result.Locked {
+		return result, err
+	}
+
+	// After the User is locked successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "user.locked",
+		UserID:    result.UserID.String(),
+	})
+	if err != nil {
+		s.events.logger.Error("failed to marshal user.locked message", zap.Error(err))
+	} else {
+		s.events.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, nil
+}
+
+// UnlockUser is a method from LoginLockoutEvents sends a user.unlocked every time a locked User is unlocked successfully in the DB.
+func (s *LoginLockoutEvents) UnlockUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	wasLocked, err := s.lockoutRepository.UnlockUser(ctx, id)
+	if err != nil || !wasLocked {
+		return wasLocked, err
+	}
+
+	// After the User is unlocked successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "user.unlocked",
+		UserID:    id.String(),
+	})
+	if err != nil {
+		s.events.logger.Error("failed to marshal user.unlocked message", zap.Error(err))
+	} else {
+		s.events.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return wasLocked, nil
+}
+
+// FindLoginLockout is a method from LoginLockoutEvents that will simply bypass the call to the LoginLockoutRepository because we are not broadcasting any reading events.
+func (s *LoginLockoutEvents) FindLoginLockout(ctx context.Context, email string) (*models.LoginLockout, error) {
+	// Bypass directly to LoginLockoutRepository.FindLoginLockout
+	return s.lockoutRepository.FindLoginLockout(ctx, email)
+}
+
+// ResetLoginFailures is a method from LoginLockoutEvents that will simply bypass the call to the LoginLockoutRepository because only the locks are broadcast.
+func (s *LoginLockoutEvents) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	// Bypass directly to LoginLockoutRepository.ResetLoginFailures
+	return s.lockoutRepository.ResetLoginFailures(ctx, id)
+}
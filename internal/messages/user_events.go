@@ -9,18 +9,23 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/metrics"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
 )
 
-// NewUserEvents instantiate a UserEvents
-func NewUserEvents(logger *zap.Logger, ch *amqp.Channel, userRepo models.UserRepository) *UserEvents {
+// UsersQueue is the queue the User events are published to
+const UsersQueue = "users"
+
+// NewUserEvents instantiate a UserEvents, an event not published within the publishTimeout is dropped
+func NewUserEvents(logger *zap.Logger, ch *amqp.Channel, publishTimeout time.Duration, userRepo models.UserRepository) *UserEvents {
 	q, err := ch.QueueDeclare(
-		"users", // name
-		false,   // durable
-		false,   // delete when unused
-		false,   // exclusive
-		false,   // no-wait
-		nil,     // arguments
+		UsersQueue, // name
+		false,      // durable
+		false,      // delete when unused
+		false,      // exclusive
+		false,      // no-wait
+		nil,        // arguments
 	)
 	if err != nil {
 		logger.Fatal("failed to declare event queue", zap.Error(err))
@@ -29,6 +34,7 @@ func NewUserEvents(logger *zap.Logger, ch *amqp.Channel, userRepo models.UserRep
 	return &UserEvents{
 		queue:          q,
 		channel:        ch,
+		publishTimeout: publishTimeout,
 		logger:         logger,
 		userRepository: userRepo,
 	}
@@ -38,13 +44,14 @@ func NewUserEvents(logger *zap.Logger, ch *amqp.Channel, userRepo models.UserRep
 type UserEvents struct {
 	queue          amqp.Queue
 	channel        *amqp.Channel
+	publishTimeout time.Duration
 	logger         *zap.Logger
 	userRepository models.UserRepository
 }
 
 // sendEvent is a method responsbible for dispatching events to RabbitMQ
 func (s *UserEvents) sendEvent(ctx context.Context, msg []byte) error {
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.publishTimeout)
 	defer cancel()
 
 	err := s.channel.PublishWithContext(ctx,
@@ -57,13 +64,24 @@ func (s *UserEvents) sendEvent(ctx context.Context, msg []byte) error {
 			Body:        msg,
 		})
 	if err != nil {
-		s.logger.Fatal("failed to publish event message", zap.Error(err))
+		// The event is dropped, RabbitMQ being down must not take the service down with it
+		metrics.EventPublishFailures.Inc()
+		s.logger.Error("failed to publish event message", zap.Error(err))
 		return err
 	}
 
+	metrics.EventsPublished.Inc()
 	return nil
 }
 
+// publish sends the event in the background once the transaction of the ctx is committed, right away outside of one.
+// The event of a change rolled back with its transaction is never sent.
+func (s *UserEvents) publish(ctx context.Context, msg []byte) {
+	transaction.AfterCommit(ctx, func() {
+		go s.sendEvent(ctx, msg)
+	})
+}
+
 // CreateUser is a method from UserEvents sends a create_user every time a User is created successfully in the DB.
 func (s *UserEvents) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	result, err := s.userRepository.CreateUser(ctx, user)
@@ -80,12 +98,36 @@ func (s *UserEvents) CreateUser(ctx context.Context, user *models.User) (*models
 	if err != nil {
 		s.logger.Error("failed to marshal create_user message", zap.Error(err))
 	} else {
-		go s.sendEvent(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
 	}
 
 	return result, err
 }
 
+// CreateUsers is a method from UserEvents sends a create_user for every User created successfully in the DB by a batch.
+func (s *UserEvents) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	result, err := s.userRepository.CreateUsers(ctx, users)
+	if err != nil {
+		return result, err
+	}
+
+	// The batch is committed at once so the events are only generated after every User exists
+	for _, user := range result {
+		jsonEvent, err := json.Marshal(&models.UserEvent{
+			Operation: "create_user",
+			UserID:    user.ID.String(),
+			User:      user,
+		})
+		if err != nil {
+			s.logger.Error("failed to marshal create_user message", zap.Error(err))
+			continue
+		}
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, nil
+}
+
 // UpdateUser is a method from UserEvents sends a create_user every time a User is updated successfully in the DB.
 func (s *UserEvents) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	// Bypass to Repo
@@ -94,25 +136,151 @@ func (s *UserEvents) UpdateUser(ctx context.Context, user *models.User) (*models
 		return result, err
 	}
 
-	// After the user is updated successfully the metod generates the event
+	// After the user is updated successfully the metod generates the event, from the stored User which never holds the password
 	jsonEvent, err := json.Marshal(&models.UserEvent{
 		Operation: "update_user",
-		UserID:    user.ID.String(),
-		User:      user,
+		UserID:    result.ID.String(),
+		User:      result,
 	})
 	if err != nil {
 		s.logger.Error("failed to marshal update_user message", zap.Error(err))
 	} else {
-		go s.sendEvent(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
 	}
 
 	return result, err
 }
 
 // FindUsers is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events. (Maybe we do when we have a caching layer)
-func (s *UserEvents) FindUsers(ctx context.Context, user *models.User, pageToken string, limit int) (*models.UsersResponse, error) {
+func (s *UserEvents) FindUsers(ctx context.Context, user *models.User, includeDeleted bool, pageToken string, limit int) (*models.UsersResponse, error) {
 	// Bypass directly to UserRepository.FindUsers
-	return s.userRepository.FindUsers(ctx, user, pageToken, limit)
+	return s.userRepository.FindUsers(ctx, user, includeDeleted, pageToken, limit)
+}
+
+// ExportUsers is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) ExportUsers(ctx context.Context, user *models.User, includeDeleted bool, fn func(*models.User) error) error {
+	// Bypass directly to UserRepository.ExportUsers
+	return s.userRepository.ExportUsers(ctx, user, includeDeleted, fn)
+}
+
+// CountUsers is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) CountUsers(ctx context.Context, user *models.User, includeDeleted bool) (int64, error) {
+	// Bypass directly to UserRepository.CountUsers
+	return s.userRepository.CountUsers(ctx, user, includeDeleted)
+}
+
+// FindUserHistory is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) FindUserHistory(ctx context.Context, id uuid.UUID, pageToken string, limit int) (*models.AuditResponse, error) {
+	// Bypass directly to UserRepository.FindUserHistory
+	return s.userRepository.FindUserHistory(ctx, id, pageToken, limit)
+}
+
+// FindUser is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) FindUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	// Bypass directly to UserRepository.FindUser
+	return s.userRepository.FindUser(ctx, id)
+}
+
+// FindUserByEmail is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	// Bypass directly to UserRepository.FindUserByEmail
+	return s.userRepository.FindUserByEmail(ctx, email)
+}
+
+// FindUserAsOf is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) FindUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error) {
+	// Bypass directly to UserRepository.FindUserAsOf
+	return s.userRepository.FindUserAsOf(ctx, id, asOf)
+}
+
+// Authenticate is a method from UserEvents that will simply bypass the call to the UserRepository because we are not broadcasting any reading events.
+func (s *UserEvents) Authenticate(ctx context.Context, email string, password string) (*models.User, error) {
+	// Bypass directly to UserRepository.Authenticate
+	return s.userRepository.Authenticate(ctx, email, password)
+}
+
+// VerifyEmail is a method from UserEvents sends an update_user every time the email of a User is verified successfully in the DB.
+func (s *UserEvents) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	result, err := s.userRepository.VerifyEmail(ctx, token)
+	if err != nil {
+		return result, err
+	}
+
+	// After the email is verified successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "update_user",
+		UserID:    result.ID.String(),
+		User:      result,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal update_user message", zap.Error(err))
+	} else {
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// RevertUser is a method from UserEvents sends an update_user every time a User is reverted successfully in the DB, a revert is a regular update for the consumers.
+func (s *UserEvents) RevertUser(ctx context.Context, id uuid.UUID, version int64) (*models.User, error) {
+	result, err := s.userRepository.RevertUser(ctx, id, version)
+	if err != nil {
+		return result, err
+	}
+
+	// After the user is reverted successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "update_user",
+		UserID:    result.ID.String(),
+		User:      result,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal update_user message", zap.Error(err))
+	} else {
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// UpdateUsersByFilter is a method from UserEvents sends an update_user for every User updated in the DB, including the chunks committed before a failure.
+func (s *UserEvents) UpdateUsersByFilter(ctx context.Context, filter *models.User, patch *models.User) ([]*models.User, error) {
+	result, err := s.userRepository.UpdateUsersByFilter(ctx, filter, patch)
+
+	for _, user := range result {
+		jsonEvent, err := json.Marshal(&models.UserEvent{
+			Operation: "update_user",
+			UserID:    user.ID.String(),
+			User:      user,
+		})
+		if err != nil {
+			s.logger.Error("failed to marshal update_user message", zap.Error(err))
+			continue
+		}
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// RemoveUsersByFilter is a method from UserEvents sends a delete_user for every User deleted in the DB, including the chunks committed before a failure.
+func (s *UserEvents) RemoveUsersByFilter(ctx context.Context, filter *models.User) ([]uuid.UUID, error) {
+	result, err := s.userRepository.RemoveUsersByFilter(ctx, filter)
+
+	for _, id := range result {
+		jsonEvent, err := json.Marshal(&models.UserEvent{
+			Operation: "delete_user",
+			UserID:    id.String(),
+			User:      nil,
+		})
+		if err != nil {
+			s.logger.Error("failed to marshal delete_user message", zap.Error(err))
+			continue
+		}
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
 }
 
 // RemoveUser is a method from UserEvents sends a create_user every time a User is deleted successfully in the DB.
@@ -122,6 +290,11 @@ func (s *UserEvents) RemoveUser(ctx context.Context, id uuid.UUID) (int64, error
 		return result, err
 	}
 
+	// Nothing was deleted so there is nothing to broadcast
+	if result == 0 {
+		return result, nil
+	}
+
 	// After the user is deleted successfully the metod generates the event
 	jsonEvent, err := json.Marshal(&models.UserEvent{
 		Operation: "delete_user",
@@ -131,7 +304,98 @@ func (s *UserEvents) RemoveUser(ctx context.Context, id uuid.UUID) (int64, error
 	if err != nil {
 		s.logger.Error("failed to marshal delete_user message", zap.Error(err))
 	} else {
-		go s.sendEvent(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// RestoreUser is a method from UserEvents sends a restore_user every time a soft-deleted User is restored successfully in the DB.
+func (s *UserEvents) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	result, err := s.userRepository.RestoreUser(ctx, id)
+	if err != nil {
+		return result, err
+	}
+
+	// After the user is restored successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "restore_user",
+		UserID:    result.ID.String(),
+		User:      result,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal restore_user message", zap.Error(err))
+	} else {
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// PurgeUsers is a method from UserEvents sends a user.purged for every User permanently deleted from the DB.
+func (s *UserEvents) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	result, err := s.userRepository.PurgeUsers(ctx, deletedBefore)
+	if err != nil {
+		return result, err
+	}
+
+	for _, id := range result {
+		jsonEvent, err := json.Marshal(&models.UserEvent{
+			Operation: "user.purged",
+			UserID:    id.String(),
+			User:      nil,
+		})
+		if err != nil {
+			s.logger.Error("failed to marshal user.purged message", zap.Error(err))
+			continue
+		}
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, nil
+}
+
+// ResetPassword is a method from UserEvents sends a user.password_changed every time a password is reset successfully in the DB.
+// The event holds the User as read back from the DB, which never includes the password.
+func (s *UserEvents) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	result, err := s.userRepository.ResetPassword(ctx, token, password)
+	if err != nil {
+		return result, err
+	}
+
+	// After the password is changed successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "user.password_changed",
+		UserID:    result.ID.String(),
+		User:      result,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal user.password_changed message", zap.Error(err))
+	} else {
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
+	}
+
+	return result, err
+}
+
+// ChangePassword is a method from UserEvents sends a user.password_changed every time a password is changed successfully in the DB.
+// The event holds the User as read back from the DB, which never includes the password.
+func (s *UserEvents) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword string, password string) (*models.User, error) {
+	result, err := s.userRepository.ChangePassword(ctx, id, currentPassword, password)
+	if err != nil {
+		return result, err
+	}
+
+	// After the password is changed successfully the metod generates the event
+	jsonEvent, err := json.Marshal(&models.UserEvent{
+		Operation: "user.password_changed",
+		UserID:    result.ID.String(),
+		User:      result,
+	})
+	if err != nil {
+		s.logger.Error("failed to marshal user.password_changed message", zap.Error(err))
+	} else {
+		s.publish(ctx, jsonEvent) // We currently don't care if the event is being handled successfully by the RabbitMQ nor the response should wait for its sending.
 	}
 
 	return result, err
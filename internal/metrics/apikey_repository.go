@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewAPIKeyRepoMetrics instantiate a APIKeyRepoMetrics
+func NewAPIKeyRepoMetrics(apiKeyRepo models.APIKeyRepository) *APIKeyRepoMetrics {
+	return &APIKeyRepoMetrics{apiKeyRepository: apiKeyRepo}
+}
+
+// APIKeyRepoMetrics implements models.APIKeyRepository and works as a wrapper measuring the latency and counting the errors of every method
+type APIKeyRepoMetrics struct {
+	apiKeyRepository models.APIKeyRepository
+}
+
+// CreateAPIKey is a method from APIKeyRepoMetrics that measures the call to APIKeyRepository.CreateAPIKey
+func (s *APIKeyRepoMetrics) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	start := time.Now()
+	result, err := s.apiKeyRepository.CreateAPIKey(ctx, key)
+	observe("CreateAPIKey", start, err)
+	return result, err
+}
+
+// FindAPIKeys is a method from APIKeyRepoMetrics that measures the call to APIKeyRepository.FindAPIKeys
+func (s *APIKeyRepoMetrics) FindAPIKeys(ctx context.Context, userID uuid.UUID, includeRevoked bool) ([]*models.APIKey, error) {
+	start := time.Now()
+	result, err := s.apiKeyRepository.FindAPIKeys(ctx, userID, includeRevoked)
+	observe("FindAPIKeys", start, err)
+	return result, err
+}
+
+// RevokeAPIKey is a method from APIKeyRepoMetrics that measures the call to APIKeyRepository.RevokeAPIKey
+func (s *APIKeyRepoMetrics) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := s.apiKeyRepository.RevokeAPIKey(ctx, id)
+	observe("RevokeAPIKey", start, err)
+	return err
+}
+
+// AuthenticateAPIKey is a method from APIKeyRepoMetrics that measures the call to APIKeyRepository.AuthenticateAPIKey
+func (s *APIKeyRepoMetrics) AuthenticateAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	start := time.Now()
+	result, err := s.apiKeyRepository.AuthenticateAPIKey(ctx, hash)
+	observe("AuthenticateAPIKey", start, err)
+	return result, err
+}
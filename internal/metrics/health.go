@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/healthz"
+)
+
+var (
+	healthUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "health", "up"),
+		"Whether the dependency passed its health check (1) or not (0).",
+		[]string{"dependency", "critical"}, nil,
+	)
+	healthLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "health", "latency_seconds"),
+		"Latency of the last health check of the dependency.",
+		[]string{"dependency"}, nil,
+	)
+)
+
+// HealthCollector exposes the health checks of the Checker as gauges. They are run on every scrape, the cache of the Checker
+// keeps the probes and the scrapes from testing the dependencies more than once per its TTL.
+type HealthCollector struct {
+	Checker *healthz.Checker
+}
+
+func NewHealthCollector(checker *healthz.Checker) *HealthCollector {
+	return &HealthCollector{Checker: checker}
+}
+
+func (s *HealthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- healthUpDesc
+	ch <- healthLatencyDesc
+}
+
+func (s *HealthCollector) Collect(ch chan<- prometheus.Metric) {
+	report := s.Checker.Run(context.Background())
+	for _, check := range report.Checks {
+		up := 0.0
+		if check.Status == healthz.StatusOK {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(healthUpDesc, prometheus.GaugeValue, up, check.Name, strconv.FormatBool(check.Critical))
+		ch <- prometheus.MustNewConstMetric(healthLatencyDesc, prometheus.GaugeValue, check.LatencyMS/1000, check.Name)
+	}
+}
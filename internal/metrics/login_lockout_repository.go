@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewLoginLockoutRepoMetrics instantiate a LoginLockoutRepoMetrics
+func NewLoginLockoutRepoMetrics(lockoutRepo models.LoginLockoutRepository) *LoginLockoutRepoMetrics {
+	return &LoginLockoutRepoMetrics{lockoutRepository: lockoutRepo}
+}
+
+// LoginLockoutRepoMetrics implements models.LoginLockoutRepository and works as a wrapper measuring the latency and counting the errors of every method
+type LoginLockoutRepoMetrics struct {
+	lockoutRepository models.LoginLockoutRepository
+}
+
+// FindLoginLockout is a method from LoginLockoutRepoMetrics that measures the call to LoginLockoutRepository.FindLoginLockout
+func (s *LoginLockoutRepoMetrics) FindLoginLockout(ctx context.Context, email string) (*models.LoginLockout, error) {
+	start := time.Now()
+	result, err := s.lockoutRepository.FindLoginLockout(ctx, email)
+	observe("FindLoginLockout", start, err)
+	return result, err
+}
+
+// RecordLoginFailure is a method from LoginLockoutRepoMetrics that measures the call to LoginLockoutRepository.RecordLoginFailure
+func (s *LoginLockoutRepoMetrics) RecordLoginFailure(ctx context.Context, email string, maxAttempts int, lockFor time.Duration) (*models.LoginLockout, error) {
+	start := time.Now()
+	result, err := s.lockoutRepository.RecordLoginFailure(ctx, email, maxAttempts, lockFor)
+	observe("RecordLoginFailure", start, err)
+	return result, err
+}
+
+// ResetLoginFailures is a method from LoginLockoutRepoMetrics that measures the call to LoginLockoutRepository.ResetLoginFailures
+func (s *LoginLockoutRepoMetrics) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := s.lockoutRepository.ResetLoginFailures(ctx, id)
+	observe("ResetLoginFailures", start, err)
+	return err
+}
+
+// UnlockUser is a method from LoginLockoutRepoMetrics that measures the call to LoginLockoutRepository.UnlockUser
+func (s *LoginLockoutRepoMetrics) UnlockUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	start := time.Now()
+	result, err := s.lockoutRepository.UnlockUser(ctx, id)
+	observe("UnlockUser", start, err)
+	return result, err
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace prefixes every metric of the service
+const namespace = "manage_user"
+
+// HTTPRequestDuration is the latency of the HTTP requests, by method, route (the path template, e.g. /api/users/:id) and status code
+var HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "http",
+	Name:      "request_duration_seconds",
+	Help:      "Latency of the HTTP requests by method, route and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// RepositoryDuration is the latency of the calls to the UserRepository, by method
+var RepositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "repository",
+	Name:      "duration_seconds",
+	Help:      "Latency of the UserRepository calls by method.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method"})
+
+// RepositoryErrors counts the calls to the UserRepository that returned an error, by method
+var RepositoryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "repository",
+	Name:      "errors_total",
+	Help:      "UserRepository calls that returned an error by method.",
+}, []string{"method"})
+
+// EventsPublished counts the User events published to RabbitMQ
+var EventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "events",
+	Name:      "published_total",
+	Help:      "User events published to RabbitMQ.",
+})
+
+// EventPublishFailures counts the User events that could not be published, they are dropped
+var EventPublishFailures = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "events",
+	Name:      "publish_failures_total",
+	Help:      "User events that failed to be published to RabbitMQ and were dropped.",
+})
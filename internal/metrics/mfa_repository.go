@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewMFARepoMetrics instantiate a MFARepoMetrics
+func NewMFARepoMetrics(mfaRepo models.MFARepository) *MFARepoMetrics {
+	return &MFARepoMetrics{mfaRepository: mfaRepo}
+}
+
+// MFARepoMetrics implements models.MFARepository and works as a wrapper measuring the latency and counting the errors of every method
+type MFARepoMetrics struct {
+	mfaRepository models.MFARepository
+}
+
+// SaveTOTPSecret is a method from MFARepoMetrics that measures the call to MFARepository.SaveTOTPSecret
+func (s *MFARepoMetrics) SaveTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	start := time.Now()
+	err := s.mfaRepository.SaveTOTPSecret(ctx, id, secret)
+	observe("SaveTOTPSecret", start, err)
+	return err
+}
+
+// FindUserMFA is a method from MFARepoMetrics that measures the call to MFARepository.FindUserMFA
+func (s *MFARepoMetrics) FindUserMFA(ctx context.Context, id uuid.UUID) (*models.UserMFA, error) {
+	start := time.Now()
+	result, err := s.mfaRepository.FindUserMFA(ctx, id)
+	observe("FindUserMFA", start, err)
+	return result, err
+}
+
+// ConfirmTOTP is a method from MFARepoMetrics that measures the call to MFARepository.ConfirmTOTP
+func (s *MFARepoMetrics) ConfirmTOTP(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	start := time.Now()
+	err := s.mfaRepository.ConfirmTOTP(ctx, id, step, recoveryCodeHashes)
+	observe("ConfirmTOTP", start, err)
+	return err
+}
+
+// UseTOTPStep is a method from MFARepoMetrics that measures the call to MFARepository.UseTOTPStep
+func (s *MFARepoMetrics) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	start := time.Now()
+	err := s.mfaRepository.UseTOTPStep(ctx, id, step)
+	observe("UseTOTPStep", start, err)
+	return err
+}
+
+// ReplaceRecoveryCodes is a method from MFARepoMetrics that measures the call to MFARepository.ReplaceRecoveryCodes
+func (s *MFARepoMetrics) ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error {
+	start := time.Now()
+	err := s.mfaRepository.ReplaceRecoveryCodes(ctx, id, recoveryCodeHashes)
+	observe("ReplaceRecoveryCodes", start, err)
+	return err
+}
+
+// UseRecoveryCode is a method from MFARepoMetrics that measures the call to MFARepository.UseRecoveryCode
+func (s *MFARepoMetrics) UseRecoveryCode(ctx context.Context, id uuid.UUID, recoveryCodeHash string) error {
+	start := time.Now()
+	err := s.mfaRepository.UseRecoveryCode(ctx, id, recoveryCodeHash)
+	observe("UseRecoveryCode", start, err)
+	return err
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewPasswordHistoryRepoMetrics instantiate a PasswordHistoryRepoMetrics
+func NewPasswordHistoryRepoMetrics(historyRepo models.PasswordHistoryRepository) *PasswordHistoryRepoMetrics {
+	return &PasswordHistoryRepoMetrics{historyRepository: historyRepo}
+}
+
+// PasswordHistoryRepoMetrics implements models.PasswordHistoryRepository and works as a wrapper measuring the latency and counting the errors of every method
+type PasswordHistoryRepoMetrics struct {
+	historyRepository models.PasswordHistoryRepository
+}
+
+// MatchPasswordHistory is a method from PasswordHistoryRepoMetrics that measures the call to PasswordHistoryRepository.MatchPasswordHistory
+func (s *PasswordHistoryRepoMetrics) MatchPasswordHistory(ctx context.Context, id uuid.UUID, password string, last int) (int, error) {
+	start := time.Now()
+	result, err := s.historyRepository.MatchPasswordHistory(ctx, id, password, last)
+	observe("MatchPasswordHistory", start, err)
+	return result, err
+}
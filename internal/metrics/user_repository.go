@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewUserRepoMetrics instantiate a UserRepoMetrics
+func NewUserRepoMetrics(userRepo models.UserRepository) *UserRepoMetrics {
+	return &UserRepoMetrics{userRepository: userRepo}
+}
+
+// UserRepoMetrics implements models.UserRepository and works as a wrapper measuring the latency and counting the errors of every method.
+// Every error is counted, the ones a client caused (e.g. a User not found) too.
+type UserRepoMetrics struct {
+	userRepository models.UserRepository
+}
+
+// observe records the latency of the call of the method and counts its error
+func observe(method string, start time.Time, err error) {
+	RepositoryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		RepositoryErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// CreateUser is a method from UserRepoMetrics that measures the call to UserRepository.CreateUser
+func (s *UserRepoMetrics) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.CreateUser(ctx, user)
+	observe("CreateUser", start, err)
+	return result, err
+}
+
+// CreateUsers is a method from UserRepoMetrics that measures the call to UserRepository.CreateUsers
+func (s *UserRepoMetrics) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.CreateUsers(ctx, users)
+	observe("CreateUsers", start, err)
+	return result, err
+}
+
+// FindUsers is a method from UserRepoMetrics that measures the call to UserRepository.FindUsers
+func (s *UserRepoMetrics) FindUsers(ctx context.Context, user *models.User, includeDeleted bool, pageToken string, limit int) (*models.UsersResponse, error) {
+	start := time.Now()
+	result, err := s.userRepository.FindUsers(ctx, user, includeDeleted, pageToken, limit)
+	observe("FindUsers", start, err)
+	return result, err
+}
+
+// ExportUsers is a method from UserRepoMetrics that measures the call to UserRepository.ExportUsers, the time fn takes to write the Users included
+func (s *UserRepoMetrics) ExportUsers(ctx context.Context, user *models.User, includeDeleted bool, fn func(*models.User) error) error {
+	start := time.Now()
+	err := s.userRepository.ExportUsers(ctx, user, includeDeleted, fn)
+	observe("ExportUsers", start, err)
+	return err
+}
+
+// CountUsers is a method from UserRepoMetrics that measures the call to UserRepository.CountUsers
+func (s *UserRepoMetrics) CountUsers(ctx context.Context, user *models.User, includeDeleted bool) (int64, error) {
+	start := time.Now()
+	result, err := s.userRepository.CountUsers(ctx, user, includeDeleted)
+	observe("CountUsers", start, err)
+	return result, err
+}
+
+// UpdateUser is a method from UserRepoMetrics that measures the call to UserRepository.UpdateUser
+func (s *UserRepoMetrics) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.UpdateUser(ctx, user)
+	observe("UpdateUser", start, err)
+	return result, err
+}
+
+// UpdateUsersByFilter is a method from UserRepoMetrics that measures the call to UserRepository.UpdateUsersByFilter
+func (s *UserRepoMetrics) UpdateUsersByFilter(ctx context.Context, filter *models.User, patch *models.User) ([]*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.UpdateUsersByFilter(ctx, filter, patch)
+	observe("UpdateUsersByFilter", start, err)
+	return result, err
+}
+
+// RemoveUsersByFilter is a method from UserRepoMetrics that measures the call to UserRepository.RemoveUsersByFilter
+func (s *UserRepoMetrics) RemoveUsersByFilter(ctx context.Context, filter *models.User) ([]uuid.UUID, error) {
+	start := time.Now()
+	result, err := s.userRepository.RemoveUsersByFilter(ctx, filter)
+	observe("RemoveUsersByFilter", start, err)
+	return result, err
+}
+
+// RemoveUser is a method from UserRepoMetrics that measures the call to UserRepository.RemoveUser
+func (s *UserRepoMetrics) RemoveUser(ctx context.Context, ID uuid.UUID) (int64, error) {
+	start := time.Now()
+	result, err := s.userRepository.RemoveUser(ctx, ID)
+	observe("RemoveUser", start, err)
+	return result, err
+}
+
+// RestoreUser is a method from UserRepoMetrics that measures the call to UserRepository.RestoreUser
+func (s *UserRepoMetrics) RestoreUser(ctx context.Context, ID uuid.UUID) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.RestoreUser(ctx, ID)
+	observe("RestoreUser", start, err)
+	return result, err
+}
+
+// PurgeUsers is a method from UserRepoMetrics that measures the call to UserRepository.PurgeUsers
+func (s *UserRepoMetrics) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	start := time.Now()
+	result, err := s.userRepository.PurgeUsers(ctx, deletedBefore)
+	observe("PurgeUsers", start, err)
+	return result, err
+}
+
+// FindUserHistory is a method from UserRepoMetrics that measures the call to UserRepository.FindUserHistory
+func (s *UserRepoMetrics) FindUserHistory(ctx context.Context, id uuid.UUID, pageToken string, limit int) (*models.AuditResponse, error) {
+	start := time.Now()
+	result, err := s.userRepository.FindUserHistory(ctx, id, pageToken, limit)
+	observe("FindUserHistory", start, err)
+	return result, err
+}
+
+// FindUser is a method from UserRepoMetrics that measures the call to UserRepository.FindUser
+func (s *UserRepoMetrics) FindUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.FindUser(ctx, id)
+	observe("FindUser", start, err)
+	return result, err
+}
+
+// FindUserByEmail is a method from UserRepoMetrics that measures the call to UserRepository.FindUserByEmail
+func (s *UserRepoMetrics) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.FindUserByEmail(ctx, email)
+	observe("FindUserByEmail", start, err)
+	return result, err
+}
+
+// FindUserAsOf is a method from UserRepoMetrics that measures the call to UserRepository.FindUserAsOf
+func (s *UserRepoMetrics) FindUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.FindUserAsOf(ctx, id, asOf)
+	observe("FindUserAsOf", start, err)
+	return result, err
+}
+
+// RevertUser is a method from UserRepoMetrics that measures the call to UserRepository.RevertUser
+func (s *UserRepoMetrics) RevertUser(ctx context.Context, id uuid.UUID, version int64) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.RevertUser(ctx, id, version)
+	observe("RevertUser", start, err)
+	return result, err
+}
+
+// VerifyEmail is a method from UserRepoMetrics that measures the call to UserRepository.VerifyEmail
+func (s *UserRepoMetrics) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.VerifyEmail(ctx, token)
+	observe("VerifyEmail", start, err)
+	return result, err
+}
+
+// ResetPassword is a method from UserRepoMetrics that measures the call to UserRepository.ResetPassword
+func (s *UserRepoMetrics) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.ResetPassword(ctx, token, password)
+	observe("ResetPassword", start, err)
+	return result, err
+}
+
+// ChangePassword is a method from UserRepoMetrics that measures the call to UserRepository.ChangePassword
+func (s *UserRepoMetrics) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword string, password string) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.ChangePassword(ctx, id, currentPassword, password)
+	observe("ChangePassword", start, err)
+	return result, err
+}
+
+// Authenticate is a method from UserRepoMetrics that measures the call to UserRepository.Authenticate
+func (s *UserRepoMetrics) Authenticate(ctx context.Context, email string, password string) (*models.User, error) {
+	start := time.Now()
+	result, err := s.userRepository.Authenticate(ctx, email, password)
+	observe("Authenticate", start, err)
+	return result, err
+}
@@ -0,0 +1,53 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/metrics"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+)
+
+func TestUserRepoMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	userRepo := metrics.NewUserRepoMetrics(mockedRepo)
+	errorsBefore := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("FindUser"))
+
+	// The result and the error are passed through, only the error is counted
+	user := &models.User{ID: uuid.New()}
+	mockedRepo.EXPECT().FindUser(gomock.Any(), user.ID).Return(user, nil)
+	result, err := userRepo.FindUser(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+	assert.Equal(t, errorsBefore, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("FindUser")))
+
+	mockedRepo.EXPECT().FindUser(gomock.Any(), user.ID).Return(nil, models.ErrUserNotFound)
+	_, err = userRepo.FindUser(context.Background(), user.ID)
+	assert.True(t, errors.Is(err, models.ErrUserNotFound))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("FindUser")))
+}
+
+func TestAPIKeyRepoMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockAPIKeyRepository(ctrl)
+	apiKeyRepo := metrics.NewAPIKeyRepoMetrics(mockedRepo)
+	errorsBefore := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("AuthenticateAPIKey"))
+
+	// The stores split out of UserRepository are labelled by their method name the same way
+	mockedRepo.EXPECT().AuthenticateAPIKey(gomock.Any(), "hash").Return(nil, models.ErrInvalidAPIKey)
+	_, err := apiKeyRepo.AuthenticateAPIKey(context.Background(), "hash")
+	assert.True(t, errors.Is(err, models.ErrInvalidAPIKey))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("AuthenticateAPIKey")))
+}
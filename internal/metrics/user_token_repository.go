@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// NewUserTokenRepoMetrics instantiate a UserTokenRepoMetrics
+func NewUserTokenRepoMetrics(tokenRepo models.UserTokenRepository) *UserTokenRepoMetrics {
+	return &UserTokenRepoMetrics{tokenRepository: tokenRepo}
+}
+
+// UserTokenRepoMetrics implements models.UserTokenRepository and works as a wrapper measuring the latency and counting the errors of every method
+type UserTokenRepoMetrics struct {
+	tokenRepository models.UserTokenRepository
+}
+
+// CreateUserToken is a method from UserTokenRepoMetrics that measures the call to UserTokenRepository.CreateUserToken
+func (s *UserTokenRepoMetrics) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	start := time.Now()
+	err := s.tokenRepository.CreateUserToken(ctx, token)
+	observe("CreateUserToken", start, err)
+	return err
+}
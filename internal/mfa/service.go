@@ -0,0 +1,191 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/secrets"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10 // Characters of base32, 50 bits each code
+)
+
+// Service implements models.MFAService, the TOTP secrets are sealed by the Box before they reach the MFARepository
+type Service struct {
+	mfaRepository  models.MFARepository
+	userRepository models.UserRepository
+	box            *secrets.Box
+	issuer         string
+	now            func() time.Time
+}
+
+func NewService(issuer string, box *secrets.Box, mfaRepo models.MFARepository, userRepo models.UserRepository) *Service {
+	return &Service{
+		mfaRepository:  mfaRepo,
+		userRepository: userRepo,
+		box:            box,
+		issuer:         issuer,
+		now:            time.Now,
+	}
+}
+
+func (s *Service) EnrollTOTP(ctx context.Context, id uuid.UUID) (*models.TOTPEnrollment, error) {
+	user, err := s.userRepository.FindUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("enrolltotp failed: %w", err)
+	}
+	if err := s.mfaRepository.SaveTOTPSecret(ctx, id, sealed); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: base32NoPadding.EncodeToString(secret),
+		URI:    uri(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *Service) ConfirmTOTP(ctx context.Context, id uuid.UUID, code string) (*models.RecoveryCodes, error) {
+	enrollment, err := s.mfaRepository.FindUserMFA(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.ConfirmedAt != nil {
+		return nil, fmt.Errorf("confirmtotp failed: %w", models.ErrMFAEnrolled)
+	}
+
+	step, err := s.validate(enrollment, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepository.ConfirmTOTP(ctx, id, step, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, id uuid.UUID, code string) (*models.RecoveryCodes, error) {
+	enrollment, err := s.confirmedEnrollment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.useTOTPCode(ctx, enrollment, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(ctx, id, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+func (s *Service) VerifySecondFactor(ctx context.Context, id uuid.UUID, totpCode string, recoveryCode string) error {
+	enrollment, err := s.confirmedEnrollment(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrMFANotEnrolled) {
+			// The second factor is only required once the enrollment is confirmed
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case totpCode != "":
+		return s.useTOTPCode(ctx, enrollment, totpCode)
+	case recoveryCode != "":
+		return s.mfaRepository.UseRecoveryCode(ctx, id, tokens.Hash(normalizeRecoveryCode(recoveryCode)))
+	default:
+		return models.ErrMFARequired
+	}
+}
+
+// confirmedEnrollment returns the enrollment of the User, or ErrMFANotEnrolled when it isn't confirmed yet
+func (s *Service) confirmedEnrollment(ctx context.Context, id uuid.UUID) (*models.UserMFA, error) {
+	enrollment, err := s.mfaRepository.FindUserMFA(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.ConfirmedAt == nil {
+		return nil, fmt.Errorf("mfa enrollment not confirmed: %w", models.ErrMFANotEnrolled)
+	}
+	return enrollment, nil
+}
+
+// useTOTPCode checks the code and records its step, so the same code can't be used twice
+func (s *Service) useTOTPCode(ctx context.Context, enrollment *models.UserMFA, code string) error {
+	step, err := s.validate(enrollment, code)
+	if err != nil {
+		return err
+	}
+	if step <= enrollment.LastUsedStep {
+		return fmt.Errorf("totp code already used: %w", models.ErrInvalidMFACode)
+	}
+	return s.mfaRepository.UseTOTPStep(ctx, enrollment.UserID, step)
+}
+
+// validate opens the secret of the enrollment and returns the step of the code
+func (s *Service) validate(enrollment *models.UserMFA, code string) (int64, error) {
+	secret, err := s.box.Open(enrollment.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("totp secret failed: %w", err)
+	}
+	step, ok := validate(secret, strings.TrimSpace(code), s.now())
+	if !ok {
+		return 0, models.ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+// newRecoveryCodes returns new recovery codes formatted as xxxxx-xxxxx and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	raw := make([]byte, recoveryCodeSize*5/8)
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("recovery code generation failed: %w", err)
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(raw))
+		codes[i] = code[:recoveryCodeSize/2] + "-" + code[recoveryCodeSize/2:]
+		hashes[i] = tokens.Hash(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts the code typed with any case, spaces or dashes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
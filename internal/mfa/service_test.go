@@ -0,0 +1,56 @@
+package mfa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/secrets"
+)
+
+func TestUseTOTPCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockMFARepository(ctrl)
+
+	box, err := secrets.NewBox([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	sealed, err := box.Seal(rfc6238Secret)
+	assert.NoError(t, err)
+
+	now := time.Unix(1234567890, 0)
+	service := NewService("manage_user_go", box, mockedRepo, nil)
+	service.now = func() time.Time { return now }
+
+	confirmedAt := now.Add(-time.Hour)
+	enrollment := &models.UserMFA{UserID: uuid.New(), Secret: sealed, ConfirmedAt: &confirmedAt}
+	current := step(now)
+
+	// The step of the code is recorded
+	mockedRepo.EXPECT().UseTOTPStep(gomock.Any(), enrollment.UserID, current).Return(nil)
+	assert.NoError(t, service.useTOTPCode(context.Background(), enrollment, code(rfc6238Secret, current)))
+	enrollment.LastUsedStep = current
+
+	// The same code is refused, even within its step
+	err = service.useTOTPCode(context.Background(), enrollment, code(rfc6238Secret, current))
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+
+	// So is the code of the step before, still within the skew
+	err = service.useTOTPCode(context.Background(), enrollment, code(rfc6238Secret, current-1))
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+
+	// The code of the next step is accepted
+	mockedRepo.EXPECT().UseTOTPStep(gomock.Any(), enrollment.UserID, current+1).Return(nil)
+	assert.NoError(t, service.useTOTPCode(context.Background(), enrollment, code(rfc6238Secret, current+1)))
+
+	// A wrong code records nothing
+	err = service.useTOTPCode(context.Background(), enrollment, "000000")
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+}
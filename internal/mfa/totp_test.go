@@ -0,0 +1,67 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA-1 seed of the test vectors of RFC 6238 Appendix B
+var rfc6238Secret = []byte("12345678901234567890")
+
+// The RFC 6238 Appendix B SHA-1 codes have 8 digits, ours are their last 6
+var rfc6238Vectors = []struct {
+	unix int64
+	step int64
+	code string
+}{
+	{unix: 59, step: 0x1, code: "287082"},                 // 94287082
+	{unix: 1111111109, step: 0x23523EC, code: "081804"},   // 07081804
+	{unix: 1111111111, step: 0x23523ED, code: "050471"},   // 14050471
+	{unix: 1234567890, step: 0x273EF07, code: "005924"},   // 89005924
+	{unix: 2000000000, step: 0x3F940AA, code: "279037"},   // 69279037
+	{unix: 20000000000, step: 0x27BC86AA, code: "353130"}, // 65353130
+}
+
+func TestCode(t *testing.T) {
+	for _, vector := range rfc6238Vectors {
+		t.Run(vector.code, func(t *testing.T) {
+			now := time.Unix(vector.unix, 0)
+			assert.Equal(t, vector.step, step(now))
+			assert.Equal(t, vector.code, code(rfc6238Secret, step(now)))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, vector := range rfc6238Vectors {
+		t.Run(vector.code, func(t *testing.T) {
+			now := time.Unix(vector.unix, 0)
+
+			s, ok := validate(rfc6238Secret, vector.code, now)
+			assert.True(t, ok)
+			assert.Equal(t, vector.step, s)
+
+			// A clock one step behind or ahead is accepted
+			s, ok = validate(rfc6238Secret, vector.code, now.Add(-period))
+			assert.True(t, ok)
+			assert.Equal(t, vector.step, s)
+			s, ok = validate(rfc6238Secret, vector.code, now.Add(period))
+			assert.True(t, ok)
+			assert.Equal(t, vector.step, s)
+
+			// Two steps away is not
+			_, ok = validate(rfc6238Secret, vector.code, now.Add(2*period))
+			assert.False(t, ok)
+			if vector.step >= 2 { // The steps before the epoch round to 0
+				_, ok = validate(rfc6238Secret, vector.code, now.Add(-2*period))
+				assert.False(t, ok)
+			}
+
+			// Nor another secret
+			_, ok = validate([]byte("09876543210987654321"), vector.code, now)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -1,17 +1,163 @@
 package migrator
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/migrations"
 )
 
-// MigrateDB function is used in a quite simple form to to just migrate up the new Schema and Table
-func MigrateDB(dbURL string) error {
-	m, err := migrate.New("file://./migrations", dbURL)
+// lockID is the key of the Postgres advisory lock held while migrating, shared by every replica of the service
+const lockID int64 = 0x5553455253 // "USERS"
+
+// Migrator runs the migrations on the DB while holding the advisory lock, so only one replica migrates at a time.
+// The other replicas wait for the lock and then find nothing left to do.
+type Migrator struct {
+	conn *sql.Conn
+	m    *migrate.Migrate
+}
+
+// New takes the advisory lock and returns a Migrator of the migrations of the sourceURL (e.g. file://./migrations),
+// or of the migrations embedded in the binary when it is empty. It must be closed to release the lock.
+func New(ctx context.Context, db *sql.DB, sourceURL string) (*Migrator, error) {
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("migrator connection failed: %w", err)
 	}
-	if err := m.Up(); err != nil {
-		return err
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migrator lock failed: %w", err)
+	}
+
+	m, err := newMigrate(ctx, conn, sourceURL)
+	if err != nil {
+		unlock(conn)
+		conn.Close()
+		return nil, err
+	}
+	return &Migrator{conn: conn, m: m}, nil
+}
+
+// newMigrate returns a migrate.Migrate running on the conn, which is closed with it
+func newMigrate(ctx context.Context, conn *sql.Conn, sourceURL string) (*migrate.Migrate, error) {
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("migrator database failed: %w", err)
+	}
+
+	if sourceURL != "" {
+		m, err := migrate.NewWithDatabaseInstance(sourceURL, "postgres", driver)
+		if err != nil {
+			return nil, fmt.Errorf("migrator source failed: %w", err)
+		}
+		return m, nil
+	}
+
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator embedded source failed: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("migrator source failed: %w", err)
+	}
+	return m, nil
+}
+
+// unlock releases the advisory lock, which is released anyway when the connection is closed
+func unlock(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	return err
+}
+
+// Up applies every migration not applied yet, it returns migrate.ErrNoChange when there is none
+func (s *Migrator) Up() error {
+	return s.m.Up()
+}
+
+// Down reverts the last n migrations applied
+func (s *Migrator) Down(n int) error {
+	if n <= 0 {
+		return errors.New("the number of migrations to revert must be positive")
+	}
+	return s.m.Steps(-n)
+}
+
+// Goto applies or reverts the migrations up to the version
+func (s *Migrator) Goto(version uint) error {
+	return s.m.Migrate(version)
+}
+
+// Force sets the version without running any migration and clears the dirty flag, once a failed migration was fixed by hand.
+// A version of -1 means no migration applied.
+func (s *Migrator) Force(version int) error {
+	return s.m.Force(version)
+}
+
+// Version returns the version of the last migration applied, and whether it failed half way leaving the DB dirty.
+// It returns migrate.ErrNilVersion when no migration was applied.
+func (s *Migrator) Version() (uint, bool, error) {
+	return s.m.Version()
+}
+
+// Close releases the advisory lock and the connection
+func (s *Migrator) Close() error {
+	unlockErr := unlock(s.conn)
+	sourceErr, dbErr := s.m.Close()
+	for _, err := range []error{unlockErr, sourceErr, dbErr} {
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// MigrateDB applies every migration not applied yet, of the sourceURL or the embedded ones when it is empty.
+// A DB left dirty by a failed migration returns a migrate.ErrDirty, to be fixed with Force.
+func MigrateDB(ctx context.Context, db *sql.DB, sourceURL string) error {
+	m, err := New(ctx, db, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.Up()
+}
+
+// LatestVersion returns the version of the last migration of the sourceURL, or of the embedded ones when it is empty,
+// the version the DB is expected to be at once migrated
+func LatestVersion(sourceURL string) (uint, error) {
+	var driver source.Driver
+	var err error
+	if sourceURL != "" {
+		driver, err = source.Open(sourceURL)
+	} else {
+		driver, err = iofs.New(migrations.FS, ".")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("latest version source failed: %w", err)
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("latest version failed: %w", err)
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("latest version failed: %w", err)
+		}
+		version = next
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key may carry, a route of a resource requires "<resource>:read" for GET requests and "<resource>:write" otherwise.
+// The admin operations (e.g. restoring or reverting a User, or acting on the keys of other Users) require ScopeAdmin on top.
+const (
+	ScopeUsersRead    = "users:read"
+	ScopeUsersWrite   = "users:write"
+	ScopeAPIKeysRead  = "apikeys:read"
+	ScopeAPIKeysWrite = "apikeys:write"
+	ScopeAdmin        = "admin"
+)
+
+// Scopes are every scope an API key may carry
+var Scopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAPIKeysRead, ScopeAPIKeysWrite, ScopeAdmin}
+
+// ErrAPIKeyNotFound is returned when the API key targeted by an operation does not exist (or is revoked)
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// ErrInvalidAPIKey is returned when an API key is unknown, expired or revoked, or its User is deleted
+var ErrInvalidAPIKey = errors.New("invalid or expired api key")
+
+// APIKey is a key a client authenticates with as its User, usually a service account. Only the Hash of the key is stored.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // The start of the key, to tell the keys apart
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Null for a key that never expires
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Hash       string     `json:"-"`
+}
+
+// HasScope returns whether the key carries the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedAPIKey is the response of the creation of an API key, the only time the Key is shown
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// APIKeysResponse is the response of the method Get all API keys
+type APIKeysResponse struct {
+	APIKeys []*APIKey `json:"api_keys"`
+}
+
+// APIKeyRepository stores the API keys of the Users
+type APIKeyRepository interface {
+	// CreateAPIKey stores a new API key of the User and returns it with its new ID
+	CreateAPIKey(ctx context.Context, key *APIKey) (*APIKey, error)
+	// FindAPIKeys returns the API keys, of every User when userID is uuid.Nil. Revoked keys are only returned when includeRevoked is true.
+	FindAPIKeys(ctx context.Context, userID uuid.UUID, includeRevoked bool) ([]*APIKey, error)
+	// RevokeAPIKey revokes the API key, it can't be used anymore
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	// AuthenticateAPIKey returns the API key with the hash and records it was used, or ErrInvalidAPIKey
+	AuthenticateAPIKey(ctx context.Context, hash string) (*APIKey, error)
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit operations recorded in the history of a User
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// FieldChange is the value of a User field before and after a mutation, nil means the field had no value
+type FieldChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+// AuditEntry is an immutable record of a mutation of a User, chained to the previous entry of the same User by hash
+type AuditEntry struct {
+	ID        int64                   `json:"id"`
+	UserID    uuid.UUID               `json:"user_id"`
+	Operation string                  `json:"operation"`
+	Actor     string                  `json:"actor"`
+	RequestID string                  `json:"request_id"`
+	SourceIP  string                  `json:"source_ip"`
+	Diff      map[string]*FieldChange `json:"diff"`
+	PrevHash  string                  `json:"prev_hash"`
+	Hash      string                  `json:"hash"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+// AuditResponse is a paginated response of the history of a User.
+// Verified tells if every entry of the page matches its hash and is linked to the entry before it.
+type AuditResponse struct {
+	Entries   []*AuditEntry `json:"entries"`
+	PageToken string        `json:"page_token"`
+	Verified  bool          `json:"verified"`
+}
+
+// ComputeHash returns the SHA-256 of the entry content and PrevHash. The ID and Hash are not part of it.
+// The diff is marshalled by encoding/json, which sorts map keys, so the hash does not depend on how the diff was stored.
+func (e *AuditEntry) ComputeHash() (string, error) {
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return "", fmt.Errorf("audit diff marshal failed: %w", err)
+	}
+
+	content := strings.Join([]string{
+		e.PrevHash,
+		e.UserID.String(),
+		e.Operation,
+		e.Actor,
+		e.RequestID,
+		e.SourceIP,
+		string(diff),
+		strconv.FormatInt(e.CreatedAt.UTC().UnixMicro(), 10),
+	}, "\n")
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyChain checks that every entry matches its hash and points to the hash of the entry before it.
+// prevHash is the hash of the entry preceding the first one, empty when the first entry is the first of the User.
+func VerifyChain(prevHash string, entries []*AuditEntry) error {
+	for _, entry := range entries {
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("audit entry %d is not linked to the previous entry", entry.ID)
+		}
+
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			return fmt.Errorf("audit entry %d does not match its hash", entry.ID)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// ReplayHistory rebuilds the state of a User by applying the new values of its audit entries, oldest first.
+// It returns ErrUserNotFound without entries and ErrUserGone when the User is deleted or purged after the last entry.
+// The password is never part of the rebuilt User since the audit only records that it changed.
+func ReplayHistory(entries []*AuditEntry) (*User, error) {
+	if len(entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	user := &User{ID: entries[0].UserID}
+	for _, entry := range entries {
+		if entry.Operation == AuditPurge {
+			return nil, ErrUserGone
+		}
+		for field, change := range entry.Diff {
+			if err := user.applyChange(field, change.New); err != nil {
+				return nil, fmt.Errorf("audit entry %d replay failed: %w", entry.ID, err)
+			}
+		}
+	}
+
+	if user.DeletedAt != nil {
+		return nil, ErrUserGone
+	}
+	return user, nil
+}
+
+// applyChange sets a field of the User from its value in an audit diff
+func (u *User) applyChange(field string, value *string) error {
+	var s string
+	if value != nil {
+		s = *value
+	}
+
+	var err error
+	switch field {
+	case "first_name":
+		u.FirstName = s
+	case "last_name":
+		u.LastName = s
+	case "nickname":
+		u.Nickname = s
+	case "email":
+		u.Email = s
+	case "country":
+		u.Country = s
+	case "service_account":
+		u.ServiceAccount = s == "true"
+	case "created_at":
+		u.CreatedAt, err = parseAuditTime(value)
+	case "updated_at":
+		u.UpdatedAt, err = parseAuditTime(value)
+	case "email_verified_at":
+		u.EmailVerifiedAt = nil
+		if value != nil {
+			var emailVerifiedAt time.Time
+			emailVerifiedAt, err = parseAuditTime(value)
+			u.EmailVerifiedAt = &emailVerifiedAt
+		}
+	case "deleted_at":
+		u.DeletedAt = nil
+		if value != nil {
+			var deletedAt time.Time
+			deletedAt, err = parseAuditTime(value)
+			u.DeletedAt = &deletedAt
+		}
+	}
+	return err
+}
+
+func parseAuditTime(value *string) (time.Time, error) {
+	if value == nil {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, *value)
+}
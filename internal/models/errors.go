@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUserNotFound is returned when the User targeted by an operation does not exist (or is soft-deleted)
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUserGone is returned when the User was deleted at the point in time being reconstructed
+var ErrUserGone = errors.New("user was deleted")
+
+// ErrVersionNotFound is returned when the audit entry a User is reverted to is not in its history
+var ErrVersionNotFound = errors.New("user version not found")
+
+// ErrInvalidToken is returned when a token sent to a User is unknown, expired or already used
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrWrongPassword is returned when the current password given to change it does not match
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrInvalidCredentials is returned when no User has the email and password given to login
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrMFARequired is returned when a User enrolled in MFA logs in without a TOTP or recovery code
+var ErrMFARequired = errors.New("totp code required")
+
+// ErrInvalidMFACode is returned when a TOTP code is wrong or already used, or a recovery code is unknown or already used
+var ErrInvalidMFACode = errors.New("invalid mfa code")
+
+// ErrMFAEnrolled is returned when enrolling a User whose TOTP enrollment is already confirmed
+var ErrMFAEnrolled = errors.New("mfa already enrolled")
+
+// ErrMFANotEnrolled is returned when the User has no TOTP enrollment (or no confirmed one, for the calls that need it)
+var ErrMFANotEnrolled = errors.New("mfa not enrolled")
+
+// DuplicateError is returned when a User field that must be unique (e.g. email or nickname) is already taken
+type DuplicateError struct {
+	Field string
+	Err   error
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("user %s already exists", e.Field)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return e.Err
+}
+
+// Violation is a rule a field value does not satisfy, Code is meant for clients and Message for humans
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the values of a User break one or more rules
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Response returns the body describing every violation
+func (e *ValidationError) Response() *ErrorResponse {
+	return &ErrorResponse{Message: e.Error(), Violations: e.Violations}
+}
+
+// ErrorResponse is the body returned by the API when a request fails for a reason the client can act on
+type ErrorResponse struct {
+	Message    string      `json:"message"`
+	Field      string      `json:"field,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginLockout is the state of the failed logins of a User since its last successful one
+type LoginLockout struct {
+	UserID         uuid.UUID
+	FailedAttempts int
+	LastFailedAt   *time.Time
+	LockedUntil    *time.Time
+	// Locked is only true when the failure just recorded locked the User
+	Locked bool
+}
+
+// ThrottledError is returned when a login is refused before the credentials are checked, RetryAfter is how long until it is allowed again
+type ThrottledError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Reason, e.RetryAfter)
+}
+
+// LoginGuard protects the login against brute force, tracking the failures per User and per source IP
+type LoginGuard interface {
+	// Check returns a ThrottledError when the login for the email from the IP must be refused for now
+	Check(ctx context.Context, email string, ip string) error
+	// Fail records a failed login for the email from the IP
+	Fail(ctx context.Context, email string, ip string) error
+	// Succeed clears the failed logins of the User
+	Succeed(ctx context.Context, id uuid.UUID) error
+}
+
+// LoginLockoutRepository stores the failed logins and the locks of the Users
+type LoginLockoutRepository interface {
+	// FindLoginLockout returns the failed logins of the User with the email, an unknown email has none
+	FindLoginLockout(ctx context.Context, email string) (*LoginLockout, error)
+	// RecordLoginFailure counts a failed login of the User with the email, and locks it for lockFor once maxAttempts failed in a row.
+	// It fails with ErrUserNotFound when no User has the email.
+	RecordLoginFailure(ctx context.Context, email string, maxAttempts int, lockFor time.Duration) (*LoginLockout, error)
+	// ResetLoginFailures clears the failed logins of the User after a successful one
+	ResetLoginFailures(ctx context.Context, id uuid.UUID) error
+	// UnlockUser clears the failed logins and the lock of the User, and returns whether it was locked
+	UnlockUser(ctx context.Context, id uuid.UUID) (bool, error)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA is the TOTP enrollment of a User. The Secret is stored encrypted, it is only readable with the configured key.
+// LastUsedStep is the TOTP time step of the last accepted code, so a code can't be replayed.
+type UserMFA struct {
+	UserID       uuid.UUID
+	Secret       string
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+}
+
+// TOTPEnrollment is returned once when a User starts the TOTP enrollment, the URI is usually shown as a QR code
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are the one-time codes a User may login with instead of a TOTP code, they are only shown when generated
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// LoginRequest is the body of the login, TOTPCode or RecoveryCode are only needed by the Users enrolled in MFA
+type LoginRequest struct {
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	TOTPCode     string `json:"totp_code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// MFAService enrolls Users in TOTP and checks their second factor
+type MFAService interface {
+	// EnrollTOTP generates a new TOTP secret for the User, replacing any enrollment not confirmed yet
+	EnrollTOTP(ctx context.Context, id uuid.UUID) (*TOTPEnrollment, error)
+	// ConfirmTOTP confirms the enrollment with a code of the new secret and returns the first recovery codes
+	ConfirmTOTP(ctx context.Context, id uuid.UUID, code string) (*RecoveryCodes, error)
+	// RegenerateRecoveryCodes replaces every recovery code of the User, a current TOTP code is required
+	RegenerateRecoveryCodes(ctx context.Context, id uuid.UUID, code string) (*RecoveryCodes, error)
+	// VerifySecondFactor checks the TOTP or recovery code of the User, it does nothing for Users not enrolled
+	VerifySecondFactor(ctx context.Context, id uuid.UUID, totpCode string, recoveryCode string) error
+}
+
+// MFARepository stores the TOTP enrollments and the recovery codes of the Users
+type MFARepository interface {
+	// SaveTOTPSecret stores a new encrypted TOTP secret not confirmed yet, it fails with ErrMFAEnrolled when the User already confirmed one
+	SaveTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	// FindUserMFA returns the TOTP enrollment of the User, or ErrMFANotEnrolled
+	FindUserMFA(ctx context.Context, id uuid.UUID) (*UserMFA, error)
+	// ConfirmTOTP confirms the enrollment accepting the code of the given step, and stores the hashes of the first recovery codes
+	ConfirmTOTP(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep records the step of an accepted code, it fails with ErrInvalidMFACode when a code of that step or a later one was already used
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error
+	// ReplaceRecoveryCodes deletes every recovery code of the User and stores the hashes of the new ones
+	ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error
+	// UseRecoveryCode marks the recovery code with that hash as used, it fails with ErrInvalidMFACode when it is unknown or already used
+	UseRecoveryCode(ctx context.Context, id uuid.UUID, recoveryCodeHash string) error
+}
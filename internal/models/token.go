@@ -0,0 +1,30 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purposes of the single-use tokens sent to Users
+const (
+	TokenEmailVerification = "email_verification"
+	TokenPasswordReset     = "password_reset"
+)
+
+// UserToken is a single-use token sent to a User. Only the Hash of the token is stored.
+// Email is the address the token was sent to, so a token can't verify an email changed after it was issued.
+type UserToken struct {
+	Hash      string
+	Purpose   string
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+}
+
+// UserTokenRepository stores the single-use tokens sent to the Users, they are consumed by the UserRepository
+type UserTokenRepository interface {
+	// CreateUserToken stores a single-use token sent to a User, only its hash is stored
+	CreateUserToken(ctx context.Context, token *UserToken) error
+}
@@ -0,0 +1,10 @@
+package models
+
+import "context"
+
+// TxManager groups the calls made with the ctx it gives to fn in a single DB transaction.
+// The UserRepository joins the transaction of the ctx instead of opening one of its own, and the events of the changes are only sent once it is committed.
+type TxManager interface {
+	// WithinTx commits the transaction when fn succeeds and rolls it back when it fails, a nested call joins the outer transaction
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
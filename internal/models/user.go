@@ -1,9 +1,18 @@
 package models
 
 //go:generate mockgen -destination=../repositories/user_repository_mock.go -package=repositories . UserRepository
+//go:generate mockgen -destination=../repositories/user_token_repository_mock.go -package=repositories . UserTokenRepository
+//go:generate mockgen -destination=../repositories/password_history_repository_mock.go -package=repositories . PasswordHistoryRepository
+//go:generate mockgen -destination=../repositories/mfa_repository_mock.go -package=repositories . MFARepository
+//go:generate mockgen -destination=../repositories/login_lockout_repository_mock.go -package=repositories . LoginLockoutRepository
+//go:generate mockgen -destination=../repositories/apikey_repository_mock.go -package=repositories . APIKeyRepository
+//go:generate mockgen -destination=../repositories/account_service_mock.go -package=repositories . AccountService
+//go:generate mockgen -destination=../repositories/mfa_service_mock.go -package=repositories . MFAService
+//go:generate mockgen -destination=../repositories/login_guard_mock.go -package=repositories . LoginGuard
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,15 +20,45 @@ import (
 
 // User Main Struct (It is being used for both API and DB in this project. Ideally should be one for each)
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Nickname  string    `json:"nickname"`
-	Password  string    `json:"password,omitempty"` // Hidden from API POST/GET/UPDATE response body for safety
-	Email     string    `json:"email"`
-	Country   string    `json:"country"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Nickname        string     `json:"nickname"`
+	Password        string     `json:"password,omitempty"` // Hidden from API POST/GET/UPDATE response body for safety
+	Email           string     `json:"email"`
+	Country         string     `json:"country"`
+	ServiceAccount  bool       `json:"service_account"`   // Service accounts are used by machine clients through API keys and can't login, it is only set on creation
+	EmailVerifiedAt *time.Time `json:"email_verified_at"` // Null until the current email is verified, never set through the API body
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // Only filled for soft-deleted Users
+}
+
+// MarshalJSON writes the timestamps in UTC, as RFC 3339 with a Z suffix, whatever the time zone they were read in.
+// The API responses and the events both go through it.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User // Without the methods of User, so it is marshalled the default way
+	utc := user(u)
+	utc.CreatedAt = u.CreatedAt.UTC()
+	utc.UpdatedAt = u.UpdatedAt.UTC()
+	utc.EmailVerifiedAt = utcTime(u.EmailVerifiedAt)
+	utc.DeletedAt = utcTime(u.DeletedAt)
+	return json.Marshal(utc)
+}
+
+// utcTime returns the time in UTC, nil when it is nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// AccountService sends the links Users need to manage their account by email
+type AccountService interface {
+	// RequestPasswordReset emails a password reset link when there is a User with that email, and does nothing otherwise
+	RequestPasswordReset(ctx context.Context, email string) error
 }
 
 // UsersResponse is a paginated response for the method Get all Users
@@ -28,6 +67,20 @@ type UsersResponse struct {
 	PageToken string  `json:"page_token"`
 }
 
+// BatchResult is the outcome of a single row of a batch operation, either the created User ID or the reason it failed
+type BatchResult struct {
+	Row   int        `json:"row"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// BatchResponse is the result of a bulk operation by filter, Sample is only filled on dry runs
+type BatchResponse struct {
+	DryRun   bool    `json:"dry_run"`
+	Affected int64   `json:"affected"`
+	Sample   []*User `json:"sample,omitempty"`
+}
+
 // UserEvent is a paginated response for the method Get all Users
 type UserEvent struct {
 	Operation string `json:"operation"`
@@ -39,10 +92,51 @@ type UserEvent struct {
 type UserRepository interface {
 	// CreateUser creates a new User and returns the User with it's new ID
 	CreateUser(ctx context.Context, user *User) (*User, error)
-	// FindUsers returnds a paginated list of Users, allowing for filtering by certain criteria (e.g. all Users with the country "UK")
-	FindUsers(ctx context.Context, user *User, pageToken string, limit int) (*UsersResponse, error)
+	// CreateUsers creates all the Users in a single transaction, either every User is created or none, and returns them in the same order
+	CreateUsers(ctx context.Context, users []*User) ([]*User, error)
+	// FindUsers returnds a paginated list of Users, allowing for filtering by certain criteria (e.g. all Users with the country "UK").
+	// An Email starting with * matches the emails ending with the rest of it (e.g. "*@example.com")
+	// Soft-deleted Users are only returned when includeDeleted is true
+	FindUsers(ctx context.Context, user *User, includeDeleted bool, pageToken string, limit int) (*UsersResponse, error)
+	// ExportUsers calls fn for every User matching the same filters as FindUsers, reading them from a server-side cursor so memory stays constant
+	ExportUsers(ctx context.Context, user *User, includeDeleted bool, fn func(*User) error) error
+	// CountUsers returns how many Users match the same filters as FindUsers
+	CountUsers(ctx context.Context, user *User, includeDeleted bool) (int64, error)
 	// UpdateUser Modifies an existing User and return the user with its new data
 	UpdateUser(ctx context.Context, user *User) (*User, error)
-	// RemoveUser deletes a user from the database by its ID
+	// UpdateUsersByFilter sets the non-empty FirstName, LastName and Country of the patch on every User matching the filter.
+	// It runs in chunked transactions and returns the updated Users, even when a later chunk fails.
+	UpdateUsersByFilter(ctx context.Context, filter *User, patch *User) ([]*User, error)
+	// RemoveUsersByFilter soft-deletes every User matching the filter in chunked transactions and returns their IDs, even when a later chunk fails
+	RemoveUsersByFilter(ctx context.Context, filter *User) ([]uuid.UUID, error)
+	// RemoveUser soft-deletes a user by its ID, the row is kept until it is purged
 	RemoveUser(ctx context.Context, ID uuid.UUID) (int64, error)
+	// RestoreUser brings back a soft-deleted User
+	RestoreUser(ctx context.Context, ID uuid.UUID) (*User, error)
+	// PurgeUsers permanently deletes the Users soft-deleted before the given time and returns their IDs
+	PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+	// FindUserHistory returns a page of the audit entries of the User, oldest first
+	FindUserHistory(ctx context.Context, id uuid.UUID, pageToken string, limit int) (*AuditResponse, error)
+	// FindUser returns the current state of the User, soft-deleted Users are not found
+	FindUser(ctx context.Context, id uuid.UUID) (*User, error)
+	// FindUserByEmail returns the User with the email, whatever its case, soft-deleted Users are not found
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	// FindUserAsOf rebuilds the state of the User at the given time from its history
+	FindUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*User, error)
+	// RevertUser sets the User back to its state after the given audit entry, the password is kept
+	RevertUser(ctx context.Context, id uuid.UUID, version int64) (*User, error)
+	// VerifyEmail consumes the email verification token and marks the email it was sent to as verified
+	VerifyEmail(ctx context.Context, token string) (*User, error)
+	// ResetPassword consumes the password reset token and sets the new password
+	ResetPassword(ctx context.Context, token string, password string) (*User, error)
+	// ChangePassword sets the new password when the current one matches
+	ChangePassword(ctx context.Context, id uuid.UUID, currentPassword string, password string) (*User, error)
+	// Authenticate returns the User with the email and password, or ErrInvalidCredentials. Service accounts never authenticate this way.
+	Authenticate(ctx context.Context, email string, password string) (*User, error)
+}
+
+// PasswordHistoryRepository reads the previous passwords of the Users, they are recorded by the UserRepository on every change
+type PasswordHistoryRepository interface {
+	// MatchPasswordHistory returns how many passwords ago the User had the password among its last ones, 0 being the current and -1 not found
+	MatchPasswordHistory(ctx context.Context, id uuid.UUID, password string, last int) (int, error)
 }
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixSize is the number of hex characters of the SHA-1 ranges, the same as the k-anonymity range API of Have I Been Pwned
+const prefixSize = 5
+
+// BreachedList holds the SHA-1 of passwords known from data breaches, grouped in ranges by the prefix of the hash.
+// A lookup only goes through the range of the candidate, like the k-anonymity range API it is downloaded from.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedList reads a file with one uppercase or lowercase SHA-1 hex per line, optionally followed by ":<count>"
+// as in the Pwned Passwords downloads. Being in memory, the file should hold a subset like the most common passwords.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached password list open failed: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedList{ranges: map[string]map[string]struct{}{}}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password list line %d is not a SHA-1", line)
+		}
+		list.add(strings.ToUpper(hash))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("breached password list read failed: %w", err)
+	}
+	return list, nil
+}
+
+func (l *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixSize], hash[prefixSize:]
+	if l.ranges[prefix] == nil {
+		l.ranges[prefix] = map[string]struct{}{}
+	}
+	l.ranges[prefix][suffix] = struct{}{}
+}
+
+// Contains tells if the password is in the list
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := l.ranges[hash[:prefixSize]][hash[prefixSize:]]
+	return ok
+}
+
+// Len returns the number of passwords in the list
+func (l *BreachedList) Len() int {
+	n := 0
+	for _, suffixes := range l.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
@@ -0,0 +1,24 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	hash, err := Hash("Secret123!")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "Secret123!", hash)
+
+	ok, err := Matches(hash, "Secret123!")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Matches(hash, "secret123!")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = Matches("not a bcrypt hash", "Secret123!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// Character classes a password may be required to contain
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// field is the User field every violation refers to
+const field = "password"
+
+// classCheckers tells if a rune belongs to a character class
+var classCheckers = map[string]func(r rune) bool{
+	ClassLower:  unicode.IsLower,
+	ClassUpper:  unicode.IsUpper,
+	ClassDigit:  unicode.IsDigit,
+	ClassSymbol: func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) },
+}
+
+// Policy holds the rules every new password must follow
+type Policy struct {
+	MinLength      int           // In characters, never less than 1
+	RequireClasses []string      // ClassLower, ClassUpper, ClassDigit and/or ClassSymbol
+	HistorySize    int           // The last N passwords, the current one included, can't be reused. 0 disables it.
+	Breached       *BreachedList // Passwords known from data breaches are rejected, nil disables it
+}
+
+// ParseClasses parses a comma separated list of character classes
+func ParseClasses(value string) ([]string, error) {
+	classes := []string{}
+	for _, class := range strings.Split(value, ",") {
+		class = strings.TrimSpace(class)
+		if class == "" {
+			continue
+		}
+		if _, ok := classCheckers[class]; !ok {
+			return nil, fmt.Errorf("unknown character class %q", class)
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// Check returns the rules the password breaks, except the history which depends on the User
+func (p *Policy) Check(password string) []models.Violation {
+	violations := []models.Violation{}
+
+	minLength := p.MinLength
+	if minLength < 1 {
+		minLength = 1
+	}
+	if len([]rune(password)) < minLength {
+		violations = append(violations, models.Violation{
+			Field:   field,
+			Code:    "min_length",
+			Message: fmt.Sprintf("password must have at least %d characters", minLength),
+		})
+	}
+
+	for _, class := range p.RequireClasses {
+		if strings.IndexFunc(password, classCheckers[class]) < 0 {
+			violations = append(violations, models.Violation{
+				Field:   field,
+				Code:    class,
+				Message: fmt.Sprintf("password must contain a %s character", class),
+			})
+		}
+	}
+
+	if p.Breached != nil && password != "" && p.Breached.Contains(password) {
+		violations = append(violations, models.Violation{
+			Field:   field,
+			Code:    "breached",
+			Message: "password was found in a data breach",
+		})
+	}
+
+	return violations
+}
+
+// reusedViolation is the violation of the password history
+func (p *Policy) reusedViolation() models.Violation {
+	return models.Violation{
+		Field:   field,
+		Code:    "reused",
+		Message: fmt.Sprintf("password must differ from the last %d passwords", p.HistorySize),
+	}
+}
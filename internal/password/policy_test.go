@@ -0,0 +1,92 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	sum := sha1.Sum([]byte("Password1!"))
+	breached := &BreachedList{ranges: map[string]map[string]struct{}{}}
+	breached.add(strings.ToUpper(hex.EncodeToString(sum[:])))
+
+	policy := &Policy{
+		MinLength:      8,
+		RequireClasses: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol},
+		Breached:       breached,
+	}
+
+	tt := []struct {
+		name     string
+		policy   *Policy
+		password string
+		codes    []string
+	}{
+		{name: "valid", policy: policy, password: "Secret123!"},
+		{name: "too short", policy: policy, password: "Se1!", codes: []string{"min_length"}},
+		{name: "length counted in characters", policy: policy, password: "Séçrét1!"},
+		{name: "without lower", policy: policy, password: "SECRET123!", codes: []string{ClassLower}},
+		{name: "without upper", policy: policy, password: "secret123!", codes: []string{ClassUpper}},
+		{name: "without digit", policy: policy, password: "SecretSecret!", codes: []string{ClassDigit}},
+		{name: "without symbol", policy: policy, password: "Secret1234", codes: []string{ClassSymbol}},
+		{name: "a space is a symbol", policy: policy, password: "Secret 123"},
+		{name: "breached", policy: policy, password: "Password1!", codes: []string{"breached"}},
+		{name: "every violation", policy: policy, password: "", codes: []string{"min_length", ClassLower, ClassUpper, ClassDigit, ClassSymbol}},
+		{name: "at least 1 character", policy: &Policy{}, password: "", codes: []string{"min_length"}},
+		{name: "no rule", policy: &Policy{}, password: "a"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			codes := []string{}
+			for _, violation := range test.policy.Check(test.password) {
+				assert.Equal(t, "password", violation.Field)
+				assert.NotEmpty(t, violation.Message)
+				codes = append(codes, violation.Code)
+			}
+			if test.codes == nil {
+				test.codes = []string{}
+			}
+			assert.Equal(t, test.codes, codes)
+		})
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	classes, err := ParseClasses(" lower, upper,,digit ,symbol")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}, classes)
+
+	classes, err = ParseClasses("")
+	assert.NoError(t, err)
+	assert.Empty(t, classes)
+
+	_, err = ParseClasses("lower,emoji")
+	assert.Error(t, err)
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := LoadBreachedList(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.Len())
+	assert.True(t, list.Contains("password"))
+	assert.True(t, list.Contains("123456"))
+	assert.False(t, list.Contains("Password"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("5BAA61E4\n"), 0o600))
+	_, err = LoadBreachedList(path)
+	assert.Error(t, err)
+
+	_, err = LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package password
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+// UserPolicy wraps a UserRepository to enforce the Policy on every password set by a create, change or reset.
+// A password breaking the policy never reaches the wrapped UserRepository, a *models.ValidationError is returned instead.
+// The reuse of a previous password is checked against the PasswordHistoryRepository.
+type UserPolicy struct {
+	models.UserRepository
+	historyRepository models.PasswordHistoryRepository
+	policy            *Policy
+}
+
+func NewUserPolicy(policy *Policy, historyRepo models.PasswordHistoryRepository, userRepo models.UserRepository) *UserPolicy {
+	return &UserPolicy{
+		UserRepository:    userRepo,
+		historyRepository: historyRepo,
+		policy:            policy,
+	}
+}
+
+// CreateUser is a method from UserPolicy checks the password before the User is created.
+func (s *UserPolicy) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	if violations := s.policy.Check(user.Password); len(violations) > 0 {
+		return nil, fmt.Errorf("createuser failed: %w", &models.ValidationError{Violations: violations})
+	}
+	return s.UserRepository.CreateUser(ctx, user)
+}
+
+// CreateUsers is a method from UserPolicy checks every password before the Users are created, none is created when one breaks the policy.
+func (s *UserPolicy) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	for _, user := range users {
+		if violations := s.policy.Check(user.Password); len(violations) > 0 {
+			return nil, fmt.Errorf("createusers failed: %w", &models.ValidationError{Violations: violations})
+		}
+	}
+	return s.UserRepository.CreateUsers(ctx, users)
+}
+
+// ChangePassword is a method from UserPolicy checks the new password before it is set.
+func (s *UserPolicy) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword string, password string) (*models.User, error) {
+	age, err := s.historyRepository.MatchPasswordHistory(ctx, id, password, s.policy.HistorySize)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(password, age); err != nil {
+		return nil, fmt.Errorf("changepassword failed: %w", err)
+	}
+	return s.UserRepository.ChangePassword(ctx, id, currentPassword, password)
+}
+
+// ResetPassword is a method from UserPolicy checks the new password before the token is consumed, so a rejected password does not burn the link.
+func (s *UserPolicy) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	age := -1
+	// The token is only verified when consumed, a forged subject just skips the history
+	if subject, err := tokens.Subject(token); err == nil {
+		if id, err := uuid.Parse(subject); err == nil {
+			if age, err = s.historyRepository.MatchPasswordHistory(ctx, id, password, s.policy.HistorySize); err != nil {
+				age = -1
+			}
+		}
+	}
+	if err := s.check(password, age); err != nil {
+		return nil, fmt.Errorf("resetpassword failed: %w", err)
+	}
+	return s.UserRepository.ResetPassword(ctx, token, password)
+}
+
+// check returns the violations of the password, age is how many passwords ago the User had it (-1 if never)
+func (s *UserPolicy) check(password string, age int) error {
+	violations := s.policy.Check(password)
+	if age >= 0 && age < s.policy.HistorySize {
+		violations = append(violations, s.policy.reusedViolation())
+	}
+	if len(violations) > 0 {
+		return &models.ValidationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+func TestUserPolicyCheck(t *testing.T) {
+	userPolicy := NewUserPolicy(&Policy{MinLength: 8, HistorySize: 3}, nil, nil)
+
+	tt := []struct {
+		name     string
+		password string
+		age      int
+		codes    []string
+	}{
+		{name: "never used", password: "Secret123!", age: -1},
+		{name: "the current password", password: "Secret123!", age: 0, codes: []string{"reused"}},
+		{name: "within the history", password: "Secret123!", age: 2, codes: []string{"reused"}},
+		{name: "older than the history", password: "Secret123!", age: 3},
+		{name: "reused and too short", password: "Se1!", age: 1, codes: []string{"min_length", "reused"}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			err := userPolicy.check(test.password, test.age)
+			if test.codes == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var valErr *models.ValidationError
+			if assert.True(t, errors.As(err, &valErr)) {
+				codes := []string{}
+				for _, violation := range valErr.Violations {
+					codes = append(codes, violation.Code)
+				}
+				assert.Equal(t, test.codes, codes)
+			}
+		})
+	}
+
+	// Without a history no password is a reuse
+	userPolicy = NewUserPolicy(&Policy{MinLength: 8}, nil, nil)
+	assert.NoError(t, userPolicy.check("Secret123!", 0))
+}
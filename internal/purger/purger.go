@@ -0,0 +1,48 @@
+package purger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// Purger periodically hard-deletes the Users that were soft-deleted longer than the Retention window ago
+type Purger struct {
+	Logger         *zap.Logger
+	UserRepository models.UserRepository
+	Retention      time.Duration
+	Interval       time.Duration
+}
+
+// Run purges once right away and then every Interval until the context is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge removes the Users deleted before the retention window, errors are just logged as the next tick will retry
+func (p *Purger) purge(ctx context.Context) {
+	deletedBefore := time.Now().Add(-p.Retention)
+
+	ids, err := p.UserRepository.PurgeUsers(ctx, deletedBefore)
+	if err != nil {
+		p.Logger.Error("failed to purge users", zap.Error(err))
+		return
+	}
+	if len(ids) > 0 {
+		p.Logger.Info("users purged", zap.Int("count", len(ids)), zap.Time("deleted_before", deletedBefore))
+	}
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket holding up to Burst requests, refilled with Rate requests per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the state of the bucket after a request took (or failed to take) a token from it
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // Until the next token, only set when the request is not allowed
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// Store keeps the buckets, it must take the token atomically so concurrent requests are all counted
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// refill returns the tokens of a bucket left with the given tokens elapsed ago
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// result returns the Result of a request leaving the bucket with the given tokens
+func (l Limit) result(allowed bool, tokens float64) *Result {
+	result := &Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     l.wait(float64(l.Burst) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = l.wait(1 - tokens)
+	}
+	return result
+}
+
+// wait returns how long the bucket takes to refill the given tokens
+func (l Limit) wait(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
+
+// ParseLimit reads a limit written as "requests/period" with an optional ":burst" (e.g. "10/1s:20"), the burst defaults to the requests
+func ParseLimit(value string) (Limit, error) {
+	rate, burst, hasBurst := strings.Cut(value, ":")
+	requests, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period[:burst]", value)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit requests %q", requests)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit period %q", period)
+	}
+
+	limit := Limit{Rate: float64(count) / duration.Seconds(), Burst: count}
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit burst %q", burst)
+		}
+	}
+	return limit, nil
+}
+
+// ParseLimits reads the limits of every route, written as comma separated "METHOD /path=limit" (e.g. "GET /api/users=10/1s:20").
+// The route "*" is the limit of the routes without one of their own.
+func ParseLimits(value string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, limitValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route rate limit %q, expected route=limit", entry)
+		}
+		limit, err := ParseLimit(limitValue)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return limits, nil
+}
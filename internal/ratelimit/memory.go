@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is the state of a token bucket at updatedAt
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time // Once passed the bucket is full, so it can be dropped
+}
+
+// MemoryStore keeps the buckets in memory, so each replica limits on its own
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// sweepInterval is how often the full buckets are dropped
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	tokens := float64(limit.Burst)
+	if b, ok := s.buckets[key]; ok {
+		tokens = limit.refill(b.tokens, now.Sub(b.updatedAt))
+	}
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	result := limit.result(allowed, tokens)
+	s.buckets[key] = &bucket{tokens: tokens, updatedAt: now, fullAt: now.Add(result.Reset)}
+	return result, nil
+}
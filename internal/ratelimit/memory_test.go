@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	// 2 requests per second, up to 3 at once
+	limit := Limit{Rate: 2, Burst: 3}
+	take := func() *Result {
+		result, err := store.Take(context.Background(), "GET /api/users ip:192.0.2.1", limit)
+		assert.NoError(t, err)
+		return result
+	}
+
+	// The burst is allowed at once
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := take()
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+		assert.Zero(t, result.RetryAfter)
+	}
+
+	// The empty bucket refuses the request until the next token
+	result := take()
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// Half a token later it still waits for the other half
+	now = now.Add(250 * time.Millisecond)
+	result = take()
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 250*time.Millisecond, result.RetryAfter)
+
+	// A token is refilled every 500ms
+	now = now.Add(250 * time.Millisecond)
+	result = take()
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// The bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	result = take()
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Equal(t, 500*time.Millisecond, result.Reset)
+
+	// Another client has a bucket of its own
+	other, err := store.Take(context.Background(), "GET /api/users ip:192.0.2.2", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, other.Remaining)
+}
+
+func TestParseLimit(t *testing.T) {
+	tt := []struct {
+		name  string
+		value string
+		limit Limit
+		err   bool
+	}{
+		{name: "burst defaults to the requests", value: "10/1s", limit: Limit{Rate: 10, Burst: 10}},
+		{name: "explicit burst", value: "10/1s:20", limit: Limit{Rate: 10, Burst: 20}},
+		{name: "per minute", value: "30/1m", limit: Limit{Rate: 0.5, Burst: 30}},
+		{name: "missing period", value: "10", err: true},
+		{name: "zero requests", value: "0/1s", err: true},
+		{name: "invalid period", value: "10/1x", err: true},
+		{name: "zero burst", value: "10/1s:0", err: true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			limit, err := ParseLimit(test.value)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.limit, limit)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps the buckets in U1.RATE_LIMITS, so every replica shares the same limits.
+// The buckets are refilled with the clock of the DB, so the replicas don't need synchronized clocks.
+type PostgresStore struct {
+	db        *sql.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// refillExpression is the tokens of the existing bucket R refilled until now, $2 being the burst and $3 the rate
+const refillExpression = "LEAST($2::FLOAT8, R.TOKENS + EXTRACT(EPOCH FROM (EXCLUDED.UPDATED_AT - R.UPDATED_AT)) * $3::FLOAT8)"
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (*Result, error) {
+	// The upsert locks the row, so concurrent requests for the same key take their tokens one at a time
+	query := `INSERT INTO U1.RATE_LIMITS AS R (BUCKET_KEY, TOKENS, ALLOWED, UPDATED_AT, FULL_AT)
+	VALUES ($1, $2::FLOAT8 - 1, TRUE, now(), now() + make_interval(secs => $2::FLOAT8 / $3::FLOAT8))
+	ON CONFLICT (BUCKET_KEY) DO UPDATE SET
+		TOKENS = CASE WHEN ` + refillExpression + ` >= 1 THEN ` + refillExpression + ` - 1 ELSE ` + refillExpression + ` END,
+		ALLOWED = ` + refillExpression + ` >= 1,
+		UPDATED_AT = EXCLUDED.UPDATED_AT,
+		FULL_AT = EXCLUDED.FULL_AT
+	RETURNING TOKENS, ALLOWED`
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, query, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit take failed: %w", err)
+	}
+
+	s.sweep(ctx)
+	return limit.result(allowed, tokens), nil
+}
+
+// sweep deletes the full buckets once per sweepInterval, a missing bucket is the same as a full one.
+// FULL_AT is when even an empty bucket would be full again, so no bucket is deleted before it is full.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	// Best effort, a failed sweep is retried on the next interval
+	_, _ = s.db.ExecContext(ctx, "DELETE FROM U1.RATE_LIMITS WHERE FULL_AT < now()")
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// apiKeyColumns are the columns returned by every query reading API keys. The KEY_HASH is never read back.
+const apiKeyColumns = "ID, USER_ID, NAME, PREFIX, SCOPES, EXPIRES_AT, LAST_USED_AT, CREATED_AT, REVOKED_AT"
+
+// scanAPIKey reads an APIKey from a row selected with apiKeyColumns
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		pq.Array(&key.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&key.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	apiKeyInUTC(key)
+	return key, nil
+}
+
+// apiKeyInUTC converts the timestamps of the scanned APIKey to UTC, like userInUTC
+func apiKeyInUTC(key *models.APIKey) {
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.ExpiresAt = utc(key.ExpiresAt)
+	key.LastUsedAt = utc(key.LastUsedAt)
+	key.RevokedAt = utc(key.RevokedAt)
+}
+
+func (s *UserRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	query := `INSERT INTO U1.API_KEYS (
+		USER_ID,
+		NAME,
+		PREFIX,
+		KEY_HASH,
+		SCOPES,
+		EXPIRES_AT
+	)
+	SELECT ID, $2, $3, $4, $5, $6 FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL
+	RETURNING ` + apiKeyColumns
+
+	var expiresAt any
+	if key.ExpiresAt != nil {
+		expiresAt = key.ExpiresAt.UTC()
+	}
+
+	row := s.conn(ctx).QueryRowContext(ctx, query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		pq.Array(key.Scopes),
+		expiresAt,
+	)
+	createdKey, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("createapikey returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("createapikey failed: %w", err)
+	}
+	return createdKey, nil
+}
+
+func (s *UserRepo) FindAPIKeys(ctx context.Context, userID uuid.UUID, includeRevoked bool) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+	FROM U1.API_KEYS
+	WHERE ($1::UUID IS NULL OR USER_ID = $1)
+		AND ($2 OR REVOKED_AT IS NULL)
+	ORDER BY CREATED_AT, ID`
+
+	var user any
+	if userID != uuid.Nil {
+		user = userID
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, user, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("findapikeys query failed: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("findapikeys failed: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("findapikeys failed: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *UserRepo) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	query := "UPDATE U1.API_KEYS SET REVOKED_AT = now() WHERE ID = $1 AND REVOKED_AT IS NULL"
+
+	result, err := s.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("revokeapikey failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revokeapikey failed: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("revokeapikey returned no rows: %w", models.ErrAPIKeyNotFound)
+	}
+	return nil
+}
+
+func (s *UserRepo) AuthenticateAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	// The key is looked up and marked as used in a single statement
+	query := `UPDATE U1.API_KEYS K SET LAST_USED_AT = now()
+	FROM U1.USERS U
+	WHERE K.KEY_HASH = $1
+		AND K.REVOKED_AT IS NULL
+		AND (K.EXPIRES_AT IS NULL OR K.EXPIRES_AT > now())
+		AND U.ID = K.USER_ID AND U.DELETED_AT IS NULL
+	RETURNING K.ID, K.USER_ID, K.NAME, K.PREFIX, K.SCOPES, K.EXPIRES_AT, K.LAST_USED_AT, K.CREATED_AT, K.REVOKED_AT`
+
+	key, err := scanAPIKey(s.conn(ctx).QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("authenticateapikey returned no rows: %w", models.ErrInvalidAPIKey)
+		}
+		return nil, fmt.Errorf("authenticateapikey failed: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,351 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/audit"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories/common"
+)
+
+// redacted replaces the password in the audit diff, only the fact that it changed is recorded
+const redacted = "[redacted]"
+
+// auditColumns are the columns returned by every query reading audit entries
+const auditColumns = "ID, USER_ID, OPERATION, ACTOR, REQUEST_ID, SOURCE_IP, DIFF, PREV_HASH, HASH, CREATED_AT"
+
+// lastAuditHashQuery selects the hash of the last audit entry of a User, the next entry is chained to it
+const lastAuditHashQuery = "SELECT HASH FROM U1.USER_AUDIT WHERE USER_ID = $1 ORDER BY ID DESC LIMIT 1"
+
+// insertAuditQuery inserts an audit entry, its arguments are returned by chainAuditEntry
+const insertAuditQuery = `INSERT INTO U1.USER_AUDIT (
+		USER_ID,
+		OPERATION,
+		ACTOR,
+		REQUEST_ID,
+		SOURCE_IP,
+		DIFF,
+		PREV_HASH,
+		HASH,
+		CREATED_AT
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+// newAuditEntry returns the audit entry of a mutation made by the actor of the context, not chained yet
+func newAuditEntry(ctx context.Context, userID uuid.UUID, operation string, diff map[string]*models.FieldChange) *models.AuditEntry {
+	actor := audit.FromContext(ctx)
+	return &models.AuditEntry{
+		UserID:    userID,
+		Operation: operation,
+		Actor:     actor.Name,
+		RequestID: actor.RequestID,
+		SourceIP:  actor.SourceIP,
+		Diff:      diff,
+		// Postgres keeps microseconds, the hash must be computed on what is read back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+// chainAuditEntry links the entry to the hash of the previous entry of its User and returns the arguments of insertAuditQuery
+func chainAuditEntry(entry *models.AuditEntry, prevHash string) ([]any, error) {
+	entry.PrevHash = prevHash
+
+	var err error
+	entry.Hash, err = entry.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonDiff, err := json.Marshal(entry.Diff)
+	if err != nil {
+		return nil, fmt.Errorf("insertaudit diff marshal failed: %w", err)
+	}
+
+	return []any{
+		entry.UserID,
+		entry.Operation,
+		entry.Actor,
+		entry.RequestID,
+		entry.SourceIP,
+		jsonDiff,
+		entry.PrevHash,
+		entry.Hash,
+		entry.CreatedAt,
+	}, nil
+}
+
+// insertAudit appends an entry to the history of the User, chained to the last entry of the same User.
+// It must run in the transaction of the mutation, after the User row is locked, so the entries of a User are written one at a time.
+func insertAudit(ctx context.Context, tx *stmtTx, userID uuid.UUID, operation string, diff map[string]*models.FieldChange) error {
+	entry := newAuditEntry(ctx, userID, operation, diff)
+
+	var prevHash string
+	err := tx.QueryRowContext(ctx, lastAuditHashQuery, userID).Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("insertaudit previous hash failed: %w", err)
+	}
+
+	args, err := chainAuditEntry(entry, prevHash)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, insertAuditQuery, args...); err != nil {
+		return fmt.Errorf("insertaudit failed: %w", err)
+	}
+	return nil
+}
+
+// userDiff returns the fields that differ between two states of a User, a nil state means the User did not exist
+func userDiff(old, new *models.User, passwordChanged bool) map[string]*models.FieldChange {
+	if old == nil {
+		old = &models.User{}
+	}
+	if new == nil {
+		new = &models.User{}
+	}
+
+	diff := map[string]*models.FieldChange{}
+	addChange(diff, "first_name", stringValue(old.FirstName), stringValue(new.FirstName))
+	addChange(diff, "last_name", stringValue(old.LastName), stringValue(new.LastName))
+	addChange(diff, "nickname", stringValue(old.Nickname), stringValue(new.Nickname))
+	addChange(diff, "email", stringValue(old.Email), stringValue(new.Email))
+	addChange(diff, "country", stringValue(old.Country), stringValue(new.Country))
+	addChange(diff, "service_account", boolValue(old.ServiceAccount), boolValue(new.ServiceAccount))
+	addChange(diff, "email_verified_at", timeValue(old.EmailVerifiedAt), timeValue(new.EmailVerifiedAt))
+	addChange(diff, "created_at", timeValue(&old.CreatedAt), timeValue(&new.CreatedAt))
+	addChange(diff, "updated_at", timeValue(&old.UpdatedAt), timeValue(&new.UpdatedAt))
+	addChange(diff, "deleted_at", timeValue(old.DeletedAt), timeValue(new.DeletedAt))
+	if passwordChanged {
+		value := redacted
+		diff["password"] = &models.FieldChange{New: &value}
+		if old.ID != uuid.Nil {
+			diff["password"].Old = &value
+		}
+	}
+	return diff
+}
+
+// deletedDiff returns the diff of a soft delete or a restore
+func deletedDiff(old, new *time.Time) map[string]*models.FieldChange {
+	diff := map[string]*models.FieldChange{}
+	addChange(diff, "deleted_at", timeValue(old), timeValue(new))
+	return diff
+}
+
+func addChange(diff map[string]*models.FieldChange, field string, old, new *string) {
+	if old == nil && new == nil {
+		return
+	}
+	if old != nil && new != nil && *old == *new {
+		return
+	}
+	diff[field] = &models.FieldChange{Old: old, New: new}
+}
+
+func stringValue(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func boolValue(b bool) *string {
+	if !b {
+		return nil
+	}
+	value := "true"
+	return &value
+}
+
+func timeValue(t *time.Time) *string {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	value := t.UTC().Format(time.RFC3339Nano)
+	return &value
+}
+
+// FindUserHistory returns a page of the audit entries of the User, oldest first, with the verification of the hash chain
+func (s *UserRepo) FindUserHistory(ctx context.Context, id uuid.UUID, pageToken string, limit int) (*models.AuditResponse, error) {
+	if limit < 1 || limit > pageLimit {
+		limit = pageLimit
+	}
+
+	fromID, err := common.DecodeBase64ToInt64(pageToken)
+	if err != nil {
+		return nil, fmt.Errorf("finduserhistory pagetoken decoding failed: %w", err)
+	}
+
+	query := `SELECT ` + auditColumns + `
+		FROM U1.USER_AUDIT
+		WHERE USER_ID = $1 AND ID >= $2
+		ORDER BY ID
+		LIMIT $3`
+
+	entries, err := findAuditEntries(ctx, s.conn(ctx), query, id, fromID, limit+oneForToken)
+	if err != nil {
+		return nil, fmt.Errorf("finduserhistory query failed: %w", err)
+	}
+
+	response := &models.AuditResponse{Entries: entries}
+	if len(entries) > limit {
+		response.PageToken = common.EncodeInt64ToBase64(entries[limit].ID)
+		response.Entries = entries[:limit]
+	}
+	if len(response.Entries) == 0 {
+		response.Verified = true
+		return response, nil
+	}
+
+	// A page is verified from the entry before it, so every page can be checked on its own
+	var prevHash string
+	query = "SELECT HASH FROM U1.USER_AUDIT WHERE USER_ID = $1 AND ID < $2 ORDER BY ID DESC LIMIT 1"
+	err = s.conn(ctx).QueryRowContext(ctx, query, id, response.Entries[0].ID).Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("finduserhistory previous hash failed: %w", err)
+	}
+	response.Verified = models.VerifyChain(prevHash, response.Entries) == nil
+
+	return response, nil
+}
+
+// scanAuditEntry reads an AuditEntry from a row selected with auditColumns
+func scanAuditEntry(row scanner) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{}
+	var diff []byte
+	err := row.Scan(
+		&entry.ID,
+		&entry.UserID,
+		&entry.Operation,
+		&entry.Actor,
+		&entry.RequestID,
+		&entry.SourceIP,
+		&diff,
+		&entry.PrevHash,
+		&entry.Hash,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(diff, &entry.Diff); err != nil {
+		return nil, fmt.Errorf("audit diff unmarshal failed: %w", err)
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC()
+	return entry, nil
+}
+
+// querier is implemented by *sql.DB, *sql.Tx and the prepared statements
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// findAuditEntries returns every audit entry selected by the query, which must select auditColumns
+func findAuditEntries(ctx context.Context, q querier, query string, args ...any) ([]*models.AuditEntry, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *UserRepo) FindUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error) {
+	query := `SELECT ` + auditColumns + `
+	FROM U1.USER_AUDIT
+	WHERE USER_ID = $1 AND CREATED_AT <= $2
+	ORDER BY ID`
+
+	entries, err := findAuditEntries(ctx, s.conn(ctx), query, id, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("finduserasof query failed: %w", err)
+	}
+
+	user, err := models.ReplayHistory(entries)
+	if err != nil {
+		return nil, fmt.Errorf("finduserasof failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserRepo) RevertUser(ctx context.Context, id uuid.UUID, version int64) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	historyQuery := `SELECT ` + auditColumns + `
+	FROM U1.USER_AUDIT
+	WHERE USER_ID = $1 AND ID <= $2
+	ORDER BY ID`
+
+	query := `UPDATE U1.USERS SET
+		FIRST_NAME = $2,
+		LAST_NAME = $3,
+		NICKNAME = $4,
+		EMAIL = $5,
+		COUNTRY = $6,
+		EMAIL_VERIFIED_AT = CASE WHEN LOWER(EMAIL) = LOWER($5) THEN EMAIL_VERIFIED_AT END,
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var revertedUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		currentUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, id))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrUserNotFound
+			}
+			return err
+		}
+
+		entries, err := findAuditEntries(ctx, tx, historyQuery, id, version)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 || entries[len(entries)-1].ID != version {
+			return models.ErrVersionNotFound
+		}
+
+		previousUser, err := models.ReplayHistory(entries)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRowContext(ctx, query,
+			id,
+			previousUser.FirstName,
+			previousUser.LastName,
+			previousUser.Nickname,
+			previousUser.Email,
+			previousUser.Country,
+		)
+		revertedUser, err = scanUser(row)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, id, models.AuditUpdate, userDiff(currentUser, revertedUser, false))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revertuser failed: %w", mapError(err))
+	}
+	return revertedUser, nil
+}
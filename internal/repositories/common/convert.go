@@ -2,6 +2,7 @@ package common
 
 import (
 	"encoding/base64"
+	"strconv"
 
 	"github.com/google/uuid"
 )
@@ -25,3 +26,22 @@ func EncodeUUIDToBase64(uuid uuid.UUID) string {
 	encoded := base64.StdEncoding.EncodeToString([]byte(uuid.String()))
 	return encoded
 }
+
+// DecodeBase64ToInt64 is responsible to unmask IDs previously converted to base 64
+func DecodeBase64ToInt64(encoded string) (int64, error) {
+	if encoded == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(decoded), 10, 64)
+}
+
+// EncodeInt64ToBase64 is responsible to mask IDs to base 64
+func EncodeInt64ToBase64(id int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
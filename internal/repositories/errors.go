@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// uniqueViolation is the Postgres error code raised when a unique index rejects a row
+const uniqueViolation pq.ErrorCode = "23505"
+
+// uniqueIndexFields maps the unique indexes created by the migrations to the User field they protect
+var uniqueIndexFields = map[string]string{
+	"users_email_unique_idx":    "email",
+	"users_nickname_unique_idx": "nickname",
+}
+
+// mapError converts known Postgres errors into the typed errors declared in models, any other error is returned untouched
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		if field, ok := uniqueIndexFields[pqErr.Constraint]; ok {
+			return &models.DuplicateError{Field: field, Err: err}
+		}
+	}
+	// The same errors raised through pgx
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == string(uniqueViolation) {
+		if field, ok := uniqueIndexFields[pgErr.ConstraintName]; ok {
+			return &models.DuplicateError{Field: field, Err: err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// lockoutColumns are the columns returned by every query reading U1.USER_LOGIN_FAILURES
+const lockoutColumns = "USER_ID, FAILED_ATTEMPTS, LAST_FAILED_AT, LOCKED_UNTIL"
+
+// scanLoginLockout reads a LoginLockout from a row selected with lockoutColumns
+func scanLoginLockout(row scanner) (*models.LoginLockout, error) {
+	lockout := &models.LoginLockout{}
+	var lastFailedAt, lockedUntil sql.NullTime
+	if err := row.Scan(&lockout.UserID, &lockout.FailedAttempts, &lastFailedAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lastFailedAt.Valid {
+		lockout.LastFailedAt = &lastFailedAt.Time
+	}
+	if lockedUntil.Valid {
+		lockout.LockedUntil = &lockedUntil.Time
+	}
+	return lockout, nil
+}
+
+func (s *UserRepo) FindLoginLockout(ctx context.Context, email string) (*models.LoginLockout, error) {
+	query := `SELECT ` + lockoutColumns + `
+	FROM U1.USER_LOGIN_FAILURES
+	WHERE USER_ID = (SELECT ID FROM U1.USERS WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL)`
+
+	lockout, err := scanLoginLockout(s.conn(ctx).QueryRowContext(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.LoginLockout{}, nil
+		}
+		return nil, fmt.Errorf("findloginlockout failed: %w", err)
+	}
+	return lockout, nil
+}
+
+func (s *UserRepo) RecordLoginFailure(ctx context.Context, email string, maxAttempts int, lockFor time.Duration) (*models.LoginLockout, error) {
+	// The increment is a single statement, so concurrent failures are all counted
+	query := `INSERT INTO U1.USER_LOGIN_FAILURES (USER_ID, FAILED_ATTEMPTS, LAST_FAILED_AT)
+	SELECT ID, 1, now() FROM U1.USERS WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL
+	ON CONFLICT (USER_ID) DO UPDATE SET
+		FAILED_ATTEMPTS = U1.USER_LOGIN_FAILURES.FAILED_ATTEMPTS + 1,
+		LAST_FAILED_AT = EXCLUDED.LAST_FAILED_AT
+	RETURNING ` + lockoutColumns
+
+	// Once locked the count starts over, so the delays start over too when the lock expires
+	lockQuery := `UPDATE U1.USER_LOGIN_FAILURES SET
+		FAILED_ATTEMPTS = 0,
+		LOCKED_UNTIL = now() + make_interval(secs => $2)
+	WHERE USER_ID = $1
+	RETURNING ` + lockoutColumns
+
+	var lockout *models.LoginLockout
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		var err error
+		lockout, err = scanLoginLockout(tx.QueryRowContext(ctx, query, email))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrUserNotFound
+			}
+			return err
+		}
+		if lockout.FailedAttempts < maxAttempts {
+			return nil
+		}
+
+		lockout, err = scanLoginLockout(tx.QueryRowContext(ctx, lockQuery, lockout.UserID, lockFor.Seconds()))
+		if err != nil {
+			return err
+		}
+		lockout.Locked = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recordloginfailure failed: %w", err)
+	}
+	return lockout, nil
+}
+
+func (s *UserRepo) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM U1.USER_LOGIN_FAILURES WHERE USER_ID = $1"
+	if _, err := s.conn(ctx).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("resetloginfailures failed: %w", err)
+	}
+	return nil
+}
+
+func (s *UserRepo) UnlockUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `WITH TARGET AS (
+		SELECT ID FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL
+	), CLEARED AS (
+		DELETE FROM U1.USER_LOGIN_FAILURES F USING TARGET
+		WHERE F.USER_ID = TARGET.ID
+		RETURNING F.LOCKED_UNTIL
+	)
+	SELECT
+		EXISTS (SELECT 1 FROM TARGET),
+		EXISTS (SELECT 1 FROM CLEARED WHERE LOCKED_UNTIL > now())`
+
+	var found, wasLocked bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, id).Scan(&found, &wasLocked); err != nil {
+		return false, fmt.Errorf("unlockuser failed: %w", err)
+	}
+	if !found {
+		return false, fmt.Errorf("unlockuser returned no rows: %w", models.ErrUserNotFound)
+	}
+	return wasLocked, nil
+}
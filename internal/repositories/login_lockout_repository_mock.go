@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fellippemendonca/manage_user_go_pg_echo/internal/models (interfaces: LoginLockoutRepository)
+
+// Package repositories is a generated GoMock package.
+package repositories
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockLoginLockoutRepository is a mock of LoginLockoutRepository interface.
+type MockLoginLockoutRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginLockoutRepositoryMockRecorder
+}
+
+// MockLoginLockoutRepositoryMockRecorder is the mock recorder for MockLoginLockoutRepository.
+type MockLoginLockoutRepositoryMockRecorder struct {
+	mock *MockLoginLockoutRepository
+}
+
+// NewMockLoginLockoutRepository creates a new mock instance.
+func NewMockLoginLockoutRepository(ctrl *gomock.Controller) *MockLoginLockoutRepository {
+	mock := &MockLoginLockoutRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginLockoutRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginLockoutRepository) EXPECT() *MockLoginLockoutRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindLoginLockout mocks base method.
+func (m *MockLoginLockoutRepository) FindLoginLockout(arg0 context.Context, arg1 string) (*models.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(*models.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLoginLockout indicates an expected call of FindLoginLockout.
+func (mr *MockLoginLockoutRepositoryMockRecorder) FindLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLoginLockout", reflect.TypeOf((*MockLoginLockoutRepository)(nil).FindLoginLockout), arg0, arg1)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockLoginLockoutRepository) RecordLoginFailure(arg0 context.Context, arg1 string, arg2 int, arg3 time.Duration) (*models.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockLoginLockoutRepositoryMockRecorder) RecordLoginFailure(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockLoginLockoutRepository)(nil).RecordLoginFailure), arg0, arg1, arg2, arg3)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockLoginLockoutRepository) ResetLoginFailures(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockLoginLockoutRepositoryMockRecorder) ResetLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockLoginLockoutRepository)(nil).ResetLoginFailures), arg0, arg1)
+}
+
+// UnlockUser mocks base method.
+func (m *MockLoginLockoutRepository) UnlockUser(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockLoginLockoutRepositoryMockRecorder) UnlockUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockLoginLockoutRepository)(nil).UnlockUser), arg0, arg1)
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+func (s *UserRepo) Authenticate(ctx context.Context, email string, password string) (*models.User, error) {
+	query := `SELECT ` + userColumns + `, PASSWORD
+	FROM U1.USERS
+	WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL AND NOT SERVICE_ACCOUNT`
+
+	var storedPassword string
+	user, err := scanUser(s.conn(ctx).QueryRowContext(ctx, query, email), &storedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("authenticate returned no rows: %w", models.ErrInvalidCredentials)
+		}
+		return nil, fmt.Errorf("authenticate failed: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(storedPassword), []byte(password)) != 1 {
+		return nil, fmt.Errorf("authenticate failed: %w", models.ErrInvalidCredentials)
+	}
+	return user, nil
+}
+
+func (s *UserRepo) SaveTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	// A confirmed enrollment is never replaced, the row is only written when it is new or still unconfirmed
+	query := `INSERT INTO U1.USER_MFA (USER_ID, TOTP_SECRET)
+	SELECT ID, $2 FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL
+	ON CONFLICT (USER_ID) DO UPDATE SET
+		TOTP_SECRET = EXCLUDED.TOTP_SECRET,
+		LAST_USED_STEP = 0,
+		CREATED_AT = now()
+	WHERE U1.USER_MFA.CONFIRMED_AT IS NULL
+	RETURNING USER_ID`
+
+	var userID uuid.UUID
+	err := s.conn(ctx).QueryRowContext(ctx, query, id, secret).Scan(&userID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("savetotpsecret failed: %w", err)
+	}
+
+	// Nothing was written, either the User does not exist or it is already enrolled
+	if _, err := s.FindUserMFA(ctx, id); err != nil {
+		if errors.Is(err, models.ErrMFANotEnrolled) {
+			return fmt.Errorf("savetotpsecret returned no rows: %w", models.ErrUserNotFound)
+		}
+		return fmt.Errorf("savetotpsecret failed: %w", err)
+	}
+	return fmt.Errorf("savetotpsecret failed: %w", models.ErrMFAEnrolled)
+}
+
+func (s *UserRepo) FindUserMFA(ctx context.Context, id uuid.UUID) (*models.UserMFA, error) {
+	query := `SELECT M.USER_ID, M.TOTP_SECRET, M.LAST_USED_STEP, M.CONFIRMED_AT
+	FROM U1.USER_MFA M
+	JOIN U1.USERS U ON U.ID = M.USER_ID
+	WHERE M.USER_ID = $1 AND U.DELETED_AT IS NULL`
+
+	mfa := &models.UserMFA{}
+	var confirmedAt sql.NullTime
+	err := s.conn(ctx).QueryRowContext(ctx, query, id).Scan(&mfa.UserID, &mfa.Secret, &mfa.LastUsedStep, &confirmedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("findusermfa returned no rows: %w", models.ErrMFANotEnrolled)
+		}
+		return nil, fmt.Errorf("findusermfa failed: %w", err)
+	}
+	if confirmedAt.Valid {
+		mfa.ConfirmedAt = &confirmedAt.Time
+	}
+	return mfa, nil
+}
+
+func (s *UserRepo) ConfirmTOTP(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	query := `UPDATE U1.USER_MFA SET
+		CONFIRMED_AT = now(),
+		LAST_USED_STEP = $2
+	WHERE USER_ID = $1 AND CONFIRMED_AT IS NULL`
+
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		result, err := tx.ExecContext(ctx, query, id, step)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			// Confirmed concurrently, or the enrollment is gone
+			return models.ErrMFANotEnrolled
+		}
+		return insertRecoveryCodes(ctx, tx, id, recoveryCodeHashes)
+	})
+	if err != nil {
+		return fmt.Errorf("confirmtotp failed: %w", err)
+	}
+	return nil
+}
+
+func (s *UserRepo) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	query := `UPDATE U1.USER_MFA SET LAST_USED_STEP = $2
+	WHERE USER_ID = $1 AND CONFIRMED_AT IS NOT NULL AND LAST_USED_STEP < $2`
+
+	result, err := s.conn(ctx).ExecContext(ctx, query, id, step)
+	if err != nil {
+		return fmt.Errorf("usetotpstep failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("usetotpstep failed: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("usetotpstep failed: %w", models.ErrInvalidMFACode)
+	}
+	return nil
+}
+
+func (s *UserRepo) ReplaceRecoveryCodes(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error {
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM U1.USER_RECOVERY_CODES WHERE USER_ID = $1", id); err != nil {
+			return err
+		}
+		return insertRecoveryCodes(ctx, tx, id, recoveryCodeHashes)
+	})
+	if err != nil {
+		return fmt.Errorf("replacerecoverycodes failed: %w", err)
+	}
+	return nil
+}
+
+// insertRecoveryCodes stores the hashes of new recovery codes of the User
+func insertRecoveryCodes(ctx context.Context, tx *stmtTx, id uuid.UUID, recoveryCodeHashes []string) error {
+	query := "INSERT INTO U1.USER_RECOVERY_CODES (USER_ID, CODE_HASH) VALUES ($1, $2)"
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, query, id, hash); err != nil {
+			return fmt.Errorf("insert recovery code failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *UserRepo) UseRecoveryCode(ctx context.Context, id uuid.UUID, recoveryCodeHash string) error {
+	query := `UPDATE U1.USER_RECOVERY_CODES SET USED_AT = now()
+	WHERE USER_ID = $1 AND CODE_HASH = $2 AND USED_AT IS NULL`
+
+	result, err := s.conn(ctx).ExecContext(ctx, query, id, recoveryCodeHash)
+	if err != nil {
+		return fmt.Errorf("userecoverycode failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("userecoverycode failed: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("userecoverycode failed: %w", models.ErrInvalidMFACode)
+	}
+	return nil
+}
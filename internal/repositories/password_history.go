@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/password"
+)
+
+// recordPassword keeps the password being replaced in the history of the User, hashed
+func recordPassword(ctx context.Context, tx *stmtTx, userID uuid.UUID, previousPassword string) error {
+	hash, err := password.Hash(previousPassword)
+	if err != nil {
+		return fmt.Errorf("record password hashing failed: %w", err)
+	}
+
+	query := "INSERT INTO U1.USER_PASSWORD_HISTORY (USER_ID, PASSWORD_HASH) VALUES ($1, $2)"
+	if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+		return fmt.Errorf("record password failed: %w", err)
+	}
+	return nil
+}
+
+func (s *UserRepo) MatchPasswordHistory(ctx context.Context, id uuid.UUID, candidate string, last int) (int, error) {
+	var current string
+	query := "SELECT PASSWORD FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL"
+	if err := s.conn(ctx).QueryRowContext(ctx, query, id).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, fmt.Errorf("matchpasswordhistory returned no rows: %w", models.ErrUserNotFound)
+		}
+		return -1, fmt.Errorf("matchpasswordhistory failed: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(current), []byte(candidate)) == 1 {
+		return 0, nil
+	}
+	if last <= 1 {
+		return -1, nil
+	}
+
+	// The current password is one of the last, so only the ones before it are read
+	query = `SELECT PASSWORD_HASH
+	FROM U1.USER_PASSWORD_HISTORY
+	WHERE USER_ID = $1
+	ORDER BY ID DESC
+	LIMIT $2`
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, id, last-1)
+	if err != nil {
+		return -1, fmt.Errorf("matchpasswordhistory query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for age := 1; rows.Next(); age++ {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return -1, fmt.Errorf("matchpasswordhistory failed: %w", err)
+		}
+		matches, err := password.Matches(hash, candidate)
+		if err != nil {
+			return -1, fmt.Errorf("matchpasswordhistory compare failed: %w", err)
+		}
+		if matches {
+			return age, nil
+		}
+	}
+	return -1, rows.Err()
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fellippemendonca/manage_user_go_pg_echo/internal/models (interfaces: PasswordHistoryRepository)
+
+// Package repositories is a generated GoMock package.
+package repositories
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockPasswordHistoryRepository is a mock of PasswordHistoryRepository interface.
+type MockPasswordHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordHistoryRepositoryMockRecorder
+}
+
+// MockPasswordHistoryRepositoryMockRecorder is the mock recorder for MockPasswordHistoryRepository.
+type MockPasswordHistoryRepositoryMockRecorder struct {
+	mock *MockPasswordHistoryRepository
+}
+
+// NewMockPasswordHistoryRepository creates a new mock instance.
+func NewMockPasswordHistoryRepository(ctrl *gomock.Controller) *MockPasswordHistoryRepository {
+	mock := &MockPasswordHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockPasswordHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordHistoryRepository) EXPECT() *MockPasswordHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// MatchPasswordHistory mocks base method.
+func (m *MockPasswordHistoryRepository) MatchPasswordHistory(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchPasswordHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchPasswordHistory indicates an expected call of MatchPasswordHistory.
+func (mr *MockPasswordHistoryRepositoryMockRecorder) MatchPasswordHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchPasswordHistory", reflect.TypeOf((*MockPasswordHistoryRepository)(nil).MatchPasswordHistory), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// pgxScanAPIKey reads an APIKey from a row selected with apiKeyColumns, the SCOPES array is scanned as is
+func pgxScanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	apiKeyInUTC(key)
+	return key, nil
+}
+
+func (s *PgxUserRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	query := `INSERT INTO U1.API_KEYS (
+		USER_ID,
+		NAME,
+		PREFIX,
+		KEY_HASH,
+		SCOPES,
+		EXPIRES_AT
+	)
+	SELECT ID, $2, $3, $4, $5, $6 FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL
+	RETURNING ` + apiKeyColumns
+
+	var expiresAt any
+	if key.ExpiresAt != nil {
+		expiresAt = key.ExpiresAt.UTC()
+	}
+
+	row := s.conn(ctx).QueryRow(ctx, query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		key.Scopes,
+		expiresAt,
+	)
+	createdKey, err := pgxScanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("createapikey returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("createapikey failed: %w", err)
+	}
+	return createdKey, nil
+}
+
+func (s *PgxUserRepo) FindAPIKeys(ctx context.Context, userID uuid.UUID, includeRevoked bool) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+	FROM U1.API_KEYS
+	WHERE ($1::UUID IS NULL OR USER_ID = $1)
+		AND ($2 OR REVOKED_AT IS NULL)
+	ORDER BY CREATED_AT, ID`
+
+	var user any
+	if userID != uuid.Nil {
+		user = userID
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, query, user, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("findapikeys query failed: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := pgxScanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("findapikeys failed: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("findapikeys failed: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *PgxUserRepo) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	query := "UPDATE U1.API_KEYS SET REVOKED_AT = now() WHERE ID = $1 AND REVOKED_AT IS NULL"
+
+	result, err := s.conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("revokeapikey failed: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("revokeapikey returned no rows: %w", models.ErrAPIKeyNotFound)
+	}
+	return nil
+}
+
+func (s *PgxUserRepo) AuthenticateAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	// The key is looked up and marked as used in a single statement
+	query := `UPDATE U1.API_KEYS K SET LAST_USED_AT = now()
+	FROM U1.USERS U
+	WHERE K.KEY_HASH = $1
+		AND K.REVOKED_AT IS NULL
+		AND (K.EXPIRES_AT IS NULL OR K.EXPIRES_AT > now())
+		AND U.ID = K.USER_ID AND U.DELETED_AT IS NULL
+	RETURNING K.ID, K.USER_ID, K.NAME, K.PREFIX, K.SCOPES, K.EXPIRES_AT, K.LAST_USED_AT, K.CREATED_AT, K.REVOKED_AT`
+
+	key, err := pgxScanAPIKey(s.conn(ctx).QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("authenticateapikey returned no rows: %w", models.ErrInvalidAPIKey)
+		}
+		return nil, fmt.Errorf("authenticateapikey failed: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories/common"
+)
+
+// pgxInsertAudits appends the entries to the histories of their Users, each chained to the last entry of the same User.
+// Whatever their number it takes two pipelined round trips, one reading the last hash of every User and one inserting the entries.
+// It must run in the transaction of the mutations, after the User rows are locked, so the entries of a User are written one at a time.
+func pgxInsertAudits(ctx context.Context, tx pgx.Tx, entries ...*models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	prevHashes := map[uuid.UUID]string{}
+	userIDs := []uuid.UUID{}
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		if _, ok := prevHashes[entry.UserID]; !ok {
+			prevHashes[entry.UserID] = ""
+			userIDs = append(userIDs, entry.UserID)
+			batch.Queue(lastAuditHashQuery, entry.UserID)
+		}
+	}
+
+	// The results come back in the order the queries were queued
+	results := tx.SendBatch(ctx, batch)
+	for _, userID := range userIDs {
+		var prevHash string
+		err := results.QueryRow().Scan(&prevHash)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			results.Close()
+			return fmt.Errorf("insertaudit previous hash failed: %w", err)
+		}
+		prevHashes[userID] = prevHash
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("insertaudit previous hash failed: %w", err)
+	}
+
+	batch = &pgx.Batch{}
+	for _, entry := range entries {
+		args, err := chainAuditEntry(entry, prevHashes[entry.UserID])
+		if err != nil {
+			return err
+		}
+		// The next entry of the same User is chained to this one
+		prevHashes[entry.UserID] = entry.Hash
+		batch.Queue(insertAuditQuery, args...)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("insertaudit failed: %w", err)
+	}
+	return nil
+}
+
+// FindUserHistory returns a page of the audit entries of the User, oldest first, with the verification of the hash chain
+func (s *PgxUserRepo) FindUserHistory(ctx context.Context, id uuid.UUID, pageToken string, limit int) (*models.AuditResponse, error) {
+	if limit < 1 || limit > pageLimit {
+		limit = pageLimit
+	}
+
+	fromID, err := common.DecodeBase64ToInt64(pageToken)
+	if err != nil {
+		return nil, fmt.Errorf("finduserhistory pagetoken decoding failed: %w", err)
+	}
+
+	query := `SELECT ` + auditColumns + `
+		FROM U1.USER_AUDIT
+		WHERE USER_ID = $1 AND ID >= $2
+		ORDER BY ID
+		LIMIT $3`
+
+	entries, err := pgxFindAuditEntries(ctx, s.conn(ctx), query, id, fromID, limit+oneForToken)
+	if err != nil {
+		return nil, fmt.Errorf("finduserhistory query failed: %w", err)
+	}
+
+	response := &models.AuditResponse{Entries: entries}
+	if len(entries) > limit {
+		response.PageToken = common.EncodeInt64ToBase64(entries[limit].ID)
+		response.Entries = entries[:limit]
+	}
+	if len(response.Entries) == 0 {
+		response.Verified = true
+		return response, nil
+	}
+
+	// A page is verified from the entry before it, so every page can be checked on its own
+	var prevHash string
+	query = "SELECT HASH FROM U1.USER_AUDIT WHERE USER_ID = $1 AND ID < $2 ORDER BY ID DESC LIMIT 1"
+	err = s.conn(ctx).QueryRow(ctx, query, id, response.Entries[0].ID).Scan(&prevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("finduserhistory previous hash failed: %w", err)
+	}
+	response.Verified = models.VerifyChain(prevHash, response.Entries) == nil
+
+	return response, nil
+}
+
+// pgxFindAuditEntries returns every audit entry selected by the query, which must select auditColumns
+func pgxFindAuditEntries(ctx context.Context, q pgxConn, query string, args ...any) ([]*models.AuditEntry, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *PgxUserRepo) FindUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error) {
+	query := `SELECT ` + auditColumns + `
+	FROM U1.USER_AUDIT
+	WHERE USER_ID = $1 AND CREATED_AT <= $2
+	ORDER BY ID`
+
+	entries, err := pgxFindAuditEntries(ctx, s.conn(ctx), query, id, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("finduserasof query failed: %w", err)
+	}
+
+	user, err := models.ReplayHistory(entries)
+	if err != nil {
+		return nil, fmt.Errorf("finduserasof failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *PgxUserRepo) RevertUser(ctx context.Context, id uuid.UUID, version int64) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	historyQuery := `SELECT ` + auditColumns + `
+	FROM U1.USER_AUDIT
+	WHERE USER_ID = $1 AND ID <= $2
+	ORDER BY ID`
+
+	query := `UPDATE U1.USERS SET
+		FIRST_NAME = $2,
+		LAST_NAME = $3,
+		NICKNAME = $4,
+		EMAIL = $5,
+		COUNTRY = $6,
+		EMAIL_VERIFIED_AT = CASE WHEN LOWER(EMAIL) = LOWER($5) THEN EMAIL_VERIFIED_AT END,
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var revertedUser *models.User
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		currentUser, err := pgxScanUser(tx.QueryRow(ctx, selectQuery, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return models.ErrUserNotFound
+			}
+			return err
+		}
+
+		entries, err := pgxFindAuditEntries(ctx, tx, historyQuery, id, version)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 || entries[len(entries)-1].ID != version {
+			return models.ErrVersionNotFound
+		}
+
+		previousUser, err := models.ReplayHistory(entries)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRow(ctx, query,
+			id,
+			previousUser.FirstName,
+			previousUser.LastName,
+			previousUser.Nickname,
+			previousUser.Email,
+			previousUser.Country,
+		)
+		revertedUser, err = pgxScanUser(row)
+		if err != nil {
+			return err
+		}
+
+		return pgxInsertAudits(ctx, tx, newAuditEntry(ctx, id, models.AuditUpdate, userDiff(currentUser, revertedUser, false)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revertuser failed: %w", mapError(err))
+	}
+	return revertedUser, nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// pgxScanLoginLockout reads a LoginLockout from a row selected with lockoutColumns
+func pgxScanLoginLockout(row pgx.Row) (*models.LoginLockout, error) {
+	lockout := &models.LoginLockout{}
+	if err := row.Scan(&lockout.UserID, &lockout.FailedAttempts, &lockout.LastFailedAt, &lockout.LockedUntil); err != nil {
+		return nil, err
+	}
+	return lockout, nil
+}
+
+func (s *PgxUserRepo) FindLoginLockout(ctx context.Context, email string) (*models.LoginLockout, error) {
+	query := `SELECT ` + lockoutColumns + `
+	FROM U1.USER_LOGIN_FAILURES
+	WHERE USER_ID = (SELECT ID FROM U1.USERS WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL)`
+
+	lockout, err := pgxScanLoginLockout(s.conn(ctx).QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &models.LoginLockout{}, nil
+		}
+		return nil, fmt.Errorf("findloginlockout failed: %w", err)
+	}
+	return lockout, nil
+}
+
+func (s *PgxUserRepo) RecordLoginFailure(ctx context.Context, email string, maxAttempts int, lockFor time.Duration) (*models.LoginLockout, error) {
+	// The increment is a single statement, so concurrent failures are all counted
+	query := `INSERT INTO U1.USER_LOGIN_FAILURES (USER_ID, FAILED_ATTEMPTS, LAST_FAILED_AT)
+	SELECT ID, 1, now() FROM U1.USERS WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL
+	ON CONFLICT (USER_ID) DO UPDATE SET
+		FAILED_ATTEMPTS = U1.USER_LOGIN_FAILURES.FAILED_ATTEMPTS + 1,
+		LAST_FAILED_AT = EXCLUDED.LAST_FAILED_AT
+	RETURNING ` + lockoutColumns
+
+	// Once locked the count starts over, so the delays start over too when the lock expires
+	lockQuery := `UPDATE U1.USER_LOGIN_FAILURES SET
+		FAILED_ATTEMPTS = 0,
+		LOCKED_UNTIL = now() + make_interval(secs => $2)
+	WHERE USER_ID = $1
+	RETURNING ` + lockoutColumns
+
+	var lockout *models.LoginLockout
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		lockout, err = pgxScanLoginLockout(tx.QueryRow(ctx, query, email))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return models.ErrUserNotFound
+			}
+			return err
+		}
+		if lockout.FailedAttempts < maxAttempts {
+			return nil
+		}
+
+		lockout, err = pgxScanLoginLockout(tx.QueryRow(ctx, lockQuery, lockout.UserID, lockFor.Seconds()))
+		if err != nil {
+			return err
+		}
+		lockout.Locked = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recordloginfailure failed: %w", err)
+	}
+	return lockout, nil
+}
+
+func (s *PgxUserRepo) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	query := "DELETE FROM U1.USER_LOGIN_FAILURES WHERE USER_ID = $1"
+	if _, err := s.conn(ctx).Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("resetloginfailures failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PgxUserRepo) UnlockUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `WITH TARGET AS (
+		SELECT ID FROM U1.USERS WHERE ID = $1 AND DELETED_AT IS NULL
+	), CLEARED AS (
+		DELETE FROM U1.USER_LOGIN_FAILURES F USING TARGET
+		WHERE F.USER_ID = TARGET.ID
+		RETURNING F.LOCKED_UNTIL
+	)
+	SELECT
+		EXISTS (SELECT 1 FROM TARGET),
+		EXISTS (SELECT 1 FROM CLEARED WHERE LOCKED_UNTIL > now())`
+
+	var found, wasLocked bool
+	if err := s.conn(ctx).QueryRow(ctx, query, id).Scan(&found, &wasLocked); err != nil {
+		return false, fmt.Errorf("unlockuser failed: %w", err)
+	}
+	if !found {
+		return false, fmt.Errorf("unlockuser returned no rows: %w", models.ErrUserNotFound)
+	}
+	return wasLocked, nil
+}
@@ -0,0 +1,577 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories/common"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
+)
+
+// PgxUserRepo implements models.UserRepository with the native pgx driver, an alternative to the lib/pq UserRepo on the same schema.
+// The pool caches the prepared statements of every connection, the values are scanned into their Go types (uuid.UUID, *time.Time)
+// and the statements of a batch (users, audit entries, recovery codes) are pipelined in a single round trip.
+type PgxUserRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxUserRepo(pool *pgxpool.Pool) *PgxUserRepo {
+	return &PgxUserRepo{
+		pool: pool,
+	}
+}
+
+// pgxScanUser reads a User from a row selected with userColumns, extra receives the columns selected after them.
+// A NULL timestamp is scanned as a nil *time.Time.
+func pgxScanUser(row pgx.Row, extra ...any) (*models.User, error) {
+	user := &models.User{}
+	dest := []any{
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Nickname,
+		&user.Email,
+		&user.Country,
+		&user.ServiceAccount,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	userInUTC(user)
+	return user, nil
+}
+
+// inTx runs fn in its own transaction, committing it when fn succeeds.
+// Within transaction.PgxTxManager.WithinTx fn joins the transaction of the ctx, which is committed or rolled back by its owner.
+func (s *PgxUserRepo) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if tx := transaction.PgxTx(ctx); tx != nil {
+		return fn(tx)
+	}
+	return pgx.BeginFunc(ctx, s.pool, fn)
+}
+
+// pgxConn is implemented by both *pgxpool.Pool and pgx.Tx
+type pgxConn interface {
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+}
+
+// conn returns where the queries of the ctx run, the transaction of the ctx if any or else the pool
+func (s *PgxUserRepo) conn(ctx context.Context) pgxConn {
+	if tx := transaction.PgxTx(ctx); tx != nil {
+		return tx
+	}
+	return s.pool
+}
+
+func (s *PgxUserRepo) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	query := `INSERT INTO U1.USERS (
+		FIRST_NAME,
+		LAST_NAME,
+		NICKNAME,
+		PASSWORD,
+		EMAIL,
+		COUNTRY,
+		SERVICE_ACCOUNT,
+		CREATED_AT,
+		UPDATED_AT
+	) VALUES (
+		$1, --FIRST_NAME
+		$2, --LAST_NAME
+		$3, --NICKNAME
+		$4, --PASSWORD
+		$5, --EMAIL
+		$6, --COUNTRY
+		$7, --SERVICE_ACCOUNT
+		now(), -- CREATED_AT
+		now() -- UPDATED_AT
+	) RETURNING ` + userColumns
+
+	var createdUser *models.User
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, query,
+			user.FirstName,
+			user.LastName,
+			user.Nickname,
+			user.Password,
+			user.Email,
+			user.Country,
+			user.ServiceAccount,
+		)
+
+		var err error
+		createdUser, err = pgxScanUser(row)
+		if err != nil {
+			return err
+		}
+
+		// The User and its first audit entry are committed together
+		return pgxInsertAudits(ctx, tx, newAuditEntry(ctx, createdUser.ID, models.AuditCreate, userDiff(nil, createdUser, true)))
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("createuser returned no rows: %w", err)
+		}
+		return nil, fmt.Errorf("createuser failed: %w", mapError(err))
+	}
+	return createdUser, nil
+}
+
+func (s *PgxUserRepo) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	query := `INSERT INTO U1.USERS (
+		FIRST_NAME,
+		LAST_NAME,
+		NICKNAME,
+		PASSWORD,
+		EMAIL,
+		COUNTRY,
+		SERVICE_ACCOUNT,
+		CREATED_AT,
+		UPDATED_AT
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())
+	RETURNING ` + userColumns
+
+	createdUsers := make([]*models.User, 0, len(users))
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		for start := 0; start < len(users); start += insertChunkSize {
+			end := start + insertChunkSize
+			if end > len(users) {
+				end = len(users)
+			}
+
+			// The same prepared INSERT for every User of the chunk, pipelined. Every INSERT returns its own row and the
+			// results are read in the order they were queued, so the created Users follow the order of the input
+			batch := &pgx.Batch{}
+			for _, user := range users[start:end] {
+				batch.Queue(query, user.FirstName, user.LastName, user.Nickname, user.Password, user.Email, user.Country, user.ServiceAccount)
+			}
+			results := tx.SendBatch(ctx, batch)
+			for range users[start:end] {
+				user, err := pgxScanUser(results.QueryRow())
+				if err != nil {
+					results.Close()
+					return mapError(err)
+				}
+				createdUsers = append(createdUsers, user)
+			}
+			if err := results.Close(); err != nil {
+				return mapError(err)
+			}
+		}
+
+		entries := make([]*models.AuditEntry, 0, len(createdUsers))
+		for _, user := range createdUsers {
+			entries = append(entries, newAuditEntry(ctx, user.ID, models.AuditCreate, userDiff(nil, user, true)))
+		}
+		return pgxInsertAudits(ctx, tx, entries...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("createusers failed: %w", err)
+	}
+	return createdUsers, nil
+}
+
+func (s *PgxUserRepo) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	// The current state is locked until the update is committed so the audit diff can't miss a concurrent change.
+	// The password is left as it is, it is only changed by ChangePassword and ResetPassword.
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	query := `UPDATE U1.USERS SET
+		FIRST_NAME = $2,
+		LAST_NAME = $3,
+		NICKNAME = $4,
+		EMAIL = $5,
+		COUNTRY = $6,
+		-- A new email must be verified again
+		EMAIL_VERIFIED_AT = CASE WHEN LOWER(EMAIL) = LOWER($5) THEN EMAIL_VERIFIED_AT END,
+		UPDATED_AT = now() -- UPDATED_AT
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var updatedUser *models.User
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		currentUser, err := pgxScanUser(tx.QueryRow(ctx, selectQuery, user.ID))
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRow(ctx, query,
+			user.ID,
+			user.FirstName,
+			user.LastName,
+			user.Nickname,
+			user.Email,
+			user.Country,
+		)
+
+		updatedUser, err = pgxScanUser(row)
+		if err != nil {
+			return err
+		}
+
+		return pgxInsertAudits(ctx, tx, newAuditEntry(ctx, updatedUser.ID, models.AuditUpdate, userDiff(currentUser, updatedUser, false)))
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("updateuser returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("updateuser failed: %w", mapError(err))
+	}
+	return updatedUser, nil
+}
+
+func (s *PgxUserRepo) FindUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL`
+
+	user, err := pgxScanUser(s.conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("finduser returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("finduser failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *PgxUserRepo) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL`
+
+	user, err := pgxScanUser(s.conn(ctx).QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("finduserbyemail returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("finduserbyemail failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *PgxUserRepo) FindUsers(ctx context.Context, user *models.User, includeDeleted bool, pageToken string, limit int) (*models.UsersResponse, error) {
+	if limit < 1 || limit > pageLimit {
+		limit = pageLimit
+	}
+
+	userID, err := common.DecodeBase64ToUUID(pageToken)
+	if err != nil {
+		return nil, fmt.Errorf("findUsers pagetoken decoding failed: %w", err)
+	}
+
+	conditions, args := userFilterConditions(user, includeDeleted, 2)
+	query := `SELECT ` + userColumns + `
+		FROM U1.USERS
+		WHERE ID >= $1` + conditions + `
+		ORDER BY ID
+		LIMIT $2`
+
+	rows, err := s.conn(ctx).Query(ctx, query, append([]any{userID, pageLimit + oneForToken}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("findUsers query failed: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := pgxScanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("findUsers failed: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("findUsers failed: %w", err)
+	}
+
+	// The nextPageToken is always a masked last User.ID
+	usersClean, pageToken := common.Paginate(users, limit)
+
+	return &models.UsersResponse{
+		Users:     usersClean,
+		PageToken: pageToken,
+	}, nil
+}
+
+func (s *PgxUserRepo) ExportUsers(ctx context.Context, user *models.User, includeDeleted bool, fn func(*models.User) error) error {
+	conditions, args := userFilterConditions(user, includeDeleted, 0)
+	query := `DECLARE USERS_EXPORT NO SCROLL CURSOR FOR
+		SELECT ` + userColumns + `
+		FROM U1.USERS
+		WHERE TRUE` + conditions + `
+		ORDER BY ID`
+
+	// Cursors only live inside a transaction, the export reads a snapshot of its own even within a transaction of the ctx
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("exportusers begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx) // Nothing to commit, closes the cursor
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exportusers declare cursor failed: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM USERS_EXPORT", exportFetchSize)
+	for {
+		fetched, err := pgxFetchUsers(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// pgxFetchUsers runs a FETCH on the export cursor calling fn for every User and returns how many Users were fetched
+func pgxFetchUsers(ctx context.Context, tx pgx.Tx, fetch string, fn func(*models.User) error) (int, error) {
+	rows, err := tx.Query(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("exportusers fetch failed: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		user, err := pgxScanUser(rows)
+		if err != nil {
+			return fetched, fmt.Errorf("exportusers failed: %w", err)
+		}
+		fetched++
+		if err := fn(user); err != nil {
+			return fetched, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("exportusers failed: %w", err)
+	}
+	return fetched, nil
+}
+
+func (s *PgxUserRepo) CountUsers(ctx context.Context, user *models.User, includeDeleted bool) (int64, error) {
+	conditions, args := userFilterConditions(user, includeDeleted, 0)
+	query := `SELECT COUNT(*)
+		FROM U1.USERS
+		WHERE TRUE` + conditions
+
+	var count int64
+	if err := s.conn(ctx).QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("countusers failed: %w", err)
+	}
+	return count, nil
+}
+
+func (s *PgxUserRepo) UpdateUsersByFilter(ctx context.Context, filter *models.User, patch *models.User) ([]*models.User, error) {
+	// Each chunk locks the next Users by ID, so Users no longer matching the filter after the update are never visited twice.
+	// The old values are returned along with the updated User to write the audit diff.
+	conditions, args := userFilterConditions(filter, false, 5)
+	query := `WITH OLD AS (
+		SELECT ID, FIRST_NAME, LAST_NAME, COUNTRY, UPDATED_AT FROM U1.USERS
+		WHERE ID > $1` + conditions + `
+		ORDER BY ID
+		LIMIT $5
+		FOR UPDATE
+	)
+	UPDATE U1.USERS U SET
+		FIRST_NAME = COALESCE(NULLIF($2, ''), U.FIRST_NAME),
+		LAST_NAME = COALESCE(NULLIF($3, ''), U.LAST_NAME),
+		COUNTRY = COALESCE(NULLIF($4, ''), U.COUNTRY),
+		UPDATED_AT = now()
+	FROM OLD
+	WHERE U.ID = OLD.ID
+	RETURNING ` + qualifiedUserColumns("U") + `, OLD.FIRST_NAME, OLD.LAST_NAME, OLD.COUNTRY, OLD.UPDATED_AT`
+
+	updatedUsers := []*models.User{}
+	lastID := uuid.Nil
+	for {
+		var chunk []*models.User
+		err := s.inTx(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, append([]any{lastID, patch.FirstName, patch.LastName, patch.Country, bulkChunkSize}, args...)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			entries := []*models.AuditEntry{}
+			for rows.Next() {
+				old := &models.User{}
+				user, err := pgxScanUser(rows, &old.FirstName, &old.LastName, &old.Country, &old.UpdatedAt)
+				if err != nil {
+					return err
+				}
+				old.ID, old.Nickname, old.Email, old.ServiceAccount, old.EmailVerifiedAt, old.CreatedAt, old.DeletedAt = user.ID, user.Nickname, user.Email, user.ServiceAccount, user.EmailVerifiedAt, user.CreatedAt, user.DeletedAt
+				chunk = append(chunk, user)
+				entries = append(entries, newAuditEntry(ctx, user.ID, models.AuditUpdate, userDiff(old, user, false)))
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return pgxInsertAudits(ctx, tx, entries...)
+		})
+		if err != nil {
+			return updatedUsers, fmt.Errorf("updateusersbyfilter failed: %w", err)
+		}
+
+		updatedUsers = append(updatedUsers, chunk...)
+		if len(chunk) < bulkChunkSize {
+			return updatedUsers, nil
+		}
+		for _, user := range chunk {
+			lastID = maxID(lastID, user.ID)
+		}
+	}
+}
+
+func (s *PgxUserRepo) RemoveUsersByFilter(ctx context.Context, filter *models.User) ([]uuid.UUID, error) {
+	conditions, args := userFilterConditions(filter, false, 2)
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = now()
+	WHERE ID IN (
+		SELECT ID FROM U1.USERS
+		WHERE ID > $1` + conditions + `
+		ORDER BY ID
+		LIMIT $2
+		FOR UPDATE
+	)
+	RETURNING ID, DELETED_AT`
+
+	removedIDs := []uuid.UUID{}
+	lastID := uuid.Nil
+	for {
+		var chunk []uuid.UUID
+		err := s.inTx(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, query, append([]any{lastID, bulkChunkSize}, args...)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			entries := []*models.AuditEntry{}
+			for rows.Next() {
+				var id uuid.UUID
+				var deletedAt time.Time
+				if err := rows.Scan(&id, &deletedAt); err != nil {
+					return err
+				}
+				chunk = append(chunk, id)
+				entries = append(entries, newAuditEntry(ctx, id, models.AuditDelete, deletedDiff(nil, &deletedAt)))
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return pgxInsertAudits(ctx, tx, entries...)
+		})
+		if err != nil {
+			return removedIDs, fmt.Errorf("removeusersbyfilter failed: %w", err)
+		}
+
+		removedIDs = append(removedIDs, chunk...)
+		if len(chunk) < bulkChunkSize {
+			return removedIDs, nil
+		}
+		for _, id := range chunk {
+			lastID = maxID(lastID, id)
+		}
+	}
+}
+
+func (s *PgxUserRepo) RemoveUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = now()
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	RETURNING DELETED_AT`
+
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		var deletedAt time.Time
+		if err := tx.QueryRow(ctx, query, id).Scan(&deletedAt); err != nil {
+			return err
+		}
+		return pgxInsertAudits(ctx, tx, newAuditEntry(ctx, id, models.AuditDelete, deletedDiff(nil, &deletedAt)))
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("removeuser affected no rows: %w", models.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("removeuser failed: %w", err)
+	}
+	return 1, nil
+}
+
+func (s *PgxUserRepo) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NOT NULL
+	FOR UPDATE`
+
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = NULL,
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var restoredUser *models.User
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		deletedUser, err := pgxScanUser(tx.QueryRow(ctx, selectQuery, id))
+		if err != nil {
+			return err
+		}
+
+		restoredUser, err = pgxScanUser(tx.QueryRow(ctx, query, id))
+		if err != nil {
+			return err
+		}
+		return pgxInsertAudits(ctx, tx, newAuditEntry(ctx, id, models.AuditRestore, userDiff(deletedUser, restoredUser, false)))
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("restoreuser returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("restoreuser failed: %w", mapError(err))
+	}
+	return restoredUser, nil
+}
+
+func (s *PgxUserRepo) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	query := "DELETE FROM U1.USERS WHERE DELETED_AT < $1 RETURNING ID"
+
+	ids := []uuid.UUID{}
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, deletedBefore)
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return err
+		}
+
+		// The history outlives the User, it is the only trace left of it
+		entries := make([]*models.AuditEntry, 0, len(ids))
+		for _, id := range ids {
+			entries = append(entries, newAuditEntry(ctx, id, models.AuditPurge, map[string]*models.FieldChange{}))
+		}
+		return pgxInsertAudits(ctx, tx, entries...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("purgeusers failed: %w", err)
+	}
+	return ids, nil
+}
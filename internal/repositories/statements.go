@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// statements prepares the fixed queries once, on their first use, and reuses them afterwards.
+// A *sql.Stmt is prepared again by database/sql on every connection of the pool it runs on, then kept for that connection,
+// so a query is parsed once per connection instead of a Prepare round trip on every call.
+// Only queries without interpolated values may go through it, a templated query would add a statement for every new filter.
+type statements struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStatements(db *sql.DB) *statements {
+	return &statements{
+		db:    db,
+		stmts: map[string]*sql.Stmt{},
+	}
+}
+
+// prepare returns the statement of the query, preparing it on its first use.
+// The lock is not held while preparing, two concurrent first uses both prepare and the second statement is dropped.
+func (s *statements) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	stmt, ok := s.stmts[query]
+	s.mu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prepared, ok := s.stmts[query]; ok {
+		stmt.Close()
+		return prepared, nil
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
+// QueryRowContext runs the prepared query, a query failing to prepare is run directly so its error is returned by Scan
+func (s *statements) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return s.db.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+func (s *statements) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (s *statements) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// Close closes every prepared statement
+func (s *statements) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for query, stmt := range s.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.stmts, query)
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// stmtConn is implemented by both *statements and *stmtTx
+type stmtConn interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// directConn is implemented by both *sql.DB and *sql.Tx
+type directConn interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// stmtTx is a *sql.Tx running its queries with the prepared statements, bound to the connection of the transaction.
+// The templated queries must use the embedded *sql.Tx methods (e.g. tx.Tx.QueryContext).
+type stmtTx struct {
+	*sql.Tx
+	stmts *statements
+}
+
+func (tx *stmtTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt, err := tx.stmts.prepare(ctx, query)
+	if err != nil {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	}
+	return tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+}
+
+func (tx *stmtTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := tx.stmts.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+}
+
+func (tx *stmtTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, err := tx.stmts.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+}
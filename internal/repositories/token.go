@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+func (s *UserRepo) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	query := `INSERT INTO U1.USER_TOKENS (
+		TOKEN_HASH,
+		PURPOSE,
+		USER_ID,
+		EMAIL,
+		EXPIRES_AT
+	) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.conn(ctx).ExecContext(ctx, query, token.Hash, token.Purpose, token.UserID, token.Email, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("createusertoken failed: %w", err)
+	}
+	return nil
+}
+
+// consumeUserToken marks the token as used and returns it, it fails with models.ErrInvalidToken when the token is unknown, expired or already used
+func consumeUserToken(ctx context.Context, tx *stmtTx, purpose, token string) (*models.UserToken, error) {
+	query := `UPDATE U1.USER_TOKENS SET
+		USED_AT = now()
+	WHERE TOKEN_HASH = $1
+		AND PURPOSE = $2
+		AND USED_AT IS NULL
+		AND EXPIRES_AT > now()
+	RETURNING TOKEN_HASH, PURPOSE, USER_ID, EMAIL, EXPIRES_AT`
+
+	userToken := &models.UserToken{}
+	err := tx.QueryRowContext(ctx, query, tokens.Hash(token), purpose).Scan(
+		&userToken.Hash,
+		&userToken.Purpose,
+		&userToken.UserID,
+		&userToken.Email,
+		&userToken.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return userToken, nil
+}
+
+func (s *UserRepo) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	query := `UPDATE U1.USERS SET
+		EMAIL_VERIFIED_AT = now(),
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var verifiedUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		userToken, err := consumeUserToken(ctx, tx, models.TokenEmailVerification, token)
+		if err != nil {
+			return err
+		}
+
+		currentUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, userToken.UserID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrInvalidToken
+			}
+			return err
+		}
+		// The email changed after the token was sent, a newer token was sent to the new one
+		if !strings.EqualFold(currentUser.Email, userToken.Email) {
+			return models.ErrInvalidToken
+		}
+		if currentUser.EmailVerifiedAt != nil {
+			verifiedUser = currentUser
+			return nil
+		}
+
+		verifiedUser, err = scanUser(tx.QueryRowContext(ctx, query, userToken.UserID))
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, verifiedUser.ID, models.AuditUpdate, userDiff(currentUser, verifiedUser, false))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verifyemail failed: %w", err)
+	}
+	return verifiedUser, nil
+}
+
+// revokeUserTokens marks every unused token of the User for the purpose as used
+func revokeUserTokens(ctx context.Context, tx *stmtTx, userID uuid.UUID, purpose string) error {
+	query := `UPDATE U1.USER_TOKENS SET
+		USED_AT = now()
+	WHERE USER_ID = $1 AND PURPOSE = $2 AND USED_AT IS NULL`
+
+	if _, err := tx.ExecContext(ctx, query, userID, purpose); err != nil {
+		return fmt.Errorf("revoke user tokens failed: %w", err)
+	}
+	return nil
+}
+
+// setPassword changes the password of the locked User, keeps the previous one in its history, revokes its pending password reset
+// and email verification tokens, so a link sent before the change can't be used after it, and records the change
+func setPassword(ctx context.Context, tx *stmtTx, currentUser *models.User, currentPassword, password string) (*models.User, error) {
+	if err := recordPassword(ctx, tx, currentUser.ID, currentPassword); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE U1.USERS SET
+		PASSWORD = $2,
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	updatedUser, err := scanUser(tx.QueryRowContext(ctx, query, currentUser.ID, password))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, purpose := range []string{models.TokenPasswordReset, models.TokenEmailVerification} {
+		if err := revokeUserTokens(ctx, tx, currentUser.ID, purpose); err != nil {
+			return nil, err
+		}
+	}
+
+	return updatedUser, insertAudit(ctx, tx, updatedUser.ID, models.AuditUpdate, userDiff(currentUser, updatedUser, true))
+}
+
+func (s *UserRepo) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `, PASSWORD
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	var updatedUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		userToken, err := consumeUserToken(ctx, tx, models.TokenPasswordReset, token)
+		if err != nil {
+			return err
+		}
+
+		var storedPassword string
+		currentUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, userToken.UserID), &storedPassword)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrInvalidToken
+			}
+			return err
+		}
+		// The link was sent to an email the User no longer has
+		if !strings.EqualFold(currentUser.Email, userToken.Email) {
+			return models.ErrInvalidToken
+		}
+
+		updatedUser, err = setPassword(ctx, tx, currentUser, storedPassword, password)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resetpassword failed: %w", err)
+	}
+	return updatedUser, nil
+}
+
+func (s *UserRepo) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword string, password string) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `, PASSWORD
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
+	var updatedUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		var storedPassword string
+		currentUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, id), &storedPassword)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrUserNotFound
+			}
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(storedPassword), []byte(currentPassword)) != 1 {
+			return models.ErrWrongPassword
+		}
+
+		updatedUser, err = setPassword(ctx, tx, currentUser, storedPassword, password)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("changepassword failed: %w", err)
+	}
+	return updatedUser, nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+func TestUserFilterConditions(t *testing.T) {
+	id := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+
+	tt := []struct {
+		name           string
+		filter         *models.User
+		includeDeleted bool
+		params         int
+		conditions     string
+		args           []any
+	}{
+		{
+			name:       "no filter",
+			filter:     &models.User{},
+			conditions: "\n\t\tAND DELETED_AT IS NULL",
+			args:       []any{},
+		},
+		{
+			name:           "no filter including the deleted Users",
+			filter:         &models.User{},
+			includeDeleted: true,
+			conditions:     "",
+			args:           []any{},
+		},
+		{
+			name:       "values are bound after the params of the query",
+			filter:     &models.User{FirstName: "John", Country: "UK", Email: "john'; DROP TABLE U1.USERS; --", ID: id},
+			params:     2,
+			conditions: "\n\t\tAND FIRST_NAME = $3\n\t\tAND COUNTRY = $4\n\t\tAND EMAIL = $5\n\t\tAND ID >= $6\n\t\tAND DELETED_AT IS NULL",
+			args:       []any{"John", "UK", "john'; DROP TABLE U1.USERS; --", id},
+		},
+		{
+			name:       "email suffix",
+			filter:     &models.User{Email: "*@Example.com"},
+			params:     5,
+			conditions: "\n\t\tAND LOWER(EMAIL) LIKE $6\n\t\tAND DELETED_AT IS NULL",
+			args:       []any{"%@example.com"},
+		},
+		{
+			name:       "email suffix with LIKE wildcards",
+			filter:     &models.User{Email: `*_100%\x.com`},
+			conditions: "\n\t\tAND LOWER(EMAIL) LIKE $1\n\t\tAND DELETED_AT IS NULL",
+			args:       []any{`%\_100\%\\x.com`},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			conditions, args := userFilterConditions(test.filter, test.includeDeleted, test.params)
+			assert.Equal(t, test.conditions, conditions)
+			assert.Equal(t, test.args, args)
+		})
+	}
+}
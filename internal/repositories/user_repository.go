@@ -1,33 +1,155 @@
 package repositories
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories/common"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
 )
 
 const (
-	pageLimit   int = 100 // Is used as the defaul pageLimit
-	oneForToken int = 1   // This will be added to the page limit in order to retrieve the NextPageToken
+	pageLimit       int = 100  // Is used as the defaul pageLimit
+	oneForToken     int = 1    // This will be added to the page limit in order to retrieve the NextPageToken
+	insertChunkSize int = 500  // Max Users per multi-row INSERT, keeps the statement far below the Postgres limit of 65535 params
+	exportFetchSize int = 1000 // Users fetched from the export cursor per round trip
+	bulkChunkSize   int = 500  // Users changed per transaction by the bulk operations by filter
 )
 
-// UserRepo implements models.UserRepository
+// userColumns are the columns returned by every query reading Users. The PASSWORD is never read back.
+const userColumns = "ID, FIRST_NAME, LAST_NAME, NICKNAME, EMAIL, COUNTRY, SERVICE_ACCOUNT, EMAIL_VERIFIED_AT, CREATED_AT, UPDATED_AT, DELETED_AT"
+
+// qualifiedUserColumns returns userColumns prefixed by the table alias, for queries where the names would be ambiguous
+func qualifiedUserColumns(alias string) string {
+	columns := strings.Split(userColumns, ", ")
+	for i, column := range columns {
+		columns[i] = alias + "." + column
+	}
+	return strings.Join(columns, ", ")
+}
+
+// emailSuffixWildcard starts an email filter matching the emails ending with the rest of it, e.g. "*@example.com"
+const emailSuffixWildcard = "*"
+
+// likeEscaper escapes the wildcards of LIKE, so a filter value only matches itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// userFilterConditions returns the conditions shared by the queries filtering Users and their args. The values are always bound,
+// never written in the query text, as the params numbered after the params the query already has.
+func userFilterConditions(filter *models.User, includeDeleted bool, params int) (string, []any) {
+	var conditions strings.Builder
+	args := []any{}
+	add := func(condition string, value any) {
+		args = append(args, value)
+		fmt.Fprintf(&conditions, "\n\t\tAND "+condition, params+len(args))
+	}
+
+	if filter.FirstName != "" {
+		add("FIRST_NAME = $%d", filter.FirstName)
+	}
+	if filter.LastName != "" {
+		add("LAST_NAME = $%d", filter.LastName)
+	}
+	if filter.Nickname != "" {
+		add("NICKNAME = $%d", filter.Nickname)
+	}
+	if filter.Country != "" {
+		add("COUNTRY = $%d", filter.Country)
+	}
+	if suffix := strings.TrimPrefix(filter.Email, emailSuffixWildcard); suffix != filter.Email {
+		add("LOWER(EMAIL) LIKE $%d", "%"+likeEscaper.Replace(strings.ToLower(suffix)))
+	} else if filter.Email != "" {
+		add("EMAIL = $%d", filter.Email)
+	}
+	if filter.ID != uuid.Nil {
+		add("ID >= $%d", filter.ID)
+	}
+	if !includeDeleted {
+		conditions.WriteString("\n\t\tAND DELETED_AT IS NULL")
+	}
+	return conditions.String(), args
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser reads a User from a row selected with userColumns, extra receives the columns selected after them
+func scanUser(row scanner, extra ...any) (*models.User, error) {
+	user := &models.User{}
+	var emailVerifiedAt, deletedAt sql.NullTime
+	dest := []any{
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Nickname,
+		&user.Email,
+		&user.Country,
+		&user.ServiceAccount,
+		&emailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&deletedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	userInUTC(user)
+	return user, nil
+}
+
+// userInUTC converts the timestamps of the scanned User to UTC, a TIMESTAMPTZ is read in the TimeZone of the session
+// with lib/pq and in the local one of the server with pgx
+func userInUTC(user *models.User) {
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	user.EmailVerifiedAt = utc(user.EmailVerifiedAt)
+	user.DeletedAt = utc(user.DeletedAt)
+}
+
+// utc returns the time in UTC, nil when it is nil
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// UserRepo implements models.UserRepository, the fixed queries run through the statements prepared on their first use
 type UserRepo struct {
-	db *sql.DB
+	db    *sql.DB
+	stmts *statements
 }
 
 func NewUserRepo(db *sql.DB) *UserRepo {
 	return &UserRepo{
-		db: db,
+		db:    db,
+		stmts: newStatements(db),
 	}
 }
 
+// Close releases the prepared statements, the DB is left open
+func (s *UserRepo) Close() error {
+	return s.stmts.Close()
+}
+
 func (s *UserRepo) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	query := `INSERT INTO U1.USERS (
 		FIRST_NAME,
@@ -36,6 +158,7 @@ func (s *UserRepo) CreateUser(ctx context.Context, user *models.User) (*models.U
 		PASSWORD,
 		EMAIL,
 		COUNTRY,
+		SERVICE_ACCOUNT,
 		CREATED_AT,
 		UPDATED_AT
 	) VALUES (
@@ -45,94 +168,213 @@ func (s *UserRepo) CreateUser(ctx context.Context, user *models.User) (*models.U
 		$4, --PASSWORD
 		$5, --EMAIL
 		$6, --COUNTRY
-		now() AT TIME ZONE 'utc', -- CREATED_AT
-		now() AT TIME ZONE 'utc' -- UPDATED_AT
-	) RETURNING ID, FIRST_NAME, LAST_NAME, NICKNAME, EMAIL, COUNTRY, CREATED_AT, UPDATED_AT`
+		$7, --SERVICE_ACCOUNT
+		now(), -- CREATED_AT
+		now() -- UPDATED_AT
+	) RETURNING ` + userColumns
 
-	stmt, err := s.db.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("createuser prepare context failed: %w", err)
-	}
-	defer stmt.Close()
+	var createdUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		row := tx.QueryRowContext(ctx, query,
+			user.FirstName,
+			user.LastName,
+			user.Nickname,
+			user.Password,
+			user.Email,
+			user.Country,
+			user.ServiceAccount,
+		)
 
-	row := stmt.QueryRow(
-		user.FirstName,
-		user.LastName,
-		user.Nickname,
-		user.Password,
-		user.Email,
-		user.Country,
-	)
+		var err error
+		createdUser, err = scanUser(row)
+		if err != nil {
+			return err
+		}
 
-	createdUser := &models.User{}
-	err = row.Scan(
-		&createdUser.ID,
-		&createdUser.FirstName,
-		&createdUser.LastName,
-		&createdUser.Nickname,
-		&createdUser.Email,
-		&createdUser.Country,
-		&createdUser.CreatedAt,
-		&createdUser.UpdatedAt,
-	)
+		// The User and its first audit entry are committed together
+		return insertAudit(ctx, tx, createdUser.ID, models.AuditCreate, userDiff(nil, createdUser, true))
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("createuser returned no rows: %w", err)
 		}
-		return nil, fmt.Errorf("createuser failed: %w", err)
+		return nil, fmt.Errorf("createuser failed: %w", mapError(err))
 	}
 	return createdUser, nil
 }
 
+func (s *UserRepo) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	createdUsers := make([]*models.User, 0, len(users))
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		for start := 0; start < len(users); start += insertChunkSize {
+			end := start + insertChunkSize
+			if end > len(users) {
+				end = len(users)
+			}
+
+			created, err := insertUsers(ctx, tx.Tx, users[start:end])
+			if err != nil {
+				return err
+			}
+			createdUsers = append(createdUsers, created...)
+		}
+
+		for _, user := range createdUsers {
+			if err := insertAudit(ctx, tx, user.ID, models.AuditCreate, userDiff(nil, user, true)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("createusers failed: %w", err)
+	}
+	return createdUsers, nil
+}
+
+// insertUsers creates the Users with a single multi-row INSERT. Postgres does not guarantee RETURNING follows the order of
+// the VALUES list, so the rows are matched back to the Users by their email, unique case-insensitive
+func insertUsers(ctx context.Context, tx *sql.Tx, users []*models.User) ([]*models.User, error) {
+	var values strings.Builder
+	args := make([]any, 0, len(users)*7)
+	for i, user := range users {
+		if i > 0 {
+			values.WriteString(",")
+		}
+		n := len(args)
+		fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, now(), now())", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+		args = append(args, user.FirstName, user.LastName, user.Nickname, user.Password, user.Email, user.Country, user.ServiceAccount)
+	}
+
+	query := `INSERT INTO U1.USERS (
+		FIRST_NAME,
+		LAST_NAME,
+		NICKNAME,
+		PASSWORD,
+		EMAIL,
+		COUNTRY,
+		SERVICE_ACCOUNT,
+		CREATED_AT,
+		UPDATED_AT
+	) VALUES ` + values.String() + ` RETURNING ` + userColumns
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("createusers query failed: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	positions := make(map[string]int, len(users))
+	for i, user := range users {
+		positions[strings.ToLower(user.Email)] = i
+	}
+
+	createdUsers := make([]*models.User, len(users))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("createusers failed: %w", err)
+		}
+		i, ok := positions[strings.ToLower(user.Email)]
+		if !ok {
+			return nil, fmt.Errorf("createusers returned an unexpected email %q", user.Email)
+		}
+		createdUsers[i] = user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("createusers failed: %w", mapError(err))
+	}
+	for i, user := range createdUsers {
+		if user == nil {
+			return nil, fmt.Errorf("createusers returned no row for user %d", i)
+		}
+	}
+	return createdUsers, nil
+}
+
 func (s *UserRepo) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	// The current state is locked until the update is committed so the audit diff can't miss a concurrent change.
+	// The password is left as it is, it is only changed by ChangePassword and ResetPassword.
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	FOR UPDATE`
+
 	query := `UPDATE U1.USERS SET
 		FIRST_NAME = $2,
 		LAST_NAME = $3,
 		NICKNAME = $4,
-		PASSWORD = $5,
-		EMAIL = $6,
-		COUNTRY = $7,
-		UPDATED_AT = now() AT TIME ZONE 'utc' -- UPDATED_AT
+		EMAIL = $5,
+		COUNTRY = $6,
+		-- A new email must be verified again
+		EMAIL_VERIFIED_AT = CASE WHEN LOWER(EMAIL) = LOWER($5) THEN EMAIL_VERIFIED_AT END,
+		UPDATED_AT = now() -- UPDATED_AT
 	WHERE ID = $1
-	RETURNING ID, FIRST_NAME, LAST_NAME, NICKNAME, EMAIL, COUNTRY, CREATED_AT, UPDATED_AT`
+	RETURNING ` + userColumns
 
-	stmt, err := s.db.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("updateuser prepare context failed: %w", err)
-	}
-	defer stmt.Close()
-
-	row := stmt.QueryRow(
-		user.ID,
-		user.FirstName,
-		user.LastName,
-		user.Nickname,
-		user.Password,
-		user.Email,
-		user.Country,
-	)
+	var updatedUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		currentUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, user.ID))
+		if err != nil {
+			return err
+		}
 
-	updatedUser := &models.User{}
-	err = row.Scan(
-		&updatedUser.ID,
-		&updatedUser.FirstName,
-		&updatedUser.LastName,
-		&updatedUser.Nickname,
-		&updatedUser.Email,
-		&updatedUser.Country,
-		&updatedUser.CreatedAt,
-		&updatedUser.UpdatedAt,
-	)
+		row := tx.QueryRowContext(ctx, query,
+			user.ID,
+			user.FirstName,
+			user.LastName,
+			user.Nickname,
+			user.Email,
+			user.Country,
+		)
+
+		updatedUser, err = scanUser(row)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, updatedUser.ID, models.AuditUpdate, userDiff(currentUser, updatedUser, false))
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("updateuser returned no rows: %w", err)
+			return nil, fmt.Errorf("updateuser returned no rows: %w", models.ErrUserNotFound)
 		}
-		return nil, fmt.Errorf("updateuser failed: %w", err)
+		return nil, fmt.Errorf("updateuser failed: %w", mapError(err))
 	}
 	return updatedUser, nil
 }
 
-func (s *UserRepo) FindUsers(ctx context.Context, user *models.User, pageToken string, limit int) (*models.UsersResponse, error) {
+func (s *UserRepo) FindUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL`
+
+	user, err := scanUser(s.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("finduser returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("finduser failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserRepo) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE LOWER(EMAIL) = LOWER($1) AND DELETED_AT IS NULL`
+
+	user, err := scanUser(s.conn(ctx).QueryRowContext(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("finduserbyemail returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("finduserbyemail failed: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserRepo) FindUsers(ctx context.Context, user *models.User, includeDeleted bool, pageToken string, limit int) (*models.UsersResponse, error) {
 
 	if limit < 1 || limit > pageLimit {
 		limit = pageLimit
@@ -143,42 +385,18 @@ func (s *UserRepo) FindUsers(ctx context.Context, user *models.User, pageToken s
 		return nil, fmt.Errorf("findUsers pagetoken decoding failed: %w", err)
 	}
 
-	template := `SELECT
-		ID,
-		FIRST_NAME,
-		LAST_NAME,
-		NICKNAME,
-		EMAIL,
-		COUNTRY,
-		CREATED_AT,
-		UPDATED_AT
+	/* The conditions of the query depend on the values that are existing in the user param.
+	The alternative https://github.com/Masterminds/squirrel seemed to me too overpower to use in just one query.
+	*/
+	conditions, args := userFilterConditions(user, includeDeleted, 2)
+	query := `SELECT ` + userColumns + `
 		FROM U1.USERS
-		WHERE ID >= $1
-		{{if .FirstName}} AND FIRST_NAME = '{{.FirstName}}' {{end}}
-		{{if .LastName}} AND LAST_NAME = '{{.LastName}}' {{end}}
-		{{if .Nickname}} AND NICKNAME = '{{.Nickname}}' {{end}}
-		{{if .Country}} AND COUNTRY = '{{.Country}}' {{end}}
-		{{if .Email}} AND EMAIL = '{{.Email}}' {{end}}
-		{{if .ID}} AND ID >= '{{.ID}}' {{end}}
+		WHERE ID >= $1` + conditions + `
 		ORDER BY ID
 		LIMIT $2`
 
-	/* The query above is a template and will be filled depending of the
-	values that are existing in the user param.
-	The alternative https://github.com/Masterminds/squirrel seemed to me too overpower to use in just one query.
-	*/
-	query, err := common.ProcessTemplate(template, user)
-	if err != nil {
-		return nil, fmt.Errorf("findUsers templating failed: %w", err)
-	}
-
-	stmt, err := s.db.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("findUsers preparation failed: %w", err)
-	}
-	defer stmt.Close()
-
-	rows, err := stmt.Query(userID, pageLimit+oneForToken)
+	// The query text depends on the filters, preparing it would only add a round trip
+	rows, err := s.direct(ctx).QueryContext(ctx, query, append([]any{userID, pageLimit + oneForToken}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("findUsers query failed: %w", err)
 	}
@@ -187,25 +405,14 @@ func (s *UserRepo) FindUsers(ctx context.Context, user *models.User, pageToken s
 	users := []*models.User{}
 
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(
-			&user.ID,
-			&user.FirstName,
-			&user.LastName,
-			&user.Nickname,
-			&user.Email,
-			&user.Country,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		); err != nil {
-			if err != nil {
-				if errors.Is(err, sql.ErrNoRows) {
-					return nil, fmt.Errorf("findUsers returned no rows: %w", err)
-				}
-				return nil, fmt.Errorf("findUsers failed: %w", err)
+		user, err := scanUser(rows)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("findUsers returned no rows: %w", err)
 			}
+			return nil, fmt.Errorf("findUsers failed: %w", err)
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	// The nextPageToken is always a masked last User.ID
@@ -217,18 +424,332 @@ func (s *UserRepo) FindUsers(ctx context.Context, user *models.User, pageToken s
 	}, nil
 }
 
+func (s *UserRepo) ExportUsers(ctx context.Context, user *models.User, includeDeleted bool, fn func(*models.User) error) error {
+	conditions, args := userFilterConditions(user, includeDeleted, 0)
+	query := `DECLARE USERS_EXPORT NO SCROLL CURSOR FOR
+		SELECT ` + userColumns + `
+		FROM U1.USERS
+		WHERE TRUE` + conditions + `
+		ORDER BY ID`
+
+	// Cursors only live inside a transaction, the export reads a snapshot of its own even within a transaction of the ctx
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("exportusers begin tx failed: %w", err)
+	}
+	defer tx.Rollback() // Nothing to commit, closes the cursor
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exportusers declare cursor failed: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM USERS_EXPORT", exportFetchSize)
+	for {
+		fetched, err := fetchUsers(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// fetchUsers runs a FETCH on the export cursor calling fn for every User and returns how many Users were fetched
+func fetchUsers(ctx context.Context, tx *sql.Tx, fetch string, fn func(*models.User) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("exportusers fetch failed: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return fetched, fmt.Errorf("exportusers failed: %w", err)
+		}
+		fetched++
+		if err := fn(user); err != nil {
+			return fetched, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("exportusers failed: %w", err)
+	}
+	return fetched, nil
+}
+
+func (s *UserRepo) CountUsers(ctx context.Context, user *models.User, includeDeleted bool) (int64, error) {
+	conditions, args := userFilterConditions(user, includeDeleted, 0)
+	query := `SELECT COUNT(*)
+		FROM U1.USERS
+		WHERE TRUE` + conditions
+
+	var count int64
+	if err := s.direct(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("countusers failed: %w", err)
+	}
+	return count, nil
+}
+
+func (s *UserRepo) UpdateUsersByFilter(ctx context.Context, filter *models.User, patch *models.User) ([]*models.User, error) {
+	// Each chunk locks the next Users by ID, so Users no longer matching the filter after the update are never visited twice.
+	// The old values are returned along with the updated User to write the audit diff.
+	conditions, args := userFilterConditions(filter, false, 5)
+	query := `WITH OLD AS (
+		SELECT ID, FIRST_NAME, LAST_NAME, COUNTRY, UPDATED_AT FROM U1.USERS
+		WHERE ID > $1` + conditions + `
+		ORDER BY ID
+		LIMIT $5
+		FOR UPDATE
+	)
+	UPDATE U1.USERS U SET
+		FIRST_NAME = COALESCE(NULLIF($2, ''), U.FIRST_NAME),
+		LAST_NAME = COALESCE(NULLIF($3, ''), U.LAST_NAME),
+		COUNTRY = COALESCE(NULLIF($4, ''), U.COUNTRY),
+		UPDATED_AT = now()
+	FROM OLD
+	WHERE U.ID = OLD.ID
+	RETURNING ` + qualifiedUserColumns("U") + `, OLD.FIRST_NAME, OLD.LAST_NAME, OLD.COUNTRY, OLD.UPDATED_AT`
+
+	updatedUsers := []*models.User{}
+	lastID := uuid.Nil
+	for {
+		var chunk []*models.User
+		err := s.inTx(ctx, func(tx *stmtTx) error {
+			rows, err := tx.Tx.QueryContext(ctx, query, append([]any{lastID, patch.FirstName, patch.LastName, patch.Country, bulkChunkSize}, args...)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			oldUsers := []*models.User{}
+			for rows.Next() {
+				old := &models.User{}
+				user, err := scanUser(rows, &old.FirstName, &old.LastName, &old.Country, &old.UpdatedAt)
+				if err != nil {
+					return err
+				}
+				old.ID, old.Nickname, old.Email, old.ServiceAccount, old.EmailVerifiedAt, old.CreatedAt, old.DeletedAt = user.ID, user.Nickname, user.Email, user.ServiceAccount, user.EmailVerifiedAt, user.CreatedAt, user.DeletedAt
+				chunk = append(chunk, user)
+				oldUsers = append(oldUsers, old)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for i, user := range chunk {
+				if err := insertAudit(ctx, tx, user.ID, models.AuditUpdate, userDiff(oldUsers[i], user, false)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return updatedUsers, fmt.Errorf("updateusersbyfilter failed: %w", err)
+		}
+
+		updatedUsers = append(updatedUsers, chunk...)
+		if len(chunk) < bulkChunkSize {
+			return updatedUsers, nil
+		}
+		for _, user := range chunk {
+			lastID = maxID(lastID, user.ID)
+		}
+	}
+}
+
+func (s *UserRepo) RemoveUsersByFilter(ctx context.Context, filter *models.User) ([]uuid.UUID, error) {
+	conditions, args := userFilterConditions(filter, false, 2)
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = now()
+	WHERE ID IN (
+		SELECT ID FROM U1.USERS
+		WHERE ID > $1` + conditions + `
+		ORDER BY ID
+		LIMIT $2
+		FOR UPDATE
+	)
+	RETURNING ID, DELETED_AT`
+
+	removedIDs := []uuid.UUID{}
+	lastID := uuid.Nil
+	for {
+		var chunk []uuid.UUID
+		err := s.inTx(ctx, func(tx *stmtTx) error {
+			rows, err := tx.Tx.QueryContext(ctx, query, append([]any{lastID, bulkChunkSize}, args...)...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			deletedAts := []time.Time{}
+			for rows.Next() {
+				var id uuid.UUID
+				var deletedAt time.Time
+				if err := rows.Scan(&id, &deletedAt); err != nil {
+					return err
+				}
+				chunk = append(chunk, id)
+				deletedAts = append(deletedAts, deletedAt)
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for i, id := range chunk {
+				if err := insertAudit(ctx, tx, id, models.AuditDelete, deletedDiff(nil, &deletedAts[i])); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return removedIDs, fmt.Errorf("removeusersbyfilter failed: %w", err)
+		}
+
+		removedIDs = append(removedIDs, chunk...)
+		if len(chunk) < bulkChunkSize {
+			return removedIDs, nil
+		}
+		for _, id := range chunk {
+			lastID = maxID(lastID, id)
+		}
+	}
+}
+
+// inTx runs fn in its own transaction, committing it when fn succeeds.
+// Within transaction.TxManager.WithinTx fn joins the transaction of the ctx, which is committed or rolled back by its owner.
+func (s *UserRepo) inTx(ctx context.Context, fn func(tx *stmtTx) error) error {
+	if tx := transaction.SQLTx(ctx); tx != nil {
+		return fn(&stmtTx{Tx: tx, stmts: s.stmts})
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	if err := fn(&stmtTx{Tx: tx, stmts: s.stmts}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns where the fixed queries of the ctx run, the transaction of the ctx if any or else the pool
+func (s *UserRepo) conn(ctx context.Context) stmtConn {
+	if tx := transaction.SQLTx(ctx); tx != nil {
+		return &stmtTx{Tx: tx, stmts: s.stmts}
+	}
+	return s.stmts
+}
+
+// direct is like conn for the templated queries, which are never prepared
+func (s *UserRepo) direct(ctx context.Context) directConn {
+	if tx := transaction.SQLTx(ctx); tx != nil {
+		return tx
+	}
+	return s.db
+}
+
+// maxID returns the greatest of the UUIDs using the same byte ordering as Postgres
+func maxID(a, b uuid.UUID) uuid.UUID {
+	if bytes.Compare(a[:], b[:]) >= 0 {
+		return a
+	}
+	return b
+}
+
 func (s *UserRepo) RemoveUser(ctx context.Context, id uuid.UUID) (int64, error) {
-	query := "DELETE FROM U1.USERS WHERE id = $1"
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = now()
+	WHERE ID = $1 AND DELETED_AT IS NULL
+	RETURNING DELETED_AT`
+
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		var deletedAt time.Time
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&deletedAt); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, id, models.AuditDelete, deletedDiff(nil, &deletedAt))
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("removeuser affected no rows: %w", models.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("removeuser failed: %w", err)
+	}
+	return 1, nil
+}
+
+func (s *UserRepo) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	selectQuery := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NOT NULL
+	FOR UPDATE`
+
+	query := `UPDATE U1.USERS SET
+		DELETED_AT = NULL,
+		UPDATED_AT = now()
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	var restoredUser *models.User
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		deletedUser, err := scanUser(tx.QueryRowContext(ctx, selectQuery, id))
+		if err != nil {
+			return err
+		}
 
-	stmt, err := s.db.PrepareContext(ctx, query)
+		restoredUser, err = scanUser(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, id, models.AuditRestore, userDiff(deletedUser, restoredUser, false))
+	})
 	if err != nil {
-		return 0, fmt.Errorf("removeuser prepare context failed: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("restoreuser returned no rows: %w", models.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("restoreuser failed: %w", mapError(err))
 	}
-	defer stmt.Close()
+	return restoredUser, nil
+}
+
+func (s *UserRepo) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	query := "DELETE FROM U1.USERS WHERE DELETED_AT < $1 RETURNING ID"
+
+	ids := []uuid.UUID{}
+	err := s.inTx(ctx, func(tx *stmtTx) error {
+		rows, err := tx.QueryContext(ctx, query, deletedBefore)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 
-	result, err := stmt.ExecContext(ctx, id)
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// The history outlives the User, it is the only trace left of it
+		for _, id := range ids {
+			if err := insertAudit(ctx, tx, id, models.AuditPurge, map[string]*models.FieldChange{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("removeuser exec context failed: %w", err)
+		return nil, fmt.Errorf("purgeusers failed: %w", err)
 	}
-	return result.RowsAffected()
+	return ids, nil
 }
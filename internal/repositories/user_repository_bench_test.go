@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/migrator"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// benchPostgresEnv is the URL of the local Postgres the benchmarks and the conformance tests run against, they are skipped when it is not set:
+//
+//	MANAGE_USER_GO_BENCH_POSTGRES='postgresql://localhost/db?user=username&password=password&sslmode=disable' \
+//	go test ./internal/repositories -run '^$' -bench . -benchtime 10s
+const benchPostgresEnv = "MANAGE_USER_GO_BENCH_POSTGRES"
+
+// benchURL returns the URL of the migrated benchmark DB
+func benchURL(tb testing.TB) string {
+	url := os.Getenv(benchPostgresEnv)
+	if url == "" {
+		tb.Skipf("%s is not set", benchPostgresEnv)
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer db.Close()
+	if err := migrator.MigrateDB(context.Background(), db, ""); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		tb.Fatal(err)
+	}
+	return url
+}
+
+// benchRepo returns a UserRepo on the migrated benchmark DB, pooled like the service by default
+func benchRepo(tb testing.TB) *UserRepo {
+	db, err := sql.Open("postgres", benchURL(tb))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	tb.Cleanup(func() { db.Close() })
+
+	repo := NewUserRepo(db)
+	tb.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// benchPgxRepo returns a PgxUserRepo on the migrated benchmark DB, with as many connections as benchRepo
+func benchPgxRepo(tb testing.TB) *PgxUserRepo {
+	config, err := pgxpool.ParseConfig(benchURL(tb))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	config.MaxConns = 25
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(pool.Close)
+	return NewPgxUserRepo(pool)
+}
+
+// benchUser returns a User with unique email and nickname
+func benchUser() *models.User {
+	id := uuid.NewString()
+	return &models.User{
+		FirstName: "Bench",
+		LastName:  "Mark",
+		Nickname:  id,
+		Password:  "Passw0rd!",
+		Email:     id + "@bench.local",
+		Country:   "UK",
+	}
+}
+
+// findUserPreparedPerCall is FindUser as it was before the statements, preparing and closing the query on every call
+func findUserPreparedPerCall(ctx context.Context, db *sql.DB, id uuid.UUID) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
+	FROM U1.USERS
+	WHERE ID = $1 AND DELETED_AT IS NULL`
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	return scanUser(stmt.QueryRowContext(ctx, id))
+}
+
+func BenchmarkFindUser(b *testing.B) {
+	repo := benchRepo(b)
+	ctx := context.Background()
+	user, err := repo.CreateUser(ctx, benchUser())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("prepared per call", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := findUserPreparedPerCall(ctx, repo.db, user.ID); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("prepared once", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := repo.FindUser(ctx, user.ID); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("pgx", func(b *testing.B) {
+		pgxRepo := benchPgxRepo(b)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := pgxRepo.FindUser(ctx, user.ID); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkCreateUser(b *testing.B) {
+	repo := benchRepo(b)
+	ctx := context.Background()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := repo.CreateUser(ctx, benchUser()); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCreateUsers(b *testing.B) {
+	ctx := context.Background()
+	batch := func() []*models.User {
+		users := make([]*models.User, 100)
+		for i := range users {
+			users[i] = benchUser()
+		}
+		return users
+	}
+
+	b.Run("pq", func(b *testing.B) {
+		repo := benchRepo(b)
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.CreateUsers(ctx, batch()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pgx", func(b *testing.B) {
+		repo := benchPgxRepo(b)
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.CreateUsers(ctx, batch()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,581 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+// The conformance tests run the same expectations against both UserRepository implementations, so the pq and pgx copies can't drift apart.
+// They run against the DB of the benchmarks and are skipped when benchPostgresEnv is not set:
+//
+//	MANAGE_USER_GO_BENCH_POSTGRES='postgresql://localhost/db?user=username&password=password&sslmode=disable' \
+//	go test ./internal/repositories -run Conformance
+var conformanceTests = []struct {
+	name string
+	test func(t *testing.T, repo userStores)
+}{
+	{name: "create and find", test: testCreateFind},
+	{name: "create in batch", test: testCreateUsers},
+	{name: "find by filter", test: testFindByFilter},
+	{name: "update", test: testUpdate},
+	{name: "update and remove by filter", test: testByFilter},
+	{name: "remove, restore and history", test: testRemoveRestore},
+	{name: "revert", test: testRevert},
+	{name: "tokens", test: testTokens},
+	{name: "passwords", test: testPasswords},
+	{name: "mfa", test: testMFA},
+	{name: "login lockout", test: testLockout},
+	{name: "api keys", test: testAPIKeys},
+}
+
+// userStores are the stores both implementations provide besides the UserRepository
+type userStores interface {
+	models.UserRepository
+	models.UserTokenRepository
+	models.PasswordHistoryRepository
+	models.MFARepository
+	models.LoginLockoutRepository
+	models.APIKeyRepository
+}
+
+func TestUserRepositoryConformance(t *testing.T) {
+	repos := map[string]func(tb testing.TB) userStores{
+		"pq":  func(tb testing.TB) userStores { return benchRepo(tb) },
+		"pgx": func(tb testing.TB) userStores { return benchPgxRepo(tb) },
+	}
+
+	for name, newRepo := range repos {
+		newRepo := newRepo
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			for _, conformance := range conformanceTests {
+				conformance := conformance
+				t.Run(conformance.name, func(t *testing.T) {
+					conformance.test(t, repo)
+				})
+			}
+		})
+	}
+}
+
+// conformanceDomain returns an email domain of its own, so the filters of a test only match the Users it created
+func conformanceDomain() string {
+	return "@" + uuid.NewString() + ".conformance.local"
+}
+
+// conformanceUser returns a User with a unique nickname and an email in the domain
+func conformanceUser(domain string) *models.User {
+	id := uuid.NewString()
+	return &models.User{
+		FirstName: "Conformance",
+		LastName:  "Test",
+		Nickname:  id,
+		Password:  "Passw0rd!",
+		Email:     id + domain,
+		Country:   "UK",
+	}
+}
+
+func mustCreate(t *testing.T, repo models.UserRepository, user *models.User) *models.User {
+	t.Helper()
+	created, err := repo.CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func testCreateFind(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	user := conformanceUser(conformanceDomain())
+
+	created := mustCreate(t, repo, user)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.Equal(t, user.Email, created.Email)
+	assert.Empty(t, created.Password)
+	assert.Nil(t, created.EmailVerifiedAt)
+	assert.Equal(t, time.UTC, created.CreatedAt.Location())
+
+	found, err := repo.FindUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	found, err = repo.FindUserByEmail(ctx, upper(user.Email))
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+
+	_, err = repo.FindUser(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = repo.FindUserByEmail(ctx, "nobody"+conformanceDomain())
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	// The email is unique whatever its case
+	duplicate := conformanceUser(conformanceDomain())
+	duplicate.Email = upper(user.Email)
+	_, err = repo.CreateUser(ctx, duplicate)
+	var duplicateErr *models.DuplicateError
+	if assert.True(t, errors.As(err, &duplicateErr)) {
+		assert.Equal(t, "email", duplicateErr.Field)
+	}
+}
+
+// upper returns the ASCII value in upper case
+func upper(value string) string {
+	b := []byte(value)
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
+
+func testCreateUsers(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	domain := conformanceDomain()
+	users := []*models.User{conformanceUser(domain), conformanceUser(domain), conformanceUser(domain)}
+
+	created, err := repo.CreateUsers(ctx, users)
+	assert.NoError(t, err)
+	if assert.Len(t, created, len(users)) {
+		for i, user := range users {
+			assert.Equal(t, user.Email, created[i].Email)
+			assert.NotEqual(t, uuid.Nil, created[i].ID)
+		}
+	}
+
+	// A duplicated row fails the whole batch
+	domain = conformanceDomain()
+	duplicate := conformanceUser(domain)
+	_, err = repo.CreateUsers(ctx, []*models.User{conformanceUser(domain), duplicate, duplicate})
+	var duplicateErr *models.DuplicateError
+	assert.True(t, errors.As(err, &duplicateErr))
+	count, err := repo.CountUsers(ctx, &models.User{Email: "*" + domain}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func testFindByFilter(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	domain := conformanceDomain()
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ids = append(ids, mustCreate(t, repo, conformanceUser(domain)).ID)
+	}
+	other := conformanceUser(domain)
+	other.Country = "BR"
+	other = mustCreate(t, repo, other)
+	_, err := repo.RemoveUser(ctx, other.ID)
+	assert.NoError(t, err)
+
+	filter := &models.User{Email: "*" + upper(domain), Country: "UK"}
+
+	// Every page but the last has a token to the next one
+	var found []uuid.UUID
+	pageToken := ""
+	for page := 0; page < 3; page++ {
+		response, err := repo.FindUsers(ctx, filter, false, pageToken, 2)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, user := range response.Users {
+			found = append(found, user.ID)
+		}
+		if pageToken = response.PageToken; pageToken == "" {
+			break
+		}
+	}
+	assert.ElementsMatch(t, ids, found)
+
+	count, err := repo.CountUsers(ctx, filter, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// The soft-deleted Users are only found when included
+	count, err = repo.CountUsers(ctx, &models.User{Email: "*" + domain}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	count, err = repo.CountUsers(ctx, &models.User{Email: "*" + domain}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	var exported []uuid.UUID
+	err = repo.ExportUsers(ctx, &models.User{Email: "*" + domain}, true, func(user *models.User) error {
+		exported = append(exported, user.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, append(ids, other.ID), exported)
+
+	// The LIKE wildcards of the filter are matched literally
+	count, err = repo.CountUsers(ctx, &models.User{Email: "*%" + domain[1:]}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func testUpdate(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	update := *created
+	update.FirstName = "Updated"
+	update.Password = "N3wPassw0rd!"
+	updated, err := repo.UpdateUser(ctx, &update)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated", updated.FirstName)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+	assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+
+	// The password is only changed by ChangePassword and ResetPassword
+	_, err = repo.Authenticate(ctx, created.Email, "Passw0rd!")
+	assert.NoError(t, err)
+
+	found, err := repo.FindUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, found)
+
+	update.ID = uuid.New()
+	_, err = repo.UpdateUser(ctx, &update)
+	assert.Error(t, err)
+}
+
+func testByFilter(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	domain := conformanceDomain()
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ids = append(ids, mustCreate(t, repo, conformanceUser(domain)).ID)
+	}
+	untouched := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	updated, err := repo.UpdateUsersByFilter(ctx, &models.User{Email: "*" + domain}, &models.User{Country: "BR"})
+	assert.NoError(t, err)
+	assert.Len(t, updated, 3)
+	for _, user := range updated {
+		assert.Equal(t, "BR", user.Country)
+		assert.Equal(t, "Conformance", user.FirstName)
+	}
+
+	removed, err := repo.RemoveUsersByFilter(ctx, &models.User{Email: "*" + domain, Country: "BR"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, ids, removed)
+
+	count, err := repo.CountUsers(ctx, &models.User{Email: "*" + domain}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	found, err := repo.FindUser(ctx, untouched.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "UK", found.Country)
+}
+
+func testRemoveRestore(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	affected, err := repo.RemoveUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	_, err = repo.FindUser(ctx, created.ID)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = repo.RemoveUser(ctx, created.ID)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	restored, err := repo.RestoreUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, restored.ID)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = repo.RestoreUser(ctx, created.ID)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	history, err := repo.FindUserHistory(ctx, created.ID, "", 2)
+	assert.NoError(t, err)
+	assert.True(t, history.Verified)
+	assert.NotEmpty(t, history.PageToken)
+	operations := []string{}
+	for _, entry := range history.Entries {
+		operations = append(operations, entry.Operation)
+	}
+	history, err = repo.FindUserHistory(ctx, created.ID, history.PageToken, 2)
+	assert.NoError(t, err)
+	assert.True(t, history.Verified)
+	assert.Empty(t, history.PageToken)
+	for _, entry := range history.Entries {
+		operations = append(operations, entry.Operation)
+	}
+	assert.Equal(t, []string{models.AuditCreate, models.AuditDelete, models.AuditRestore}, operations)
+}
+
+func testRevert(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	update := *created
+	update.FirstName = "Updated"
+	_, err := repo.UpdateUser(ctx, &update)
+	assert.NoError(t, err)
+
+	history, err := repo.FindUserHistory(ctx, created.ID, "", 10)
+	assert.NoError(t, err)
+	if !assert.Len(t, history.Entries, 2) {
+		return
+	}
+
+	asOf, err := repo.FindUserAsOf(ctx, created.ID, history.Entries[0].CreatedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "Conformance", asOf.FirstName)
+
+	reverted, err := repo.RevertUser(ctx, created.ID, history.Entries[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Conformance", reverted.FirstName)
+
+	_, err = repo.RevertUser(ctx, created.ID, history.Entries[0].ID-1)
+	assert.ErrorIs(t, err, models.ErrVersionNotFound)
+}
+
+func testTokens(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	verification := uuid.NewString()
+	err := repo.CreateUserToken(ctx, &models.UserToken{
+		Hash:      tokens.Hash(verification),
+		Purpose:   models.TokenEmailVerification,
+		UserID:    created.ID,
+		Email:     created.Email,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	verified, err := repo.VerifyEmail(ctx, verification)
+	assert.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+	_, err = repo.VerifyEmail(ctx, verification)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	// A token is only consumed for its purpose
+	reset := uuid.NewString()
+	err = repo.CreateUserToken(ctx, &models.UserToken{
+		Hash:      tokens.Hash(reset),
+		Purpose:   models.TokenPasswordReset,
+		UserID:    created.ID,
+		Email:     created.Email,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	_, err = repo.VerifyEmail(ctx, reset)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	_, err = repo.ResetPassword(ctx, reset, "N3wPassw0rd!")
+	assert.NoError(t, err)
+	_, err = repo.ResetPassword(ctx, reset, "An0therPassw0rd!")
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+	_, err = repo.Authenticate(ctx, created.Email, "N3wPassw0rd!")
+	assert.NoError(t, err)
+
+	// An expired token is refused
+	expired := uuid.NewString()
+	err = repo.CreateUserToken(ctx, &models.UserToken{
+		Hash:      tokens.Hash(expired),
+		Purpose:   models.TokenPasswordReset,
+		UserID:    created.ID,
+		Email:     created.Email,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	assert.NoError(t, err)
+	_, err = repo.ResetPassword(ctx, expired, "An0therPassw0rd!")
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+}
+
+func testPasswords(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	_, err := repo.Authenticate(ctx, upper(created.Email), "Passw0rd!")
+	assert.NoError(t, err)
+	_, err = repo.Authenticate(ctx, created.Email, "wrong")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials)
+
+	key, err := repo.CreateAPIKey(ctx, &models.APIKey{UserID: created.ID, Name: "before", Prefix: "mug_", Hash: tokens.Hash(uuid.NewString()), Scopes: []string{models.ScopeUsersRead}})
+	assert.NoError(t, err)
+	pending := map[string]string{models.TokenPasswordReset: uuid.NewString(), models.TokenEmailVerification: uuid.NewString()}
+	for purpose, token := range pending {
+		err := repo.CreateUserToken(ctx, &models.UserToken{
+			Hash:      tokens.Hash(token),
+			Purpose:   purpose,
+			UserID:    created.ID,
+			Email:     created.Email,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.NoError(t, err)
+	}
+
+	_, err = repo.ChangePassword(ctx, created.ID, "wrong", "N3wPassw0rd!")
+	assert.ErrorIs(t, err, models.ErrWrongPassword)
+	_, err = repo.ChangePassword(ctx, created.ID, "Passw0rd!", "N3wPassw0rd!")
+	assert.NoError(t, err)
+
+	age, err := repo.MatchPasswordHistory(ctx, created.ID, "N3wPassw0rd!", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, age)
+	age, err = repo.MatchPasswordHistory(ctx, created.ID, "Passw0rd!", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, age)
+	age, err = repo.MatchPasswordHistory(ctx, created.ID, "Passw0rd!", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, age)
+
+	// The pending links are revoked with the password change, the API keys of the User are kept
+	_, err = repo.ResetPassword(ctx, pending[models.TokenPasswordReset], "An0therPassw0rd!")
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+	_, err = repo.VerifyEmail(ctx, pending[models.TokenEmailVerification])
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+	keys, err := repo.FindAPIKeys(ctx, created.ID, false)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, key.ID, keys[0].ID)
+		assert.Nil(t, keys[0].RevokedAt)
+	}
+
+	// Service accounts never authenticate with a password
+	service := conformanceUser(conformanceDomain())
+	service.ServiceAccount = true
+	service = mustCreate(t, repo, service)
+	_, err = repo.Authenticate(ctx, service.Email, "Passw0rd!")
+	assert.ErrorIs(t, err, models.ErrInvalidCredentials)
+}
+
+func testMFA(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	_, err := repo.FindUserMFA(ctx, created.ID)
+	assert.ErrorIs(t, err, models.ErrMFANotEnrolled)
+
+	assert.NoError(t, repo.SaveTOTPSecret(ctx, created.ID, "first"))
+	assert.NoError(t, repo.SaveTOTPSecret(ctx, created.ID, "sealed"))
+	enrollment, err := repo.FindUserMFA(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sealed", enrollment.Secret)
+	assert.Nil(t, enrollment.ConfirmedAt)
+
+	assert.NoError(t, repo.ConfirmTOTP(ctx, created.ID, 100, []string{tokens.Hash("a"), tokens.Hash("b")}))
+	assert.ErrorIs(t, repo.SaveTOTPSecret(ctx, created.ID, "replaced"), models.ErrMFAEnrolled)
+	enrollment, err = repo.FindUserMFA(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, enrollment.ConfirmedAt)
+	assert.Equal(t, int64(100), enrollment.LastUsedStep)
+
+	// A step is only used once, and never after a later one
+	assert.ErrorIs(t, repo.UseTOTPStep(ctx, created.ID, 100), models.ErrInvalidMFACode)
+	assert.NoError(t, repo.UseTOTPStep(ctx, created.ID, 101))
+	assert.ErrorIs(t, repo.UseTOTPStep(ctx, created.ID, 100), models.ErrInvalidMFACode)
+
+	assert.NoError(t, repo.UseRecoveryCode(ctx, created.ID, tokens.Hash("a")))
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, created.ID, tokens.Hash("a")), models.ErrInvalidMFACode)
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, created.ID, tokens.Hash("unknown")), models.ErrInvalidMFACode)
+
+	assert.NoError(t, repo.ReplaceRecoveryCodes(ctx, created.ID, []string{tokens.Hash("c")}))
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, created.ID, tokens.Hash("b")), models.ErrInvalidMFACode)
+	assert.NoError(t, repo.UseRecoveryCode(ctx, created.ID, tokens.Hash("c")))
+}
+
+func testLockout(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	lockout, err := repo.FindLoginLockout(ctx, created.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lockout.FailedAttempts)
+
+	lockout, err = repo.RecordLoginFailure(ctx, upper(created.Email), 2, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lockout.FailedAttempts)
+	assert.False(t, lockout.Locked)
+	assert.NotNil(t, lockout.LastFailedAt)
+
+	lockout, err = repo.RecordLoginFailure(ctx, created.Email, 2, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, lockout.Locked)
+	if assert.NotNil(t, lockout.LockedUntil) {
+		assert.True(t, lockout.LockedUntil.After(time.Now()))
+	}
+
+	lockout, err = repo.FindLoginLockout(ctx, created.Email)
+	assert.NoError(t, err)
+	assert.NotNil(t, lockout.LockedUntil)
+
+	unlocked, err := repo.UnlockUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.True(t, unlocked)
+	unlocked, err = repo.UnlockUser(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.False(t, unlocked)
+
+	_, err = repo.RecordLoginFailure(ctx, created.Email, 2, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.ResetLoginFailures(ctx, created.ID))
+	lockout, err = repo.FindLoginLockout(ctx, created.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lockout.FailedAttempts)
+
+	_, err = repo.RecordLoginFailure(ctx, "nobody"+conformanceDomain(), 2, time.Minute)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func testAPIKeys(t *testing.T, repo userStores) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, conformanceUser(conformanceDomain()))
+
+	hash := tokens.Hash(uuid.NewString())
+	key, err := repo.CreateAPIKey(ctx, &models.APIKey{
+		UserID: created.ID,
+		Name:   "batch job",
+		Prefix: "mug_abcd",
+		Hash:   hash,
+		Scopes: []string{models.ScopeUsersRead, models.ScopeUsersWrite},
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, key.ID)
+	assert.Equal(t, []string{models.ScopeUsersRead, models.ScopeUsersWrite}, key.Scopes)
+	assert.Nil(t, key.ExpiresAt)
+
+	authenticated, err := repo.AuthenticateAPIKey(ctx, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.Equal(t, created.ID, authenticated.UserID)
+
+	keys, err := repo.FindAPIKeys(ctx, created.ID, false)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.NotNil(t, keys[0].LastUsedAt)
+	}
+
+	assert.NoError(t, repo.RevokeAPIKey(ctx, key.ID))
+	assert.ErrorIs(t, repo.RevokeAPIKey(ctx, key.ID), models.ErrAPIKeyNotFound)
+	_, err = repo.AuthenticateAPIKey(ctx, hash)
+	assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+
+	keys, err = repo.FindAPIKeys(ctx, created.ID, false)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	keys, err = repo.FindAPIKeys(ctx, created.ID, true)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// An expired key is refused
+	expiresAt := time.Now().Add(-time.Minute)
+	expiredHash := tokens.Hash(uuid.NewString())
+	_, err = repo.CreateAPIKey(ctx, &models.APIKey{UserID: created.ID, Name: "expired", Prefix: "mug_efgh", Hash: expiredHash, Scopes: []string{models.ScopeUsersRead}, ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+	_, err = repo.AuthenticateAPIKey(ctx, expiredHash)
+	assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+}
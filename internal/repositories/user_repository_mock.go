@@ -7,6 +7,7 @@ package repositories
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -36,6 +37,51 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Authenticate mocks base method.
+func (m *MockUserRepository) Authenticate(arg0 context.Context, arg1, arg2 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockUserRepositoryMockRecorder) Authenticate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockUserRepository)(nil).Authenticate), arg0, arg1, arg2)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUserRepository) ChangePassword(arg0 context.Context, arg1 uuid.UUID, arg2, arg3 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserRepositoryMockRecorder) ChangePassword(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserRepository)(nil).ChangePassword), arg0, arg1, arg2, arg3)
+}
+
+// CountUsers mocks base method.
+func (m *MockUserRepository) CountUsers(arg0 context.Context, arg1 *models.User, arg2 bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockUserRepositoryMockRecorder) CountUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockUserRepository)(nil).CountUsers), arg0, arg1, arg2)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserRepository) CreateUser(arg0 context.Context, arg1 *models.User) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -51,19 +97,123 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), arg0, arg1)
 }
 
+// CreateUsers mocks base method.
+func (m *MockUserRepository) CreateUsers(arg0 context.Context, arg1 []*models.User) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", arg0, arg1)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockUserRepositoryMockRecorder) CreateUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockUserRepository)(nil).CreateUsers), arg0, arg1)
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserRepository) ExportUsers(arg0 context.Context, arg1 *models.User, arg2 bool, arg3 func(*models.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserRepositoryMockRecorder) ExportUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserRepository)(nil).ExportUsers), arg0, arg1, arg2, arg3)
+}
+
+// FindUser mocks base method.
+func (m *MockUserRepository) FindUser(arg0 context.Context, arg1 uuid.UUID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUser", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUser indicates an expected call of FindUser.
+func (mr *MockUserRepositoryMockRecorder) FindUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockUserRepository)(nil).FindUser), arg0, arg1)
+}
+
+// FindUserAsOf mocks base method.
+func (m *MockUserRepository) FindUserAsOf(arg0 context.Context, arg1 uuid.UUID, arg2 time.Time) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserAsOf", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserAsOf indicates an expected call of FindUserAsOf.
+func (mr *MockUserRepositoryMockRecorder) FindUserAsOf(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserAsOf", reflect.TypeOf((*MockUserRepository)(nil).FindUserAsOf), arg0, arg1, arg2)
+}
+
+// FindUserByEmail mocks base method.
+func (m *MockUserRepository) FindUserByEmail(arg0 context.Context, arg1 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByEmail indicates an expected call of FindUserByEmail.
+func (mr *MockUserRepositoryMockRecorder) FindUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindUserByEmail), arg0, arg1)
+}
+
+// FindUserHistory mocks base method.
+func (m *MockUserRepository) FindUserHistory(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 int) (*models.AuditResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.AuditResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserHistory indicates an expected call of FindUserHistory.
+func (mr *MockUserRepositoryMockRecorder) FindUserHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserHistory", reflect.TypeOf((*MockUserRepository)(nil).FindUserHistory), arg0, arg1, arg2, arg3)
+}
+
 // FindUsers mocks base method.
-func (m *MockUserRepository) FindUsers(arg0 context.Context, arg1 *models.User, arg2 string, arg3 int) (*models.UsersResponse, error) {
+func (m *MockUserRepository) FindUsers(arg0 context.Context, arg1 *models.User, arg2 bool, arg3 string, arg4 int) (*models.UsersResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUsers", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "FindUsers", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(*models.UsersResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindUsers indicates an expected call of FindUsers.
-func (mr *MockUserRepositoryMockRecorder) FindUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) FindUsers(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsers", reflect.TypeOf((*MockUserRepository)(nil).FindUsers), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsers", reflect.TypeOf((*MockUserRepository)(nil).FindUsers), arg0, arg1, arg2, arg3, arg4)
+}
+
+// PurgeUsers mocks base method.
+func (m *MockUserRepository) PurgeUsers(arg0 context.Context, arg1 time.Time) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUsers", arg0, arg1)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUsers indicates an expected call of PurgeUsers.
+func (mr *MockUserRepositoryMockRecorder) PurgeUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUsers", reflect.TypeOf((*MockUserRepository)(nil).PurgeUsers), arg0, arg1)
 }
 
 // RemoveUser mocks base method.
@@ -81,6 +231,66 @@ func (mr *MockUserRepositoryMockRecorder) RemoveUser(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockUserRepository)(nil).RemoveUser), arg0, arg1)
 }
 
+// RemoveUsersByFilter mocks base method.
+func (m *MockUserRepository) RemoveUsersByFilter(arg0 context.Context, arg1 *models.User) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUsersByFilter", arg0, arg1)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveUsersByFilter indicates an expected call of RemoveUsersByFilter.
+func (mr *MockUserRepositoryMockRecorder) RemoveUsersByFilter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUsersByFilter", reflect.TypeOf((*MockUserRepository)(nil).RemoveUsersByFilter), arg0, arg1)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserRepository) ResetPassword(arg0 context.Context, arg1, arg2 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserRepositoryMockRecorder) ResetPassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserRepository)(nil).ResetPassword), arg0, arg1, arg2)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserRepository) RestoreUser(arg0 context.Context, arg1 uuid.UUID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserRepositoryMockRecorder) RestoreUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserRepository)(nil).RestoreUser), arg0, arg1)
+}
+
+// RevertUser mocks base method.
+func (m *MockUserRepository) RevertUser(arg0 context.Context, arg1 uuid.UUID, arg2 int64) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertUser indicates an expected call of RevertUser.
+func (mr *MockUserRepositoryMockRecorder) RevertUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertUser", reflect.TypeOf((*MockUserRepository)(nil).RevertUser), arg0, arg1, arg2)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUserRepository) UpdateUser(arg0 context.Context, arg1 *models.User) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -95,3 +305,33 @@ func (mr *MockUserRepositoryMockRecorder) UpdateUser(arg0, arg1 interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), arg0, arg1)
 }
+
+// UpdateUsersByFilter mocks base method.
+func (m *MockUserRepository) UpdateUsersByFilter(arg0 context.Context, arg1, arg2 *models.User) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUsersByFilter", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUsersByFilter indicates an expected call of UpdateUsersByFilter.
+func (mr *MockUserRepositoryMockRecorder) UpdateUsersByFilter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsersByFilter", reflect.TypeOf((*MockUserRepository)(nil).UpdateUsersByFilter), arg0, arg1, arg2)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserRepository) VerifyEmail(arg0 context.Context, arg1 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserRepositoryMockRecorder) VerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserRepository)(nil).VerifyEmail), arg0, arg1)
+}
@@ -0,0 +1,69 @@
+package secrets_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/secrets"
+)
+
+var (
+	key      = []byte("0123456789abcdef0123456789abcdef")
+	otherKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestNewBox(t *testing.T) {
+	_, err := secrets.NewBox(key[:16])
+	assert.Error(t, err)
+}
+
+func TestSealOpen(t *testing.T) {
+	box, err := secrets.NewBox(key)
+	assert.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("12345678901234567890"))
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, base64.StdEncoding.EncodeToString([]byte("12345678901234567890")))
+
+	plaintext, err := box.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("12345678901234567890"), plaintext)
+
+	// Every Seal has a nonce of its own
+	again, err := box.Seal([]byte("12345678901234567890"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+}
+
+func TestOpenTampered(t *testing.T) {
+	box, err := secrets.NewBox(key)
+	assert.NoError(t, err)
+	sealed, err := box.Seal([]byte("12345678901234567890"))
+	assert.NoError(t, err)
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	assert.NoError(t, err)
+
+	// Flipping a bit anywhere, in the nonce, the ciphertext or the tag, is detected
+	for i := range raw {
+		tampered := append([]byte{}, raw...)
+		tampered[i] ^= 0x01
+		_, err := box.Open(base64.StdEncoding.EncodeToString(tampered))
+		assert.Error(t, err, "byte %d", i)
+	}
+
+	other, err := secrets.NewBox(otherKey)
+	assert.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.Error(t, err, "another key")
+
+	_, err = box.Open(base64.StdEncoding.EncodeToString(raw[:len(raw)-1]))
+	assert.Error(t, err, "truncated")
+
+	_, err = box.Open(base64.StdEncoding.EncodeToString(raw[:4]))
+	assert.Error(t, err, "shorter than the nonce")
+
+	_, err = box.Open("not base64!")
+	assert.Error(t, err, "not base64")
+}
@@ -0,0 +1,31 @@
+package apikeys
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// isAdmin returns whether the caller key carries the admin scope
+func isAdmin(caller *models.APIKey) bool {
+	return caller != nil && caller.HasScope(models.ScopeAdmin)
+}
+
+// callerAllowed returns whether the caller may act on the API keys of the User, only its own ones unless it is an admin
+func callerAllowed(caller *models.APIKey, userID uuid.UUID) bool {
+	return isAdmin(caller) || (caller != nil && caller.UserID == userID)
+}
+
+// missingScope returns a scope the caller can't grant because it lacks it, empty when it may grant them all.
+// An admin grants any scope, the others only the ones they carry, so a key never creates a more powerful one.
+func missingScope(caller *models.APIKey, scopes []string) string {
+	if isAdmin(caller) {
+		return ""
+	}
+	for _, scope := range scopes {
+		if caller == nil || !caller.HasScope(scope) {
+			return scope
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// createRequest holds the owner, the name, the scopes and the optional expiry of a new API key
+type createRequest struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Create API Key Controller generates an API key for a User, the key is only returned in this response.
+// A caller creates keys for its own User with the scopes it carries, only an admin creates them for others or with any scope.
+func Create(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		request := new(createRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse api key body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if valErr := validate(request, time.Now()); valErr != nil {
+			return c.JSON(http.StatusBadRequest, valErr.Response())
+		}
+		caller := middlewares.CallerAPIKey(c)
+		if !callerAllowed(caller, request.UserID) {
+			return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: "only an admin may create api keys for another user", Field: "user_id"})
+		}
+		if scope := missingScope(caller, request.Scopes); scope != "" {
+			return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: "a key may not grant the scope " + scope + " it lacks", Field: "scopes"})
+		}
+
+		key, prefix, hash, err := apikeys.Generate()
+		if err != nil {
+			s.Logger.Error("failed to generate api key", zap.Error(err))
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		apiKey, err := s.APIKeyRepository.CreateAPIKey(c.Request().Context(), &models.APIKey{
+			UserID:    request.UserID,
+			Name:      request.Name,
+			Prefix:    prefix,
+			Scopes:    request.Scopes,
+			ExpiresAt: request.ExpiresAt,
+			Hash:      hash,
+		})
+		if err != nil {
+			s.Logger.Error("failed to create api key", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.JSON(http.StatusNotFound, &models.ErrorResponse{Message: models.ErrUserNotFound.Error(), Field: "user_id"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusCreated, &models.CreatedAPIKey{APIKey: apiKey, Key: key})
+	}
+}
+
+// validate returns every problem of the request, or nil when there is none
+func validate(request *createRequest, now time.Time) *models.ValidationError {
+	violations := []models.Violation{}
+	if request.UserID == uuid.Nil {
+		violations = append(violations, models.Violation{Field: "user_id", Code: "required", Message: "user_id is required"})
+	}
+	if request.Name == "" {
+		violations = append(violations, models.Violation{Field: "name", Code: "required", Message: "name is required"})
+	}
+	if len(request.Scopes) == 0 {
+		violations = append(violations, models.Violation{Field: "scopes", Code: "required", Message: "at least one scope is required"})
+	}
+	for _, scope := range request.Scopes {
+		if !knownScope(scope) {
+			violations = append(violations, models.Violation{Field: "scopes", Code: "unknown", Message: "unknown scope " + scope})
+		}
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(now) {
+		violations = append(violations, models.Violation{Field: "expires_at", Code: "past", Message: "expires_at must be in the future"})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &models.ValidationError{Violations: violations}
+}
+
+func knownScope(scope string) bool {
+	for _, s := range models.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package apikeys_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCreate(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockAPIKeyRepository(ctrl)
+	s.APIKeyRepository = mockedRepo
+
+	handler := apikeys.Create(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	self := &models.APIKey{UserID: userID, Scopes: []string{models.ScopeUsersRead, models.ScopeAPIKeysWrite}}
+	other := &models.APIKey{UserID: uuid.New(), Scopes: []string{models.ScopeUsersRead, models.ScopeAPIKeysWrite}}
+	admin := &models.APIKey{UserID: uuid.New(), Scopes: []string{models.ScopeAPIKeysWrite, models.ScopeAdmin}}
+
+	tt := []struct {
+		name       string
+		caller     *models.APIKey
+		body       string
+		repoCall   int
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "apikeys.Create StatusCreated",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"],"expires_at":"2099-01-01T00:00:00Z"}`,
+			repoCall:   1,
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name:       "apikeys.Create StatusCreated admin for another user",
+			caller:     admin,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"]}`,
+			repoCall:   1,
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name:       "apikeys.Create StatusForbidden another user",
+			caller:     other,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"]}`,
+			repoCall:   0,
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:       "apikeys.Create StatusForbidden scope the caller lacks",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read","admin"]}`,
+			repoCall:   0,
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:       "apikeys.Create StatusForbidden without caller",
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"]}`,
+			repoCall:   0,
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:       "apikeys.Create StatusNotFound",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"]}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("createapikey returned no rows: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "apikeys.Create StatusInternalServerError",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"]}`,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "apikeys.Create StatusBadRequest unknown scope",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:admin"]}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "apikeys.Create StatusBadRequest expired",
+			caller:     self,
+			body:       `{"user_id":"904bc695-6b6c-418a-82a0-0acc7a747d46","name":"nightly export","scopes":["users:read"],"expires_at":"2001-01-01T00:00:00Z"}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "apikeys.Create StatusBadRequest",
+			caller:     self,
+			body:       `{"user_id":`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if test.caller != nil {
+				c.Set(middlewares.ContextAPIKey, test.caller)
+			}
+
+			// Mocked User Repository, the stored key must never be the key itself
+			var stored *models.APIKey
+			mockedRepo.EXPECT().CreateAPIKey(c.Request().Context(), gomock.Any()).Times(test.repoCall).DoAndReturn(
+				func(_ any, key *models.APIKey) (*models.APIKey, error) {
+					if test.repoErr != nil {
+						return nil, test.repoErr
+					}
+					stored = key
+					created := *key
+					created.ID = uuid.New()
+					return &created, nil
+				})
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.httpStatus == http.StatusCreated {
+					var response struct {
+						models.APIKey
+						Key string `json:"key"`
+					}
+					assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+					assert.Equal(t, userID, response.UserID)
+					assert.Equal(t, []string{models.ScopeUsersRead}, response.Scopes)
+					assert.True(t, strings.HasPrefix(response.Key, response.Prefix))
+					assert.NotEqual(t, response.Key, stored.Hash)
+					assert.NotContains(t, rec.Body.String(), stored.Hash)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package apikeys
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// Find API Keys Controller lists the API keys, of a single User with the user_id query param. Revoked keys are listed with include_revoked=true.
+// Without the admin scope only the keys of the User of the caller are listed.
+func Find(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		values := c.Request().URL.Query()
+
+		var userID uuid.UUID
+		var includeRevoked bool
+		var err error
+		if values.Has("user_id") {
+			if userID, err = uuid.Parse(values.Get("user_id")); err != nil {
+				s.Logger.Error("failed to parse user id", zap.Error(err))
+				return c.NoContent(http.StatusBadRequest)
+			}
+		}
+		if values.Has("include_revoked") {
+			if includeRevoked, err = strconv.ParseBool(values.Get("include_revoked")); err != nil {
+				s.Logger.Error("failed to parse include_revoked", zap.Error(err))
+				return c.NoContent(http.StatusBadRequest)
+			}
+		}
+
+		// Only an admin lists the keys of other Users, the others list their own ones
+		caller := middlewares.CallerAPIKey(c)
+		if !isAdmin(caller) {
+			if !values.Has("user_id") && caller != nil {
+				userID = caller.UserID
+			}
+			if !callerAllowed(caller, userID) {
+				return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: "only an admin may list the api keys of another user", Field: "user_id"})
+			}
+		}
+
+		keys, err := s.APIKeyRepository.FindAPIKeys(c.Request().Context(), userID, includeRevoked)
+		if err != nil {
+			s.Logger.Error("failed to find api keys", zap.Error(err))
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, &models.APIKeysResponse{APIKeys: keys})
+	}
+}
@@ -0,0 +1,127 @@
+package apikeys_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestFind(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockAPIKeyRepository(ctrl)
+	s.APIKeyRepository = mockedRepo
+
+	handler := apikeys.Find(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	keys := []*models.APIKey{
+		{ID: uuid.New(), UserID: userID, Name: "nightly export", Prefix: "mug_abcdefgh", Scopes: []string{models.ScopeUsersRead}},
+	}
+	admin := &models.APIKey{UserID: uuid.New(), Scopes: []string{models.ScopeAPIKeysRead, models.ScopeAdmin}}
+	self := &models.APIKey{UserID: userID, Scopes: []string{models.ScopeAPIKeysRead}}
+
+	tt := []struct {
+		name           string
+		query          string
+		caller         *models.APIKey
+		repoCall       int
+		repoUserID     uuid.UUID
+		includeRevoked bool
+		repoErr        error
+		httpStatus     int
+	}{
+		{
+			name:       "apikeys.Find StatusOK",
+			caller:     admin,
+			repoCall:   1,
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:           "apikeys.Find StatusOK by user",
+			query:          "?user_id=904bc695-6b6c-418a-82a0-0acc7a747d46&include_revoked=true",
+			caller:         admin,
+			repoCall:       1,
+			repoUserID:     userID,
+			includeRevoked: true,
+			httpStatus:     http.StatusOK,
+		},
+		{
+			name:       "apikeys.Find StatusOK own keys",
+			caller:     self,
+			repoCall:   1,
+			repoUserID: userID,
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "apikeys.Find StatusForbidden another user",
+			query:      "?user_id=" + admin.UserID.String(),
+			caller:     self,
+			repoCall:   0,
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:       "apikeys.Find StatusForbidden without caller",
+			repoCall:   0,
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:       "apikeys.Find StatusInternalServerError",
+			caller:     admin,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "apikeys.Find StatusBadRequest",
+			caller:     admin,
+			query:      "?user_id=904bc695-6b6cxxxxx82a0-0acc7a747d46",
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if test.caller != nil {
+				c.Set(middlewares.ContextAPIKey, test.caller)
+			}
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().FindAPIKeys(c.Request().Context(), test.repoUserID, test.includeRevoked).Times(test.repoCall).Return(keys, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.httpStatus == http.StatusOK {
+					var response models.APIKeysResponse
+					assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+					assert.Equal(t, keys, response.APIKeys)
+				}
+			}
+		})
+	}
+}
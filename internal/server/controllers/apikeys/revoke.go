@@ -0,0 +1,65 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// Revoke API Key Controller revokes an API key, the requests using it are refused from then on.
+func Revoke(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse api key id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		// Without the admin scope only the keys of the User of the caller are revoked, the others are not found
+		ctx := c.Request().Context()
+		if caller := middlewares.CallerAPIKey(c); !isAdmin(caller) {
+			owned, err := ownsAPIKey(s, c, caller, parsedID)
+			if err != nil {
+				s.Logger.Error("failed to find api keys", zap.Error(err))
+				return c.NoContent(http.StatusInternalServerError)
+			}
+			if !owned {
+				return c.NoContent(http.StatusNotFound)
+			}
+		}
+
+		if err := s.APIKeyRepository.RevokeAPIKey(ctx, parsedID); err != nil {
+			s.Logger.Error("failed to revoke api key", zap.Error(err))
+			if errors.Is(err, models.ErrAPIKeyNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// ownsAPIKey returns whether the key is one of the active keys of the User of the caller
+func ownsAPIKey(s *server.Server, c echo.Context, caller *models.APIKey, id uuid.UUID) (bool, error) {
+	if caller == nil {
+		return false, nil
+	}
+	keys, err := s.APIKeyRepository.FindAPIKeys(c.Request().Context(), caller.UserID, false)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range keys {
+		if key.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,134 @@
+package apikeys_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRevoke(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockAPIKeyRepository(ctrl)
+	s.APIKeyRepository = mockedRepo
+
+	handler := apikeys.Revoke(s)
+
+	e := echo.New()
+
+	keyID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	admin := &models.APIKey{UserID: uuid.New(), Scopes: []string{models.ScopeAPIKeysWrite, models.ScopeAdmin}}
+	self := &models.APIKey{UserID: uuid.New(), Scopes: []string{models.ScopeAPIKeysWrite}}
+	owned := []*models.APIKey{{ID: keyID, UserID: self.UserID}}
+
+	tt := []struct {
+		name       string
+		inputID    string
+		caller     *models.APIKey
+		findCall   int
+		findKeys   []*models.APIKey
+		repoInput  uuid.UUID
+		repoCall   int
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "apikeys.Revoke StatusNoContent",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			caller:     admin,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "apikeys.Revoke StatusNotFound",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			caller:     admin,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			repoErr:    fmt.Errorf("revokeapikey returned no rows: %w", models.ErrAPIKeyNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "apikeys.Revoke StatusInternalServerError",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			caller:     admin,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "apikeys.Revoke StatusBadRequest",
+			inputID:    "904bc695-6b6cxxxxx82a0-0acc7a747d46",
+			caller:     admin,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "apikeys.Revoke StatusNoContent own key",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			caller:     self,
+			findCall:   1,
+			findKeys:   owned,
+			repoInput:  keyID,
+			repoCall:   1,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "apikeys.Revoke StatusNotFound key of another user",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			caller:     self,
+			findCall:   1,
+			repoCall:   0,
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "apikeys.Revoke StatusNotFound without caller",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:   0,
+			httpStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+			if test.caller != nil {
+				c.Set(middlewares.ContextAPIKey, test.caller)
+			}
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().FindAPIKeys(c.Request().Context(), self.UserID, false).Times(test.findCall).Return(test.findKeys, nil)
+			mockedRepo.EXPECT().RevokeAPIKey(c.Request().Context(), test.repoInput).Times(test.repoCall).Return(test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// Login Controller checks the email and password of the User, and the TOTP or recovery code when the User is enrolled in MFA.
+// Wrong passwords and codes are counted by the LoginGuard, which refuses the logins coming too fast or for a locked User.
+// The API issues no session nor token yet, the authenticated User is returned.
+func Login(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		request := new(models.LoginRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Email == "" || request.Password == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "email and password are required"})
+		}
+
+		ctx := c.Request().Context()
+		ip := c.RealIP()
+		if err := s.LoginGuard.Check(ctx, request.Email, ip); err != nil {
+			s.Logger.Error("login refused", zap.Error(err))
+			var throttledErr *models.ThrottledError
+			if errors.As(err, &throttledErr) {
+				c.Response().Header().Set(echo.HeaderRetryAfter, fmt.Sprint(int(math.Ceil(throttledErr.RetryAfter.Seconds()))))
+				return c.JSON(http.StatusTooManyRequests, &models.ErrorResponse{Message: throttledErr.Reason})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		user, err := s.UserRepository.Authenticate(ctx, request.Email, request.Password)
+		if err != nil {
+			s.Logger.Error("failed to authenticate", zap.Error(err))
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				failLogin(s, c, request.Email, ip)
+				return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: models.ErrInvalidCredentials.Error()})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		err = s.MFAService.VerifySecondFactor(ctx, user.ID, request.TOTPCode, request.RecoveryCode)
+		if err != nil {
+			s.Logger.Error("failed to verify second factor", zap.Error(err))
+			if errors.Is(err, models.ErrMFARequired) {
+				return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: models.ErrMFARequired.Error(), Field: "totp_code"})
+			}
+			if errors.Is(err, models.ErrInvalidMFACode) {
+				failLogin(s, c, request.Email, ip)
+				return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: models.ErrInvalidMFACode.Error(), Field: "totp_code"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		if err := s.LoginGuard.Succeed(ctx, user.ID); err != nil {
+			s.Logger.Error("failed to clear login failures", zap.Error(err))
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+// failLogin counts the failed login, the response does not depend on it
+func failLogin(s *server.Server, c echo.Context, email, ip string) {
+	if err := s.LoginGuard.Fail(c.Request().Context(), email, ip); err != nil {
+		s.Logger.Error("failed to record login failure", zap.Error(err))
+	}
+}
@@ -0,0 +1,156 @@
+package auth_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/auth"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestLogin(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+	mockedService := repositories.NewMockMFAService(ctrl)
+	s.MFAService = mockedService
+	mockedGuard := repositories.NewMockLoginGuard(ctrl)
+	s.LoginGuard = mockedGuard
+
+	handler := auth.Login(s)
+
+	e := echo.New()
+
+	user := &models.User{ID: uuid.New(), Email: "john.tester@email.com"}
+
+	tt := []struct {
+		name        string
+		body        string
+		checkCall   int
+		checkErr    error
+		failCall    int
+		succeedCall int
+		repoCall    int
+		repoErr     error
+		totpCode    string
+		serviceCall int
+		serviceErr  error
+		httpStatus  int
+	}{
+		{
+			name:        "auth.Login StatusOK",
+			body:        `{"email":"john.tester@email.com","password":"Secret123!"}`,
+			checkCall:   1,
+			succeedCall: 1,
+			repoCall:    1,
+			serviceCall: 1,
+			httpStatus:  http.StatusOK,
+		},
+		{
+			name:        "auth.Login StatusOK with totp code",
+			body:        `{"email":"john.tester@email.com","password":"Secret123!","totp_code":"123456"}`,
+			checkCall:   1,
+			succeedCall: 1,
+			repoCall:    1,
+			totpCode:    "123456",
+			serviceCall: 1,
+			httpStatus:  http.StatusOK,
+		},
+		{
+			name:        "auth.Login StatusUnauthorized totp code required",
+			body:        `{"email":"john.tester@email.com","password":"Secret123!"}`,
+			checkCall:   1,
+			repoCall:    1,
+			serviceCall: 1,
+			serviceErr:  models.ErrMFARequired,
+			httpStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "auth.Login StatusUnauthorized invalid totp code",
+			body:        `{"email":"john.tester@email.com","password":"Secret123!","totp_code":"123456"}`,
+			checkCall:   1,
+			failCall:    1,
+			repoCall:    1,
+			totpCode:    "123456",
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("usetotpstep failed: %w", models.ErrInvalidMFACode),
+			httpStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:       "auth.Login StatusUnauthorized invalid credentials",
+			body:       `{"email":"john.tester@email.com","password":"Secret123!"}`,
+			checkCall:  1,
+			failCall:   1,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("authenticate failed: %w", models.ErrInvalidCredentials),
+			httpStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "auth.Login StatusInternalServerError",
+			body:       `{"email":"john.tester@email.com","password":"Secret123!"}`,
+			checkCall:  1,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "auth.Login StatusTooManyRequests",
+			body:       `{"email":"john.tester@email.com","password":"Secret123!"}`,
+			checkCall:  1,
+			checkErr:   &models.ThrottledError{Reason: "account locked", RetryAfter: 1500 * time.Millisecond},
+			httpStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "auth.Login StatusBadRequest",
+			body:       `{"email":"john.tester@email.com"}`,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.RemoteAddr = "192.0.2.1:41234"
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked Login Guard, User Repository and MFA Service
+			mockedGuard.EXPECT().Check(c.Request().Context(), "john.tester@email.com", "192.0.2.1").Times(test.checkCall).Return(test.checkErr)
+			mockedGuard.EXPECT().Fail(c.Request().Context(), "john.tester@email.com", "192.0.2.1").Times(test.failCall).Return(nil)
+			mockedGuard.EXPECT().Succeed(c.Request().Context(), user.ID).Times(test.succeedCall).Return(nil)
+			var repoUser *models.User
+			if test.repoErr == nil {
+				repoUser = user
+			}
+			mockedRepo.EXPECT().Authenticate(c.Request().Context(), "john.tester@email.com", "Secret123!").Times(test.repoCall).Return(repoUser, test.repoErr)
+			mockedService.EXPECT().VerifySecondFactor(c.Request().Context(), user.ID, test.totpCode, "").Times(test.serviceCall).Return(test.serviceErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.httpStatus == http.StatusTooManyRequests {
+					assert.Equal(t, "2", rec.Header().Get(echo.HeaderRetryAfter))
+				}
+			}
+		})
+	}
+}
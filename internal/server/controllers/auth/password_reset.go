@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// passwordResetRequest holds the email of the User asking for a reset link
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// passwordResetConfirmation holds the token of the reset link and the new password
+type passwordResetConfirmation struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// RequestPasswordReset Controller emails a password reset link to the User.
+// It is always accepted, whether a User has that email or not.
+func RequestPasswordReset(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		request := new(passwordResetRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Email == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "email is required", Field: "email"})
+		}
+
+		if err := s.AccountService.RequestPasswordReset(c.Request().Context(), request.Email); err != nil {
+			s.Logger.Error("failed to request password reset", zap.Error(err))
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+// ConfirmPasswordReset Controller sets the new password of the User the reset link was sent to, a link can only be used once.
+func ConfirmPasswordReset(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		request := new(passwordResetConfirmation)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Token == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "token is required", Field: "token"})
+		}
+		if request.Password == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "password is required", Field: "password"})
+		}
+
+		if _, err := s.UserRepository.ResetPassword(c.Request().Context(), request.Token, request.Password); err != nil {
+			s.Logger.Error("failed to reset password", zap.Error(err))
+			var valErr *models.ValidationError
+			if errors.As(err, &valErr) {
+				return c.JSON(http.StatusBadRequest, valErr.Response())
+			}
+			if errors.Is(err, models.ErrInvalidToken) {
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: models.ErrInvalidToken.Error(), Field: "token"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,153 @@
+package auth_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/auth"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRequestPasswordReset(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedService := repositories.NewMockAccountService(ctrl)
+	s.AccountService = mockedService
+
+	handler := auth.RequestPasswordReset(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name        string
+		body        string
+		serviceCall int
+		serviceErr  error
+		httpStatus  int
+	}{
+		{
+			name:        "auth.RequestPasswordReset StatusAccepted",
+			body:        `{"email":"john.tester@email.com"}`,
+			serviceCall: 1,
+			httpStatus:  http.StatusAccepted,
+		},
+		{
+			name:        "auth.RequestPasswordReset StatusInternalServerError",
+			body:        `{"email":"john.tester@email.com"}`,
+			serviceCall: 1,
+			serviceErr:  errors.New("Generic Error"),
+			httpStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:        "auth.RequestPasswordReset StatusBadRequest",
+			body:        `{"email":""}`,
+			serviceCall: 0,
+			httpStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked Account Service
+			mockedService.EXPECT().RequestPasswordReset(c.Request().Context(), "john.tester@email.com").Times(test.serviceCall).Return(test.serviceErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := auth.ConfirmPasswordReset(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name       string
+		body       string
+		repoCall   int
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "auth.ConfirmPasswordReset StatusNoContent",
+			body:       `{"token":"abc.def","password":"NewPass123!"}`,
+			repoCall:   1,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "auth.ConfirmPasswordReset StatusBadRequest used token",
+			body:       `{"token":"abc.def","password":"NewPass123!"}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("resetpassword failed: %w", models.ErrInvalidToken),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "auth.ConfirmPasswordReset StatusInternalServerError",
+			body:       `{"token":"abc.def","password":"NewPass123!"}`,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "auth.ConfirmPasswordReset StatusBadRequest missing password",
+			body:       `{"token":"abc.def"}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "auth.ConfirmPasswordReset StatusBadRequest missing token",
+			body:       `{"password":"NewPass123!"}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().ResetPassword(c.Request().Context(), "abc.def", "NewPass123!").Times(test.repoCall).Return(&models.User{}, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
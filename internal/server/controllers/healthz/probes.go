@@ -0,0 +1,49 @@
+package healthz
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/healthz"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// Live is the liveness probe, it only tells the process still answers. The dependencies are left out on purpose,
+// restarting the pod would not bring them back.
+func Live(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, &healthz.Report{Status: healthz.StatusOK})
+	}
+}
+
+// Ready is the readiness probe, it tests every dependency and answers 503 Service Unavailable only when a critical one is down.
+// A non-critical one being down is reported as degraded, and the pod keeps receiving requests.
+func Ready(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		report := s.HealthChecker.Run(c.Request().Context())
+		return respond(s, c, report)
+	}
+}
+
+// Startup is the startup probe, it answers 503 Service Unavailable until the critical dependencies were up once
+func Startup(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		report := s.HealthChecker.Startup(c.Request().Context())
+		return respond(s, c, report)
+	}
+}
+
+// respond logs the dependencies that are down and writes the report with the status code of its status
+func respond(s *server.Server, c echo.Context, report *healthz.Report) error {
+	for _, check := range report.Checks {
+		if check.Status != healthz.StatusOK {
+			s.Logger.Error("health check failed", zap.String("dependency", check.Name), zap.Bool("critical", check.Critical), zap.String("error", check.Error))
+		}
+	}
+	if report.Status == healthz.StatusDown {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,209 @@
+package healthz_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/healthz"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	controllers "github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/healthz"
+)
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+// hang ignores the context, the check must give up on it by itself
+func hang(ctx context.Context) error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestProbes(t *testing.T) {
+	e := echo.New()
+
+	tt := []struct {
+		name       string
+		probe      func(s *server.Server) func(c echo.Context) error
+		postgres   healthz.TesterFunc
+		rabbitmq   healthz.TesterFunc
+		httpStatus int
+		response   *healthz.Report
+	}{
+		{
+			name:       "livez StatusOK with the dependencies down",
+			probe:      controllers.Live,
+			postgres:   down,
+			rabbitmq:   down,
+			httpStatus: http.StatusOK,
+			response:   &healthz.Report{Status: healthz.StatusOK},
+		},
+		{
+			name:       "readyz StatusOK",
+			probe:      controllers.Ready,
+			postgres:   up,
+			rabbitmq:   up,
+			httpStatus: http.StatusOK,
+			response: &healthz.Report{Status: healthz.StatusOK, Checks: []*healthz.CheckResult{
+				{Name: "postgres", Status: healthz.StatusOK, Critical: true},
+				{Name: "rabbitmq", Status: healthz.StatusOK},
+			}},
+		},
+		{
+			name:       "readyz StatusOK degraded",
+			probe:      controllers.Ready,
+			postgres:   up,
+			rabbitmq:   down,
+			httpStatus: http.StatusOK,
+			response: &healthz.Report{Status: healthz.StatusDegraded, Checks: []*healthz.CheckResult{
+				{Name: "postgres", Status: healthz.StatusOK, Critical: true},
+				{Name: "rabbitmq", Status: healthz.StatusDown, Error: "connection refused"},
+			}},
+		},
+		{
+			name:       "readyz StatusServiceUnavailable",
+			probe:      controllers.Ready,
+			postgres:   down,
+			rabbitmq:   down,
+			httpStatus: http.StatusServiceUnavailable,
+			response: &healthz.Report{Status: healthz.StatusDown, Checks: []*healthz.CheckResult{
+				{Name: "postgres", Status: healthz.StatusDown, Critical: true, Error: "connection refused"},
+				{Name: "rabbitmq", Status: healthz.StatusDown, Error: "connection refused"},
+			}},
+		},
+		{
+			name:       "readyz StatusServiceUnavailable timeout",
+			probe:      controllers.Ready,
+			postgres:   hang,
+			rabbitmq:   up,
+			httpStatus: http.StatusServiceUnavailable,
+			response: &healthz.Report{Status: healthz.StatusDown, Checks: []*healthz.CheckResult{
+				{Name: "postgres", Status: healthz.StatusDown, Critical: true, Error: healthz.ErrTimeout.Error()},
+				{Name: "rabbitmq", Status: healthz.StatusOK},
+			}},
+		},
+		{
+			name:       "startupz StatusServiceUnavailable",
+			probe:      controllers.Startup,
+			postgres:   down,
+			rabbitmq:   up,
+			httpStatus: http.StatusServiceUnavailable,
+			response: &healthz.Report{Status: healthz.StatusDown, Checks: []*healthz.CheckResult{
+				{Name: "postgres", Status: healthz.StatusDown, Critical: true, Error: "connection refused"},
+				{Name: "rabbitmq", Status: healthz.StatusOK},
+			}},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			s := server.NewServer()
+			s.Logger = zap.NewNop()
+			s.HealthChecker = healthz.NewChecker(0,
+				healthz.Check{Name: "postgres", Critical: true, Timeout: 50 * time.Millisecond, Tester: test.postgres},
+				healthz.Check{Name: "rabbitmq", Timeout: 50 * time.Millisecond, Tester: test.rabbitmq},
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Assertions
+			start := time.Now()
+			if assert.NoError(t, test.probe(s)(c)) {
+				assert.Less(t, time.Since(start), 500*time.Millisecond)
+				assert.Equal(t, test.httpStatus, rec.Code)
+
+				var response healthz.Report
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				for _, check := range response.Checks {
+					check.LatencyMS = 0
+				}
+				assert.Equal(t, test.response, &response)
+			}
+		})
+	}
+}
+
+func TestStartupOnce(t *testing.T) {
+	e := echo.New()
+	s := server.NewServer()
+	s.Logger = zap.NewNop()
+
+	calls := 0
+	s.HealthChecker = healthz.NewChecker(0, healthz.Check{Name: "postgres", Critical: true, Timeout: time.Second, Tester: healthz.TesterFunc(func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("the database system is starting up")
+		}
+		return nil
+	})})
+
+	for _, httpStatus := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		if assert.NoError(t, controllers.Startup(s)(c)) {
+			assert.Equal(t, httpStatus, rec.Code)
+		}
+	}
+	// Once started the dependencies are not tested again
+	assert.Equal(t, 2, calls)
+}
+
+func TestReadyCached(t *testing.T) {
+	e := echo.New()
+	s := server.NewServer()
+	s.Logger = zap.NewNop()
+
+	calls := 0
+	s.HealthChecker = healthz.NewChecker(time.Minute, healthz.Check{Name: "postgres", Critical: true, Timeout: time.Second, Tester: healthz.TesterFunc(func(ctx context.Context) error {
+		calls++
+		return errors.New("connection refused")
+	})})
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		if assert.NoError(t, controllers.Ready(s)(c)) {
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		}
+	}
+	// The failure is cached too, so a dependency that is down is not hammered by the probes
+	assert.Equal(t, 1, calls)
+}
+
+func TestReadyCanceledProbe(t *testing.T) {
+	e := echo.New()
+	s := server.NewServer()
+	s.Logger = zap.NewNop()
+	s.HealthChecker = healthz.NewChecker(time.Minute, healthz.Check{Name: "postgres", Critical: true, Timeout: time.Second, Tester: healthz.TesterFunc(up)})
+
+	// The first probe hangs up before the checks run, the shared run must not be canceled with it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx), rec)
+	if assert.NoError(t, controllers.Ready(s)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	// Neither is the cached Report served to the next probe
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if assert.NoError(t, controllers.Ready(s)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
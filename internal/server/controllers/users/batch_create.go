@@ -0,0 +1,355 @@
+package users
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+const (
+	// BatchBodyLimit is the largest batch body accepted, larger bodies are answered with 413 Request Entity Too Large
+	BatchBodyLimit = "16M"
+	// BatchMaxRows is the most rows an all_or_nothing batch holds in memory before answering 413 Request Entity Too Large
+	BatchMaxRows = 10000
+
+	batchChunkSize = 500 // Users created per transaction when the batch is not all_or_nothing
+	mimeNDJSON     = "application/x-ndjson"
+	mimeCSV        = "text/csv"
+)
+
+// batchRow is a parsed row of the batch body, err is filled when the row could not be parsed or is invalid
+type batchRow struct {
+	row  int
+	user *models.User
+	err  error
+}
+
+// BatchCreate Users Controller streams NDJSON or CSV Users from the body, creates them in chunked transactions and streams back one NDJSON result per row.
+// With all_or_nothing=true the whole batch is created in a single transaction and nothing is created if any row fails.
+func BatchCreate(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		var allOrNothing bool
+		var err error
+		if values := c.QueryParams(); values.Has("all_or_nothing") {
+			allOrNothing, err = strconv.ParseBool(values.Get("all_or_nothing"))
+			if err != nil {
+				s.Logger.Error("failed to parse all_or_nothing", zap.Error(err))
+				return c.NoContent(http.StatusBadRequest)
+			}
+		}
+
+		next, err := newBatchReader(c.Request())
+		if err != nil {
+			s.Logger.Error("failed to read users batch", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		if allOrNothing {
+			return batchCreateAll(c, s, next)
+		}
+		return batchCreateChunks(c, s, next)
+	}
+}
+
+// batchCreateChunks creates the valid rows in chunks, a failed chunk is retried row by row to report which rows failed
+func batchCreateChunks(c echo.Context, s *server.Server, next func() (*batchRow, error)) error {
+	ctx := c.Request().Context()
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, mimeNDJSON)
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+
+	chunk := []*batchRow{}
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		results := createChunk(c, s, chunk)
+		chunk = chunk[:0]
+		for _, result := range results {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		}
+		res.Flush()
+		return nil
+	}
+
+	for {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.Logger.Error("failed to read users batch", zap.Error(err))
+			return nil // The status was already sent, the client sees the stream ending early
+		}
+
+		if row.err != nil {
+			if err := enc.Encode(&models.BatchResult{Row: row.row, Error: row.err.Error()}); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) == batchChunkSize {
+			if err := flush(); err != nil || ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+
+	_ = flush()
+	return nil
+}
+
+// createChunk creates the rows in a single transaction or falls back to one by one when the transaction fails
+func createChunk(c echo.Context, s *server.Server, chunk []*batchRow) []*models.BatchResult {
+	ctx := c.Request().Context()
+	results := make([]*models.BatchResult, len(chunk))
+
+	users := make([]*models.User, len(chunk))
+	for i, row := range chunk {
+		users[i] = row.user
+	}
+
+	created, err := s.UserRepository.CreateUsers(ctx, users)
+	if err == nil {
+		for i, user := range created {
+			results[i] = &models.BatchResult{Row: chunk[i].row, ID: &user.ID}
+		}
+		return results
+	}
+	s.Logger.Warn("failed to persist users chunk, retrying one by one", zap.Error(err))
+
+	for i, row := range chunk {
+		user, err := s.UserRepository.CreateUser(ctx, row.user)
+		if err != nil {
+			s.Logger.Error("failed to persist batch user", zap.Int("row", row.row), zap.Error(err))
+			results[i] = &models.BatchResult{Row: row.row, Error: batchError(err)}
+			continue
+		}
+		results[i] = &models.BatchResult{Row: row.row, ID: &user.ID}
+	}
+	return results
+}
+
+// batchCreateAll reads the whole batch and creates it in a single transaction, if any row is invalid nothing is created
+func batchCreateAll(c echo.Context, s *server.Server, next func() (*batchRow, error)) error {
+	rows := []*batchRow{}
+	invalid := false
+	for {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.Logger.Error("failed to read users batch", zap.Error(err))
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				return c.NoContent(httpErr.Code) // e.g. the body limit was exceeded while reading
+			}
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if len(rows) == BatchMaxRows {
+			s.Logger.Error("users batch has too many rows", zap.Int("max_rows", BatchMaxRows))
+			return c.JSON(http.StatusRequestEntityTooLarge, &models.ErrorResponse{Message: fmt.Sprintf("batch exceeds %d rows", BatchMaxRows)})
+		}
+		invalid = invalid || row.err != nil
+		rows = append(rows, row)
+	}
+
+	results := make([]*models.BatchResult, len(rows))
+	if invalid {
+		for i, row := range rows {
+			results[i] = &models.BatchResult{Row: row.row, Error: "batch aborted"}
+			if row.err != nil {
+				results[i].Error = row.err.Error()
+			}
+		}
+		return streamResults(c, http.StatusBadRequest, results)
+	}
+
+	users := make([]*models.User, len(rows))
+	for i, row := range rows {
+		users[i] = row.user
+	}
+
+	created, err := s.UserRepository.CreateUsers(c.Request().Context(), users)
+	if err != nil {
+		s.Logger.Error("failed to persist users batch", zap.Error(err))
+		var valErr *models.ValidationError
+		if errors.As(err, &valErr) {
+			return c.JSON(http.StatusBadRequest, valErr.Response())
+		}
+		var dupErr *models.DuplicateError
+		if errors.As(err, &dupErr) {
+			return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: dupErr.Error(), Field: dupErr.Field})
+		}
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	for i, user := range created {
+		results[i] = &models.BatchResult{Row: rows[i].row, ID: &user.ID}
+	}
+	return streamResults(c, http.StatusOK, results)
+}
+
+// streamResults writes one NDJSON line per result
+func streamResults(c echo.Context, status int, results []*models.BatchResult) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, mimeNDJSON)
+	res.WriteHeader(status)
+	enc := json.NewEncoder(res)
+	for _, result := range results {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	res.Flush()
+	return nil
+}
+
+// batchError hides unexpected failures from the client, only errors the client can act on are described
+func batchError(err error) string {
+	var dupErr *models.DuplicateError
+	if errors.As(err, &dupErr) {
+		return dupErr.Error()
+	}
+	var valErr *models.ValidationError
+	if errors.As(err, &valErr) {
+		return valErr.Error()
+	}
+	return "failed to create user"
+}
+
+// newBatchReader returns a function that yields one row of the body per call until io.EOF, the format depends on the Content-Type
+func newBatchReader(req *http.Request) (func() (*batchRow, error), error) {
+	contentType := req.Header.Get(echo.HeaderContentType)
+	switch {
+	case strings.HasPrefix(contentType, mimeCSV):
+		return newCSVReader(req.Body)
+	case strings.HasPrefix(contentType, mimeNDJSON), strings.HasPrefix(contentType, echo.MIMEApplicationJSON):
+		return newNDJSONReader(req.Body), nil
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+// newNDJSONReader reads one JSON User per line, blank lines are skipped
+func newNDJSONReader(body io.Reader) func() (*batchRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	return func() (*batchRow, error) {
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+
+			user := new(models.User)
+			if err := json.Unmarshal(data, user); err != nil {
+				return &batchRow{row: line, err: errors.New("invalid json")}, nil
+			}
+			return &batchRow{row: line, user: user, err: validateUser(user)}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// newCSVReader reads one User per record, the first record is a header naming the columns with the User JSON names
+func newCSVReader(body io.Reader) (func() (*batchRow, error), error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	line := 0
+	return func() (*batchRow, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		line++
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &batchRow{row: line, err: errors.New("invalid csv record")}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		user := &models.User{
+			FirstName: field("first_name"),
+			LastName:  field("last_name"),
+			Nickname:  field("nickname"),
+			Password:  field("password"),
+			Email:     field("email"),
+			Country:   field("country"),
+		}
+		return &batchRow{row: line, user: user, err: validateUser(user)}, nil
+	}, nil
+}
+
+// validateUser checks the fields required to create a User
+func validateUser(user *models.User) error {
+	missing := []string{}
+	for name, value := range map[string]string{
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"nickname":   user.Nickname,
+		"password":   user.Password,
+		"email":      user.Email,
+		"country":    user.Country,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if !strings.Contains(user.Email, "@") {
+		return errors.New("invalid email")
+	}
+	if len(user.Country) != 2 {
+		return errors.New("country must be a 2 letter code")
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package users_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestBatchCreate(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.BatchCreate(s)
+
+	e := echo.New()
+
+	john := &models.User{FirstName: "John", LastName: "Tester", Nickname: "JT", Password: "ABC123!", Email: "john.tester@email.com", Country: "US"}
+	jane := &models.User{FirstName: "Jane", LastName: "Tester", Nickname: "JA", Password: "ABC123!", Email: "jane.tester@email.com", Country: "GB"}
+	johnID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	janeID := uuid.MustParse("bec30bd2-0a60-4609-8271-d74cd206a7ed")
+
+	johnJSON := `{"first_name":"John","last_name":"Tester","nickname":"JT","password":"ABC123!","email":"john.tester@email.com","country":"US"}`
+	janeJSON := `{"first_name":"Jane","last_name":"Tester","nickname":"JA","password":"ABC123!","email":"jane.tester@email.com","country":"GB"}`
+
+	tt := []struct {
+		name        string
+		queryStr    string
+		contentType string
+		body        string
+		mock        func()
+		httpStatus  int
+		results     []models.BatchResult
+	}{
+		{
+			name:        "users.BatchCreate NDJSON StatusOK",
+			contentType: "application/x-ndjson",
+			body:        johnJSON + "\n\n" + janeJSON + "\n",
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john, jane}).Times(1).
+					Return([]*models.User{{ID: johnID}, {ID: janeID}}, nil)
+			},
+			httpStatus: http.StatusOK,
+			results:    []models.BatchResult{{Row: 1, ID: &johnID}, {Row: 3, ID: &janeID}},
+		},
+		{
+			name:        "users.BatchCreate CSV StatusOK with invalid rows",
+			contentType: "text/csv",
+			body: "first_name,last_name,nickname,password,email,country\n" +
+				"John,Tester,JT,ABC123!,john.tester@email.com,US\n" +
+				"Jane,Tester,JA,ABC123!,jane.tester,GB\n" +
+				"Jane,Tester\n",
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john}).Times(1).
+					Return([]*models.User{{ID: johnID}}, nil)
+			},
+			httpStatus: http.StatusOK,
+			results: []models.BatchResult{
+				{Row: 2, Error: "invalid email"},
+				{Row: 3, Error: "invalid csv record"},
+				{Row: 1, ID: &johnID},
+			},
+		},
+		{
+			name:        "users.BatchCreate NDJSON StatusOK chunk retried row by row",
+			contentType: "application/x-ndjson",
+			body:        johnJSON + "\n" + janeJSON + "\n",
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john, jane}).Times(1).
+					Return(nil, fmt.Errorf("createusers query failed: %w", &models.DuplicateError{Field: "email"}))
+				mockedRepo.EXPECT().CreateUser(gomock.Any(), john).Times(1).
+					Return(&models.User{ID: johnID}, nil)
+				mockedRepo.EXPECT().CreateUser(gomock.Any(), jane).Times(1).
+					Return(nil, fmt.Errorf("createuser failed: %w", &models.DuplicateError{Field: "email"}))
+			},
+			httpStatus: http.StatusOK,
+			results:    []models.BatchResult{{Row: 1, ID: &johnID}, {Row: 2, Error: "user email already exists"}},
+		},
+		{
+			name:        "users.BatchCreate AllOrNothing StatusOK",
+			queryStr:    "?all_or_nothing=true",
+			contentType: "application/x-ndjson",
+			body:        johnJSON + "\n" + janeJSON,
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john, jane}).Times(1).
+					Return([]*models.User{{ID: johnID}, {ID: janeID}}, nil)
+			},
+			httpStatus: http.StatusOK,
+			results:    []models.BatchResult{{Row: 1, ID: &johnID}, {Row: 2, ID: &janeID}},
+		},
+		{
+			name:        "users.BatchCreate AllOrNothing StatusBadRequest invalid row",
+			queryStr:    "?all_or_nothing=true",
+			contentType: "application/x-ndjson",
+			body:        johnJSON + "\n" + `{"first_name":"Jane"}`,
+			mock:        func() {},
+			httpStatus:  http.StatusBadRequest,
+			results: []models.BatchResult{
+				{Row: 1, Error: "batch aborted"},
+				{Row: 2, Error: "missing country, email, last_name, nickname, password"},
+			},
+		},
+		{
+			name:        "users.BatchCreate AllOrNothing StatusConflict",
+			queryStr:    "?all_or_nothing=true",
+			contentType: "application/x-ndjson",
+			body:        johnJSON + "\n" + janeJSON,
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john, jane}).Times(1).
+					Return(nil, fmt.Errorf("createusers query failed: %w", &models.DuplicateError{Field: "nickname"}))
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:        "users.BatchCreate AllOrNothing StatusBadRequest password policy",
+			queryStr:    "?all_or_nothing=true",
+			contentType: "application/x-ndjson",
+			body:        johnJSON,
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john}).Times(1).
+					Return(nil, fmt.Errorf("createusers failed: %w", &models.ValidationError{Violations: []models.Violation{
+						{Field: "password", Code: "lower", Message: "password must contain a lower character"},
+					}}))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "users.BatchCreate AllOrNothing StatusInternalServerError",
+			queryStr:    "?all_or_nothing=true",
+			contentType: "application/x-ndjson",
+			body:        johnJSON,
+			mock: func() {
+				mockedRepo.EXPECT().CreateUsers(gomock.Any(), []*models.User{john}).Times(1).
+					Return(nil, errors.New("Generic Error"))
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "users.BatchCreate StatusBadRequest ContentType",
+			contentType: "application/xml",
+			body:        "<users/>",
+			mock:        func() {},
+			httpStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "users.BatchCreate StatusBadRequest AllOrNothing",
+			queryStr:    "?all_or_nothing=sure",
+			contentType: "application/x-ndjson",
+			body:        johnJSON,
+			mock:        func() {},
+			httpStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+test.queryStr, bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			test.mock()
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.results != nil {
+					results := []models.BatchResult{}
+					scanner := bufio.NewScanner(rec.Body)
+					for scanner.Scan() {
+						var result models.BatchResult
+						assert.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+						results = append(results, result)
+					}
+					assert.Equal(t, test.results, results)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchCreateTooLarge(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	e := echo.New()
+
+	johnJSON := `{"first_name":"John","last_name":"Tester","nickname":"JT","password":"ABC123!","email":"john.tester@email.com","country":"US"}`
+
+	tt := []struct {
+		name          string
+		body          string
+		limit         string
+		contentLength int64
+	}{
+		{
+			name:          "too many rows",
+			body:          strings.Repeat(johnJSON+"\n", users.BatchMaxRows+1),
+			limit:         users.BatchBodyLimit,
+			contentLength: -1,
+		},
+		{
+			name:          "body limit exceeded while reading",
+			body:          strings.Repeat(johnJSON+"\n", 20),
+			limit:         "1K",
+			contentLength: -1, // Unknown, e.g. a chunked upload, so the limit is only found while reading
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			handler := middleware.BodyLimit(test.limit)(users.BatchCreate(s))
+
+			req := httptest.NewRequest(http.MethodPost, "/?all_or_nothing=true", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, "application/x-ndjson")
+			req.ContentLength = test.contentLength
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Nothing is created
+			mockedRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Any()).Times(0)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+const dryRunSampleSize = 10 // Users returned as a sample of a dry run
+
+// batchPatch are the fields that may be changed in bulk, unique fields like email and nickname are left out on purpose
+type batchPatch struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Country   string `json:"country"`
+}
+
+// BatchUpdate Users Controller applies the patch in the body to every User matching the same filters as Find.
+// With dry_run=true nothing is changed and the affected count is returned with a sample of the Users.
+func BatchUpdate(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		filter, dryRun, err := parseBatchFilter(c.Request().URL.Query())
+		if err != nil {
+			s.Logger.Error("Failed to parse batch filters", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: err.Error()})
+		}
+
+		patch := new(batchPatch)
+		dec := json.NewDecoder(c.Request().Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(patch); err != nil {
+			s.Logger.Error("failed to parse patch body", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "only first_name, last_name and country can be patched"})
+		}
+		if *patch == (batchPatch{}) {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "the patch is empty"})
+		}
+		if patch.Country != "" && len(patch.Country) != 2 {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "country must be a 2 letter code", Field: "country"})
+		}
+
+		if dryRun {
+			return dryRunResponse(c, s, filter)
+		}
+
+		updatedUsers, err := s.UserRepository.UpdateUsersByFilter(c.Request().Context(), filter, &models.User{
+			FirstName: patch.FirstName,
+			LastName:  patch.LastName,
+			Country:   patch.Country,
+		})
+		if err != nil {
+			// The chunks committed before the failure stay updated
+			s.Logger.Error("UpdateUsersByFilter failed", zap.Int("updated", len(updatedUsers)), zap.Error(err))
+			return c.JSON(http.StatusInternalServerError, &models.BatchResponse{Affected: int64(len(updatedUsers))})
+		}
+
+		return c.JSON(http.StatusOK, &models.BatchResponse{Affected: int64(len(updatedUsers))})
+	}
+}
+
+// BatchDelete Users Controller soft-deletes every User matching the same filters as Find.
+// With dry_run=true nothing is deleted and the affected count is returned with a sample of the Users.
+func BatchDelete(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		filter, dryRun, err := parseBatchFilter(c.Request().URL.Query())
+		if err != nil {
+			s.Logger.Error("Failed to parse batch filters", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: err.Error()})
+		}
+
+		if dryRun {
+			return dryRunResponse(c, s, filter)
+		}
+
+		removedIDs, err := s.UserRepository.RemoveUsersByFilter(c.Request().Context(), filter)
+		if err != nil {
+			// The chunks committed before the failure stay deleted
+			s.Logger.Error("RemoveUsersByFilter failed", zap.Int("removed", len(removedIDs)), zap.Error(err))
+			return c.JSON(http.StatusInternalServerError, &models.BatchResponse{Affected: int64(len(removedIDs))})
+		}
+
+		return c.JSON(http.StatusOK, &models.BatchResponse{Affected: int64(len(removedIDs))})
+	}
+}
+
+// dryRunResponse returns how many Users match the filter with a sample of them
+func dryRunResponse(c echo.Context, s *server.Server, filter *models.User) error {
+	ctx := c.Request().Context()
+
+	count, err := s.UserRepository.CountUsers(ctx, filter, false)
+	if err != nil {
+		s.Logger.Error("CountUsers failed", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	sample, err := s.UserRepository.FindUsers(ctx, filter, false, "", dryRunSampleSize)
+	if err != nil {
+		s.Logger.Error("FindUsers failed", zap.Error(err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, &models.BatchResponse{DryRun: true, Affected: count, Sample: sample.Users})
+}
+
+// parseBatchFilter parses the same filters as Find plus dry_run. At least one filter is required so a typo can't change every User.
+func parseBatchFilter(values url.Values) (*models.User, bool, error) {
+	filter, includeDeleted, err := parseFilter(values)
+	if err != nil {
+		return nil, false, err
+	}
+	if includeDeleted {
+		return nil, false, errors.New("include_deleted is not supported by batch operations")
+	}
+	if *filter == (models.User{}) {
+		return nil, false, errors.New("at least one filter is required")
+	}
+
+	var dryRun bool
+	if values.Has("dry_run") {
+		dryRun, err = strconv.ParseBool(values.Get("dry_run"))
+		if err != nil {
+			return nil, false, errors.New("invalid dry_run")
+		}
+	}
+
+	return filter, dryRun, nil
+}
@@ -0,0 +1,236 @@
+package users_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestBatchUpdate(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.BatchUpdate(s)
+
+	e := echo.New()
+
+	sample := []*models.User{{ID: uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"), Country: "UK"}}
+
+	tt := []struct {
+		name       string
+		queryStr   string
+		body       string
+		mock       func()
+		httpStatus int
+		response   *models.BatchResponse
+	}{
+		{
+			name:     "users.BatchUpdate StatusOK",
+			queryStr: "?country=UK",
+			body:     `{"country":"GB"}`,
+			mock: func() {
+				mockedRepo.EXPECT().UpdateUsersByFilter(gomock.Any(), &models.User{Country: "UK"}, &models.User{Country: "GB"}).Times(1).
+					Return([]*models.User{{}, {}}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.BatchResponse{Affected: 2},
+		},
+		{
+			name:     "users.BatchUpdate StatusOK DryRun",
+			queryStr: "?country=UK&dry_run=true",
+			body:     `{"country":"GB"}`,
+			mock: func() {
+				mockedRepo.EXPECT().CountUsers(gomock.Any(), &models.User{Country: "UK"}, false).Times(1).Return(int64(42), nil)
+				mockedRepo.EXPECT().FindUsers(gomock.Any(), &models.User{Country: "UK"}, false, "", 10).Times(1).
+					Return(&models.UsersResponse{Users: sample}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.BatchResponse{DryRun: true, Affected: 42, Sample: sample},
+		},
+		{
+			name:     "users.BatchUpdate StatusInternalServerError partially updated",
+			queryStr: "?country=UK",
+			body:     `{"country":"GB"}`,
+			mock: func() {
+				mockedRepo.EXPECT().UpdateUsersByFilter(gomock.Any(), &models.User{Country: "UK"}, &models.User{Country: "GB"}).Times(1).
+					Return([]*models.User{{}}, errors.New("Generic Error"))
+			},
+			httpStatus: http.StatusInternalServerError,
+			response:   &models.BatchResponse{Affected: 1},
+		},
+		{
+			name:       "users.BatchUpdate StatusBadRequest without filter",
+			queryStr:   "",
+			body:       `{"country":"GB"}`,
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.BatchUpdate StatusBadRequest unique field",
+			queryStr:   "?country=UK",
+			body:       `{"email":"same@email.com"}`,
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.BatchUpdate StatusBadRequest empty patch",
+			queryStr:   "?country=UK",
+			body:       `{}`,
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.BatchUpdate StatusBadRequest include_deleted",
+			queryStr:   "?country=UK&include_deleted=true",
+			body:       `{"country":"GB"}`,
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api"+test.queryStr, bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			test.mock()
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.response != nil {
+					var response models.BatchResponse
+					err := json.Unmarshal(rec.Body.Bytes(), &response)
+					assert.NoError(t, err)
+					assert.Equal(t, *test.response, response)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.BatchDelete(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name       string
+		queryStr   string
+		mock       func()
+		httpStatus int
+		response   *models.BatchResponse
+	}{
+		{
+			name:     "users.BatchDelete StatusOK",
+			queryStr: "?last_name=Tester",
+			mock: func() {
+				mockedRepo.EXPECT().RemoveUsersByFilter(gomock.Any(), &models.User{LastName: "Tester"}).Times(1).
+					Return([]uuid.UUID{uuid.New(), uuid.New(), uuid.New()}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.BatchResponse{Affected: 3},
+		},
+		{
+			name:     "users.BatchDelete StatusOK DryRun",
+			queryStr: "?last_name=Tester&dry_run=1",
+			mock: func() {
+				mockedRepo.EXPECT().CountUsers(gomock.Any(), &models.User{LastName: "Tester"}, false).Times(1).Return(int64(0), nil)
+				mockedRepo.EXPECT().FindUsers(gomock.Any(), &models.User{LastName: "Tester"}, false, "", 10).Times(1).
+					Return(&models.UsersResponse{Users: []*models.User{}}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.BatchResponse{DryRun: true, Affected: 0},
+		},
+		{
+			name:     "users.BatchDelete StatusInternalServerError DryRun",
+			queryStr: "?last_name=Tester&dry_run=true",
+			mock: func() {
+				mockedRepo.EXPECT().CountUsers(gomock.Any(), &models.User{LastName: "Tester"}, false).Times(1).Return(int64(0), errors.New("Generic Error"))
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.BatchDelete StatusBadRequest dry_run",
+			queryStr:   "?last_name=Tester&dry_run=perhaps",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.BatchDelete StatusBadRequest without filter",
+			queryStr:   "?dry_run=false",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "users.BatchDelete StatusOK email suffix",
+			queryStr: "?email=*%40example.com",
+			mock: func() {
+				mockedRepo.EXPECT().RemoveUsersByFilter(gomock.Any(), &models.User{Email: "*@example.com"}).Times(1).
+					Return([]uuid.UUID{uuid.New()}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.BatchResponse{Affected: 1},
+		},
+		{
+			name:       "users.BatchDelete StatusBadRequest email wildcard without suffix",
+			queryStr:   "?email=*",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api"+test.queryStr, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			test.mock()
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.response != nil {
+					var response models.BatchResponse
+					err := json.Unmarshal(rec.Body.Bytes(), &response)
+					assert.NoError(t, err)
+					assert.Equal(t, *test.response, response)
+				}
+			}
+		})
+	}
+}
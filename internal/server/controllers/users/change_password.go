@@ -0,0 +1,57 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// changePasswordRequest holds the current password, which must match, and the new one
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+}
+
+// ChangePassword User Controller sets a new password without resending the whole User.
+func ChangePassword(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		request := new(changePasswordRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Password == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "password is required", Field: "password"})
+		}
+
+		_, err = s.UserRepository.ChangePassword(c.Request().Context(), parsedID, request.CurrentPassword, request.Password)
+		if err != nil {
+			s.Logger.Error("failed to change password", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			var valErr *models.ValidationError
+			if errors.As(err, &valErr) {
+				return c.JSON(http.StatusBadRequest, valErr.Response())
+			}
+			if errors.Is(err, models.ErrWrongPassword) {
+				return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: models.ErrWrongPassword.Error(), Field: "current_password"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
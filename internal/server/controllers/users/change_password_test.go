@@ -0,0 +1,123 @@
+package users_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestChangePassword(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.ChangePassword(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+
+	tt := []struct {
+		name       string
+		inputID    string
+		body       string
+		repoCall   int
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "users.ChangePassword StatusNoContent",
+			inputID:    userID.String(),
+			body:       `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall:   1,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "users.ChangePassword StatusForbidden wrong password",
+			inputID:    userID.String(),
+			body:       `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("changepassword failed: %w", models.ErrWrongPassword),
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:     "users.ChangePassword StatusBadRequest reused password",
+			inputID:  userID.String(),
+			body:     `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall: 1,
+			repoErr: fmt.Errorf("changepassword failed: %w", &models.ValidationError{Violations: []models.Violation{
+				{Field: "password", Code: "reused", Message: "password must differ from the last 5 passwords"},
+			}}),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.ChangePassword StatusNotFound",
+			inputID:    userID.String(),
+			body:       `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("changepassword failed: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "users.ChangePassword StatusInternalServerError",
+			inputID:    userID.String(),
+			body:       `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.ChangePassword StatusBadRequest missing password",
+			inputID:    userID.String(),
+			body:       `{"current_password":"ABC123!"}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.ChangePassword StatusBadRequest id",
+			inputID:    "123",
+			body:       `{"current_password":"ABC123!","password":"NewPass123!"}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id/password")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().ChangePassword(c.Request().Context(), userID, "ABC123!", "NewPass123!").Times(test.repoCall).Return(&models.User{}, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -18,10 +19,22 @@ func Create(s *server.Server) func(c echo.Context) error {
 			s.Logger.Error("failed to parse user body", zap.Error(err))
 			return c.NoContent(http.StatusBadRequest)
 		}
+		if err := validateUser(u); err != nil {
+			s.Logger.Error("invalid user body", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: err.Error()})
+		}
 
 		user, err := s.UserRepository.CreateUser(c.Request().Context(), u)
 		if err != nil {
 			s.Logger.Error("failed to persist user", zap.Error(err))
+			var valErr *models.ValidationError
+			if errors.As(err, &valErr) {
+				return c.JSON(http.StatusBadRequest, valErr.Response())
+			}
+			var dupErr *models.DuplicateError
+			if errors.As(err, &dupErr) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: dupErr.Error(), Field: dupErr.Field})
+			}
 			return c.NoContent(http.StatusInternalServerError)
 		}
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -85,6 +86,74 @@ func TestCreate(t *testing.T) {
 			repoErr:    errors.New("Generic Error"),
 			httpStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "users.Create StatusConflict",
+			inputUser: `{
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"password":"ABC123!",
+				"email":"john.tester@email.com",
+				"country":"US"
+			}`,
+			repoCall: 1,
+			repoUser: &models.User{
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Password:  "ABC123!",
+				Email:     "john.tester@email.com",
+				Country:   "US",
+			},
+			repoErr:    fmt.Errorf("createuser failed: %w", &models.DuplicateError{Field: "email"}),
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name: "users.Create StatusBadRequest password policy",
+			inputUser: `{
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"password":"ABC123!",
+				"email":"john.tester@email.com",
+				"country":"US"
+			}`,
+			repoCall: 1,
+			repoUser: &models.User{
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Password:  "ABC123!",
+				Email:     "john.tester@email.com",
+				Country:   "US",
+			},
+			repoErr: fmt.Errorf("createuser failed: %w", &models.ValidationError{Violations: []models.Violation{
+				{Field: "password", Code: "lower", Message: "password must contain a lower character"},
+			}}),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "users.Create StatusBadRequest invalid user",
+			inputUser: `{
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"password":"ABC123!",
+				"email":"john.tester",
+				"country":"USA"
+			}`,
+			repoCall: 0,
+			repoUser: &models.User{
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Password:  "ABC123!",
+				Email:     "john.tester",
+				Country:   "USA",
+			},
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusBadRequest,
+		},
 		{
 			name: "users.Create StatusBadRequest",
 			inputUser: `{
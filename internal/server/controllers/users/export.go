@@ -0,0 +1,273 @@
+package users
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xitongsys/parquet-go/writer"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+const (
+	exportFlushEvery    = 1000            // Users written between flushes of the response
+	parquetRowGroupSize = 8 * 1024 * 1024 // Parquet buffers a whole row group in memory before writing it
+)
+
+// exportFields are the fields that can be exported, in their default order. The password is never exported.
+var exportFields = []string{"id", "first_name", "last_name", "nickname", "email", "country", "created_at", "updated_at", "deleted_at"}
+
+// exportWriter writes Users in a given format, Close must be called to write any buffered data
+type exportWriter interface {
+	Write(user *models.User) error
+	Close() error
+}
+
+// Export Users Controller streams every User matching the same filters as Find in CSV, NDJSON or Parquet.
+// The fields query param is a comma separated projection of the exported fields.
+func Export(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		values := c.Request().URL.Query()
+
+		user, includeDeleted, err := parseFilter(values)
+		if err != nil {
+			s.Logger.Error("Failed to parse filters", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		fields, err := parseFields(values.Get("fields"))
+		if err != nil {
+			s.Logger.Error("Failed to parse fields", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: err.Error(), Field: "fields"})
+		}
+
+		format := values.Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "ndjson" && format != "parquet" {
+			s.Logger.Error("Unsupported export format", zap.String("format", format))
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "unsupported format", Field: "format"})
+		}
+
+		// Headers must be set before creating the writer as the Parquet writer already writes to the response
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "users."+format))
+		var w exportWriter
+		switch format {
+		case "csv":
+			res.Header().Set(echo.HeaderContentType, "text/csv")
+			w, err = newCSVExportWriter(res, fields)
+		case "ndjson":
+			res.Header().Set(echo.HeaderContentType, mimeNDJSON)
+			w = newNDJSONExportWriter(res, fields)
+		case "parquet":
+			res.Header().Set(echo.HeaderContentType, "application/vnd.apache.parquet")
+			w, err = newParquetExportWriter(res, fields)
+		}
+		if err != nil {
+			s.Logger.Error("Failed to create export writer", zap.Error(err))
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		res.WriteHeader(http.StatusOK)
+
+		exported := 0
+		err = s.UserRepository.ExportUsers(c.Request().Context(), user, includeDeleted, func(user *models.User) error {
+			if err := w.Write(user); err != nil {
+				return err
+			}
+			exported++
+			if exported%exportFlushEvery == 0 {
+				res.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			// The status was already sent, the client sees the stream ending early
+			s.Logger.Error("ExportUsers failed", zap.Int("exported", exported), zap.Error(err))
+			return nil
+		}
+
+		if err := w.Close(); err != nil {
+			s.Logger.Error("Failed to finish export", zap.Error(err))
+		}
+		res.Flush()
+		return nil
+	}
+}
+
+// parseFields validates the fields projection, all exportFields are returned when it is empty
+func parseFields(param string) ([]string, error) {
+	if param == "" {
+		return exportFields, nil
+	}
+
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if !isExportField(field) {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		// A repeated field would be a duplicated CSV header and Parquet column
+		if seen[field] {
+			return nil, fmt.Errorf("duplicated field %q", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func isExportField(field string) bool {
+	for _, f := range exportFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue returns the value of the field as text, timestamps are RFC 3339 in UTC. ok is false for a nil deleted_at.
+func fieldValue(user *models.User, field string) (value string, ok bool) {
+	switch field {
+	case "id":
+		return user.ID.String(), true
+	case "first_name":
+		return user.FirstName, true
+	case "last_name":
+		return user.LastName, true
+	case "nickname":
+		return user.Nickname, true
+	case "email":
+		return user.Email, true
+	case "country":
+		return user.Country, true
+	case "created_at":
+		return user.CreatedAt.UTC().Format(time.RFC3339Nano), true
+	case "updated_at":
+		return user.UpdatedAt.UTC().Format(time.RFC3339Nano), true
+	case "deleted_at":
+		if user.DeletedAt == nil {
+			return "", false
+		}
+		return user.DeletedAt.UTC().Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// csvExportWriter writes a header with the field names and one record per User
+type csvExportWriter struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func newCSVExportWriter(out io.Writer, fields []string) (*csvExportWriter, error) {
+	w := csv.NewWriter(out)
+	if err := w.Write(fields); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{w: w, fields: fields}, nil
+}
+
+func (e *csvExportWriter) Write(user *models.User) error {
+	record := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		record[i], _ = fieldValue(user, field)
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonExportWriter writes one JSON object per line with just the projected fields
+type ndjsonExportWriter struct {
+	enc    *json.Encoder
+	fields []string
+}
+
+func newNDJSONExportWriter(out io.Writer, fields []string) *ndjsonExportWriter {
+	return &ndjsonExportWriter{enc: json.NewEncoder(out), fields: fields}
+}
+
+func (e *ndjsonExportWriter) Write(user *models.User) error {
+	record := make(map[string]*string, len(e.fields))
+	for _, field := range e.fields {
+		if value, ok := fieldValue(user, field); ok {
+			record[field] = &value
+		} else {
+			record[field] = nil
+		}
+	}
+	return e.enc.Encode(record)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// parquetExportWriter writes a Parquet file with one optional column per projected field, timestamps are stored as TIMESTAMP_MILLIS
+type parquetExportWriter struct {
+	pw     *writer.CSVWriter
+	fields []string
+}
+
+func newParquetExportWriter(out io.Writer, fields []string) (*parquetExportWriter, error) {
+	schema := make([]string, len(fields))
+	for i, field := range fields {
+		if strings.HasSuffix(field, "_at") {
+			schema[i] = fmt.Sprintf("name=%s, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL", field)
+		} else {
+			schema[i] = fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL", field)
+		}
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(schema, out, 1)
+	if err != nil {
+		return nil, fmt.Errorf("parquet writer creation failed: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+
+	return &parquetExportWriter{pw: pw, fields: fields}, nil
+}
+
+func (e *parquetExportWriter) Write(user *models.User) error {
+	record := make([]*string, len(e.fields))
+	for i, field := range e.fields {
+		var value string
+		var ok bool
+		switch field {
+		case "created_at":
+			value, ok = strconv.FormatInt(user.CreatedAt.UnixMilli(), 10), true
+		case "updated_at":
+			value, ok = strconv.FormatInt(user.UpdatedAt.UnixMilli(), 10), true
+		case "deleted_at":
+			if user.DeletedAt != nil {
+				value, ok = strconv.FormatInt(user.DeletedAt.UnixMilli(), 10), true
+			}
+		default:
+			value, ok = fieldValue(user, field)
+		}
+		if ok {
+			record[i] = &value
+		}
+	}
+	return e.pw.WriteString(record)
+}
+
+func (e *parquetExportWriter) Close() error {
+	return e.pw.WriteStop()
+}
@@ -0,0 +1,171 @@
+package users_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestExport(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.Export(s)
+
+	e := echo.New()
+
+	createdAt := time.Date(2022, 10, 9, 16, 25, 3, 0, time.UTC)
+	deletedAt := time.Date(2022, 10, 10, 8, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+	repoUsers := []*models.User{
+		{
+			ID:        uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			FirstName: "John",
+			LastName:  "Tester",
+			Nickname:  "JT",
+			Password:  "ABC123!",
+			Email:     "john.tester@email.com",
+			Country:   "US",
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		},
+		{
+			ID:        uuid.MustParse("bec30bd2-0a60-4609-8271-d74cd206a7ed"),
+			FirstName: "Jane",
+			LastName:  "Tester",
+			Nickname:  "JA",
+			Password:  "ABC123!",
+			Email:     "jane.tester@email.com",
+			Country:   "US",
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			DeletedAt: &deletedAt,
+		},
+	}
+
+	tt := []struct {
+		name         string
+		queryStr     string
+		inputUser    *models.User
+		inputDeleted bool
+		repoCall     int
+		repoErr      error
+		httpStatus   int
+		body         string
+	}{
+		{
+			name:         "users.Export CSV StatusOK",
+			queryStr:     "?country=US&include_deleted=true",
+			inputUser:    &models.User{Country: "US"},
+			inputDeleted: true,
+			repoCall:     1,
+			httpStatus:   http.StatusOK,
+			body: "id,first_name,last_name,nickname,email,country,created_at,updated_at,deleted_at\n" +
+				"904bc695-6b6c-418a-82a0-0acc7a747d46,John,Tester,JT,john.tester@email.com,US,2022-10-09T16:25:03Z,2022-10-09T16:25:03Z,\n" +
+				"bec30bd2-0a60-4609-8271-d74cd206a7ed,Jane,Tester,JA,jane.tester@email.com,US,2022-10-09T16:25:03Z,2022-10-09T16:25:03Z,2022-10-10T11:00:00Z\n",
+		},
+		{
+			name:       "users.Export NDJSON StatusOK with fields",
+			queryStr:   "?format=ndjson&fields=id,email,deleted_at",
+			inputUser:  &models.User{},
+			repoCall:   1,
+			httpStatus: http.StatusOK,
+			body: `{"deleted_at":null,"email":"john.tester@email.com","id":"904bc695-6b6c-418a-82a0-0acc7a747d46"}` + "\n" +
+				`{"deleted_at":"2022-10-10T11:00:00Z","email":"jane.tester@email.com","id":"bec30bd2-0a60-4609-8271-d74cd206a7ed"}` + "\n",
+		},
+		{
+			name:       "users.Export Parquet StatusOK",
+			queryStr:   "?format=parquet",
+			inputUser:  &models.User{},
+			repoCall:   1,
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "users.Export StatusOK with stream interrupted",
+			queryStr:   "?format=ndjson&fields=id",
+			inputUser:  &models.User{},
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "users.Export StatusBadRequest password field",
+			queryStr:   "?fields=id,password",
+			inputUser:  &models.User{},
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.Export StatusBadRequest duplicated field",
+			queryStr:   "?format=parquet&fields=id,email,email",
+			inputUser:  &models.User{},
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.Export StatusBadRequest format",
+			queryStr:   "?format=xlsx",
+			inputUser:  &models.User{},
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.Export StatusBadRequest include_deleted",
+			queryStr:   "?include_deleted=maybe",
+			inputUser:  &models.User{},
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api"+test.queryStr, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().ExportUsers(c.Request().Context(), test.inputUser, test.inputDeleted, gomock.Any()).Times(test.repoCall).
+				DoAndReturn(func(_ any, _ *models.User, _ bool, fn func(*models.User) error) error {
+					for _, user := range repoUsers {
+						if err := fn(user); err != nil {
+							return err
+						}
+					}
+					return test.repoErr
+				})
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.body != "" {
+					assert.Equal(t, test.body, rec.Body.String())
+				}
+				if test.queryStr == "?format=parquet" {
+					assert.True(t, bytes.HasPrefix(rec.Body.Bytes(), []byte("PAR1")))
+					assert.True(t, bytes.HasSuffix(rec.Body.Bytes(), []byte("PAR1")))
+				}
+				assert.NotContains(t, rec.Body.String(), "ABC123!")
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package users
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
@@ -30,16 +32,14 @@ func Find(s *server.Server) func(c echo.Context) error {
 			}
 		}
 
-		// Fills a User object with possible values provided in the querystring
-		user := &models.User{}
-		user.Country = values.Get("country")
-		user.FirstName = values.Get("first_name")
-		user.LastName = values.Get("last_name")
-		user.Email = values.Get("email")
-		user.Nickname = values.Get("nickname")
+		user, includeDeleted, err := parseFilter(values)
+		if err != nil {
+			s.Logger.Error("Failed to parse filters", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
 		pageToken := values.Get("page_token")
 
-		usersResponse, err := s.UserRepository.FindUsers(c.Request().Context(), user, pageToken, limit)
+		usersResponse, err := s.UserRepository.FindUsers(c.Request().Context(), user, includeDeleted, pageToken, limit)
 		if err != nil {
 			s.Logger.Error("FindUsers failed", zap.Error(err))
 			if errors.Is(err, sql.ErrNoRows) {
@@ -51,3 +51,29 @@ func Find(s *server.Server) func(c echo.Context) error {
 		return c.JSON(http.StatusOK, usersResponse)
 	}
 }
+
+// parseFilter fills a User object with possible values provided in the querystring, soft-deleted Users are only included when explicitly requested
+func parseFilter(values url.Values) (*models.User, bool, error) {
+	user := &models.User{}
+	user.Country = values.Get("country")
+	user.FirstName = values.Get("first_name")
+	user.LastName = values.Get("last_name")
+	user.Email = values.Get("email")
+	user.Nickname = values.Get("nickname")
+
+	// An email starting with * matches the emails ending with the rest of it, e.g. *@example.com
+	if user.Email == "*" {
+		return nil, false, errors.New("invalid email, the suffix after * is required")
+	}
+
+	var includeDeleted bool
+	if values.Has("include_deleted") {
+		var err error
+		includeDeleted, err = strconv.ParseBool(values.Get("include_deleted"))
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid include_deleted: %w", err)
+		}
+	}
+
+	return user, includeDeleted, nil
+}
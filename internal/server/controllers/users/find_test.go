@@ -39,6 +39,7 @@ func TestFind(t *testing.T) {
 		name           string
 		queryStr       string
 		inputUser      *models.User
+		inputDeleted   bool
 		inputPageToken string
 		repoCall       int
 		repoResult     *models.UsersResponse
@@ -74,6 +75,22 @@ func TestFind(t *testing.T) {
 			repoErr:    nil,
 			httpStatus: http.StatusOK,
 		},
+		{
+			name:     "users.Find StatusOK IncludeDeleted",
+			queryStr: "?country=US&include_deleted=true&page_token=ABC&limit=1",
+			repoCall: 1,
+			inputUser: &models.User{
+				Country: "US",
+			},
+			inputDeleted:   true,
+			inputPageToken: "ABC",
+			repoResult: &models.UsersResponse{
+				Users:     []*models.User{},
+				PageToken: "",
+			},
+			repoErr:    nil,
+			httpStatus: http.StatusOK,
+		},
 		{
 			name:     "users.Find StatusNotFound",
 			queryStr: "?first_name=J&page_token=ABC&limit=1",
@@ -117,6 +134,19 @@ func TestFind(t *testing.T) {
 			repoErr:    errors.New("Generic Error"),
 			httpStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "users.Find StatusBadRequest IncludeDeleted",
+			queryStr:       "?include_deleted=maybe&limit=1",
+			repoCall:       0,
+			inputUser:      &models.User{},
+			inputPageToken: "",
+			repoResult: &models.UsersResponse{
+				Users:     []*models.User{},
+				PageToken: "",
+			},
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range tt {
@@ -130,7 +160,7 @@ func TestFind(t *testing.T) {
 			test.repoResult.Users = append(test.repoResult.Users, test.inputUser)
 
 			// Mocked User Repository
-			mockedRepo.EXPECT().FindUsers(c.Request().Context(), test.inputUser, test.inputPageToken, 1).Times(test.repoCall).Return(test.repoResult, test.repoErr)
+			mockedRepo.EXPECT().FindUsers(c.Request().Context(), test.inputUser, test.inputDeleted, test.inputPageToken, 1).Times(test.repoCall).Return(test.repoResult, test.repoErr)
 
 			// Assertions
 			if assert.NoError(t, handler(c)) {
@@ -0,0 +1,53 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// Get User Controller retrieves a User by its ID.
+// With as_of (RFC 3339) the User is rebuilt from its history as it was at that instant.
+func Get(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		values := c.Request().URL.Query()
+
+		var user *models.User
+		if values.Has("as_of") {
+			var asOf time.Time
+			asOf, err = time.Parse(time.RFC3339Nano, values.Get("as_of"))
+			if err != nil {
+				s.Logger.Error("failed to parse as_of", zap.Error(err))
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "as_of must be an RFC 3339 timestamp", Field: "as_of"})
+			}
+			user, err = s.UserRepository.FindUserAsOf(c.Request().Context(), parsedID, asOf)
+		} else {
+			user, err = s.UserRepository.FindUser(c.Request().Context(), parsedID)
+		}
+		if err != nil {
+			s.Logger.Error("failed to find user", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			if errors.Is(err, models.ErrUserGone) {
+				return c.JSON(http.StatusGone, &models.ErrorResponse{Message: "the user was deleted at that time"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
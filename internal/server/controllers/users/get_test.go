@@ -0,0 +1,157 @@
+package users_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGet(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.Get(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	asOf := time.Date(2022, 10, 4, 12, 0, 0, 0, time.UTC)
+	repoUser := &models.User{
+		ID:        userID,
+		FirstName: "John",
+		LastName:  "Tester",
+		Nickname:  "JT",
+		Email:     "john.tester@email.com",
+		Country:   "US",
+	}
+
+	tt := []struct {
+		name       string
+		inputID    string
+		queryStr   string
+		mock       func()
+		httpStatus int
+		response   *models.User
+	}{
+		{
+			name:    "users.Get StatusOK",
+			inputID: userID.String(),
+			mock: func() {
+				mockedRepo.EXPECT().FindUser(gomock.Any(), userID).Times(1).Return(repoUser, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   repoUser,
+		},
+		{
+			name:     "users.Get StatusOK as_of",
+			inputID:  userID.String(),
+			queryStr: "?as_of=2022-10-04T09:00:00-03:00",
+			mock: func() {
+				mockedRepo.EXPECT().FindUserAsOf(gomock.Any(), userID, gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, _ uuid.UUID, at time.Time) (*models.User, error) {
+						assert.True(t, asOf.Equal(at))
+						return repoUser, nil
+					})
+			},
+			httpStatus: http.StatusOK,
+			response:   repoUser,
+		},
+		{
+			name:     "users.Get StatusGone as_of",
+			inputID:  userID.String(),
+			queryStr: "?as_of=2022-10-04T12:00:00Z",
+			mock: func() {
+				mockedRepo.EXPECT().FindUserAsOf(gomock.Any(), userID, gomock.Any()).Times(1).
+					Return(nil, fmt.Errorf("finduserasof failed: %w", models.ErrUserGone))
+			},
+			httpStatus: http.StatusGone,
+		},
+		{
+			name:     "users.Get StatusNotFound as_of before creation",
+			inputID:  userID.String(),
+			queryStr: "?as_of=2022-10-04T12:00:00Z",
+			mock: func() {
+				mockedRepo.EXPECT().FindUserAsOf(gomock.Any(), userID, gomock.Any()).Times(1).
+					Return(nil, fmt.Errorf("finduserasof failed: %w", models.ErrUserNotFound))
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:    "users.Get StatusNotFound",
+			inputID: userID.String(),
+			mock: func() {
+				mockedRepo.EXPECT().FindUser(gomock.Any(), userID).Times(1).
+					Return(nil, fmt.Errorf("finduser returned no rows: %w", models.ErrUserNotFound))
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:    "users.Get StatusInternalServerError",
+			inputID: userID.String(),
+			mock: func() {
+				mockedRepo.EXPECT().FindUser(gomock.Any(), userID).Times(1).Return(nil, errors.New("Generic Error"))
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.Get StatusBadRequest as_of",
+			inputID:    userID.String(),
+			queryStr:   "?as_of=last-tuesday",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.Get StatusBadRequest id",
+			inputID:    "123",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api"+test.queryStr, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			test.mock()
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.response != nil {
+					var response models.User
+					err := json.Unmarshal(rec.Body.Bytes(), &response)
+					assert.NoError(t, err)
+					assert.Equal(t, test.response.ID, response.ID)
+					assert.Equal(t, test.response.Email, response.Email)
+				}
+			}
+		})
+	}
+}
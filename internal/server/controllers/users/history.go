@@ -0,0 +1,52 @@
+package users
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// History User Controller retrieves a paginated list of the audit entries of a User, oldest first.
+// The entries outlive the User, so the history of a purged User can still be read.
+func History(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		values := c.Request().URL.Query()
+
+		var limit int
+		if values.Has("limit") {
+			limit, err = strconv.Atoi(values.Get("limit"))
+			if err != nil {
+				s.Logger.Error("Failed to parse page limit", zap.Error(err))
+				return c.NoContent(http.StatusBadRequest)
+			}
+		}
+		pageToken := values.Get("page_token")
+
+		history, err := s.UserRepository.FindUserHistory(c.Request().Context(), parsedID, pageToken, limit)
+		if err != nil {
+			s.Logger.Error("FindUserHistory failed", zap.Error(err))
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		// Every User has at least its creation entry
+		if len(history.Entries) == 0 && pageToken == "" {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if !history.Verified {
+			s.Logger.Warn("audit chain verification failed", zap.String("user_id", parsedID.String()))
+		}
+
+		return c.JSON(http.StatusOK, history)
+	}
+}
@@ -0,0 +1,136 @@
+package users_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestHistory(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.History(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	history := &models.AuditResponse{
+		Entries: []*models.AuditEntry{{
+			ID:        1,
+			UserID:    userID,
+			Operation: models.AuditCreate,
+			Actor:     "tester",
+			Hash:      "5f0c",
+			CreatedAt: time.Date(2022, 10, 9, 16, 25, 3, 0, time.UTC),
+		}},
+		PageToken: "Mg==",
+		Verified:  true,
+	}
+
+	tt := []struct {
+		name       string
+		inputID    string
+		queryStr   string
+		mock       func()
+		httpStatus int
+		response   *models.AuditResponse
+	}{
+		{
+			name:     "users.History StatusOK",
+			inputID:  userID.String(),
+			queryStr: "?limit=1",
+			mock: func() {
+				mockedRepo.EXPECT().FindUserHistory(gomock.Any(), userID, "", 1).Times(1).Return(history, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   history,
+		},
+		{
+			name:     "users.History StatusOK last page",
+			inputID:  userID.String(),
+			queryStr: "?page_token=Mg==",
+			mock: func() {
+				mockedRepo.EXPECT().FindUserHistory(gomock.Any(), userID, "Mg==", 0).Times(1).
+					Return(&models.AuditResponse{Entries: []*models.AuditEntry{}, Verified: true}, nil)
+			},
+			httpStatus: http.StatusOK,
+			response:   &models.AuditResponse{Entries: []*models.AuditEntry{}, Verified: true},
+		},
+		{
+			name:    "users.History StatusNotFound",
+			inputID: userID.String(),
+			mock: func() {
+				mockedRepo.EXPECT().FindUserHistory(gomock.Any(), userID, "", 0).Times(1).
+					Return(&models.AuditResponse{Entries: []*models.AuditEntry{}, Verified: true}, nil)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:    "users.History StatusInternalServerError",
+			inputID: userID.String(),
+			mock: func() {
+				mockedRepo.EXPECT().FindUserHistory(gomock.Any(), userID, "", 0).Times(1).Return(nil, errors.New("Generic Error"))
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.History StatusBadRequest id",
+			inputID:    "123",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.History StatusBadRequest limit",
+			inputID:    userID.String(),
+			queryStr:   "?limit=ten",
+			mock:       func() {},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api"+test.queryStr, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id/history")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			test.mock()
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.response != nil {
+					var response models.AuditResponse
+					err := json.Unmarshal(rec.Body.Bytes(), &response)
+					assert.NoError(t, err)
+					assert.Equal(t, *test.response, response)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// errCodeRequired is returned when the TOTP code is missing from the body
+var errCodeRequired = errors.New("code is required")
+
+// totpCodeRequest holds a code of the authenticator app of the User
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// EnrollTOTP User Controller generates a TOTP secret for the User, it must be confirmed with ConfirmTOTP before it is required to login.
+func EnrollTOTP(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		enrollment, err := s.MFAService.EnrollTOTP(c.Request().Context(), parsedID)
+		if err != nil {
+			s.Logger.Error("failed to enroll totp", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			if errors.Is(err, models.ErrMFAEnrolled) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: models.ErrMFAEnrolled.Error()})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusCreated, enrollment)
+	}
+}
+
+// ConfirmTOTP User Controller confirms the TOTP enrollment with a code and returns the recovery codes, which are never shown again.
+func ConfirmTOTP(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, request, err := parseTOTPCodeRequest(c)
+		if err != nil {
+			s.Logger.Error("failed to parse totp code request", zap.Error(err))
+			if errors.Is(err, errCodeRequired) {
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: errCodeRequired.Error(), Field: "code"})
+			}
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		codes, err := s.MFAService.ConfirmTOTP(c.Request().Context(), parsedID, request.Code)
+		if err != nil {
+			s.Logger.Error("failed to confirm totp", zap.Error(err))
+			return mfaError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, codes)
+	}
+}
+
+// RegenerateRecoveryCodes User Controller replaces the recovery codes of a User enrolled in TOTP, a current code is required.
+func RegenerateRecoveryCodes(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, request, err := parseTOTPCodeRequest(c)
+		if err != nil {
+			s.Logger.Error("failed to parse totp code request", zap.Error(err))
+			if errors.Is(err, errCodeRequired) {
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: errCodeRequired.Error(), Field: "code"})
+			}
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		codes, err := s.MFAService.RegenerateRecoveryCodes(c.Request().Context(), parsedID, request.Code)
+		if err != nil {
+			s.Logger.Error("failed to regenerate recovery codes", zap.Error(err))
+			return mfaError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, codes)
+	}
+}
+
+// parseTOTPCodeRequest reads the User ID and the code of the request
+func parseTOTPCodeRequest(c echo.Context) (uuid.UUID, *totpCodeRequest, error) {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	request := new(totpCodeRequest)
+	if err := c.Bind(request); err != nil {
+		return uuid.Nil, nil, err
+	}
+	if request.Code == "" {
+		return uuid.Nil, nil, errCodeRequired
+	}
+	return parsedID, request, nil
+}
+
+// mfaError writes the response of a failed TOTP confirmation or recovery codes regeneration
+func mfaError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrMFANotEnrolled):
+		return c.JSON(http.StatusNotFound, &models.ErrorResponse{Message: models.ErrMFANotEnrolled.Error()})
+	case errors.Is(err, models.ErrMFAEnrolled):
+		return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: models.ErrMFAEnrolled.Error()})
+	case errors.Is(err, models.ErrInvalidMFACode):
+		return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: models.ErrInvalidMFACode.Error(), Field: "code"})
+	}
+	return c.NoContent(http.StatusInternalServerError)
+}
@@ -0,0 +1,231 @@
+package users_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEnrollTOTP(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedService := repositories.NewMockMFAService(ctrl)
+	s.MFAService = mockedService
+
+	handler := users.EnrollTOTP(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name        string
+		id          string
+		serviceCall int
+		enrollment  *models.TOTPEnrollment
+		serviceErr  error
+		httpStatus  int
+	}{
+		{
+			name:        "users.EnrollTOTP StatusCreated",
+			id:          uuid.NewString(),
+			serviceCall: 1,
+			enrollment: &models.TOTPEnrollment{
+				Secret: "JBSWY3DPEHPK3PXP",
+				URI:    "otpauth://totp/manage_user_go:john.tester%40email.com?issuer=manage_user_go&secret=JBSWY3DPEHPK3PXP",
+			},
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name:        "users.EnrollTOTP StatusConflict",
+			id:          uuid.NewString(),
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("savetotpsecret failed: %w", models.ErrMFAEnrolled),
+			httpStatus:  http.StatusConflict,
+		},
+		{
+			name:        "users.EnrollTOTP StatusNotFound",
+			id:          uuid.NewString(),
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("finduser returned no rows: %w", models.ErrUserNotFound),
+			httpStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "users.EnrollTOTP StatusInternalServerError",
+			id:          uuid.NewString(),
+			serviceCall: 1,
+			serviceErr:  errors.New("Generic Error"),
+			httpStatus:  http.StatusInternalServerError,
+		},
+		{
+			name:        "users.EnrollTOTP StatusBadRequest",
+			id:          "not-a-uuid",
+			serviceCall: 0,
+			httpStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(test.id)
+
+			// Mocked MFA Service
+			parsedID, _ := uuid.Parse(test.id)
+			mockedService.EXPECT().EnrollTOTP(c.Request().Context(), parsedID).Times(test.serviceCall).Return(test.enrollment, test.serviceErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.enrollment != nil {
+					var enrollment models.TOTPEnrollment
+					assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &enrollment))
+					assert.Equal(t, *test.enrollment, enrollment)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedService := repositories.NewMockMFAService(ctrl)
+	s.MFAService = mockedService
+
+	e := echo.New()
+
+	codes := &models.RecoveryCodes{Codes: []string{"abcde-fghij", "klmno-pqrst"}}
+
+	tt := []struct {
+		name        string
+		handler     func(c echo.Context) error
+		regenerate  bool
+		body        string
+		serviceCall int
+		serviceErr  error
+		httpStatus  int
+	}{
+		{
+			name:        "users.ConfirmTOTP StatusOK",
+			handler:     users.ConfirmTOTP(s),
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			httpStatus:  http.StatusOK,
+		},
+		{
+			name:        "users.ConfirmTOTP StatusForbidden",
+			handler:     users.ConfirmTOTP(s),
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			serviceErr:  models.ErrInvalidMFACode,
+			httpStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "users.ConfirmTOTP StatusConflict",
+			handler:     users.ConfirmTOTP(s),
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("confirmtotp failed: %w", models.ErrMFAEnrolled),
+			httpStatus:  http.StatusConflict,
+		},
+		{
+			name:        "users.ConfirmTOTP StatusNotFound",
+			handler:     users.ConfirmTOTP(s),
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("findusermfa returned no rows: %w", models.ErrMFANotEnrolled),
+			httpStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "users.ConfirmTOTP StatusBadRequest",
+			handler:     users.ConfirmTOTP(s),
+			body:        `{"code":""}`,
+			serviceCall: 0,
+			httpStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "users.RegenerateRecoveryCodes StatusOK",
+			handler:     users.RegenerateRecoveryCodes(s),
+			regenerate:  true,
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			httpStatus:  http.StatusOK,
+		},
+		{
+			name:        "users.RegenerateRecoveryCodes StatusForbidden",
+			handler:     users.RegenerateRecoveryCodes(s),
+			regenerate:  true,
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			serviceErr:  fmt.Errorf("totp code already used: %w", models.ErrInvalidMFACode),
+			httpStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "users.RegenerateRecoveryCodes StatusInternalServerError",
+			handler:     users.RegenerateRecoveryCodes(s),
+			regenerate:  true,
+			body:        `{"code":"123456"}`,
+			serviceCall: 1,
+			serviceErr:  errors.New("Generic Error"),
+			httpStatus:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			id := uuid.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(id.String())
+
+			// Mocked MFA Service
+			var serviceCodes *models.RecoveryCodes
+			if test.serviceErr == nil {
+				serviceCodes = codes
+			}
+			if test.regenerate {
+				mockedService.EXPECT().RegenerateRecoveryCodes(c.Request().Context(), id, "123456").Times(test.serviceCall).Return(serviceCodes, test.serviceErr)
+			} else {
+				mockedService.EXPECT().ConfirmTOTP(c.Request().Context(), id, "123456").Times(test.serviceCall).Return(serviceCodes, test.serviceErr)
+			}
+
+			// Assertions
+			if assert.NoError(t, test.handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.httpStatus == http.StatusOK {
+					var response models.RecoveryCodes
+					assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+					assert.Equal(t, *codes, response)
+				}
+			}
+		})
+	}
+}
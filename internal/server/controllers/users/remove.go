@@ -1,16 +1,18 @@
 package users
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
 )
 
-// Remove User Controller is responsible for Deleting the user from the database using its ID.
+// Remove User Controller is responsible for soft-deleting the user using its ID.
 func Remove(s *server.Server) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		parsedID, err := uuid.Parse(c.Param("id"))
@@ -22,6 +24,9 @@ func Remove(s *server.Server) func(c echo.Context) error {
 		_, err = s.UserRepository.RemoveUser(c.Request().Context(), parsedID)
 		if err != nil {
 			s.Logger.Error("failed to remove user", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
 			return c.NoContent(http.StatusInternalServerError)
 		}
 
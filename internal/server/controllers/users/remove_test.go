@@ -2,10 +2,12 @@ package users_test
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
@@ -49,6 +51,15 @@ func TestRemove(t *testing.T) {
 			repoErr:    nil,
 			httpStatus: http.StatusAccepted,
 		},
+		{
+			name:       "users.Remove StatusNotFound",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:   1,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoResult: 0,
+			repoErr:    fmt.Errorf("removeuser affected no rows: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
 		{
 			name:       "users.Remove StatusInternalServerError",
 			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
@@ -0,0 +1,40 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// Restore User Controller is responsible for bringing back a soft-deleted User using its ID.
+func Restore(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		user, err := s.UserRepository.RestoreUser(c.Request().Context(), parsedID)
+		if err != nil {
+			s.Logger.Error("failed to restore user", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			// The email or nickname may have been taken while the User was deleted
+			var dupErr *models.DuplicateError
+			if errors.As(err, &dupErr) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: dupErr.Error(), Field: dupErr.Field})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
@@ -0,0 +1,125 @@
+package users_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRestore(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.Restore(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name       string
+		inputID    string
+		repoInput  uuid.UUID
+		repoCall   int
+		repoUser   *models.User
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:      "users.Restore StatusOK",
+			inputID:   "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:  1,
+			repoInput: uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoUser: &models.User{
+				ID:        uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Email:     "john.tester@email.com",
+				Country:   "US",
+			},
+			repoErr:    nil,
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "users.Restore StatusNotFound",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:   1,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoUser:   nil,
+			repoErr:    fmt.Errorf("restoreuser returned no rows: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "users.Restore StatusConflict",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:   1,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoUser:   nil,
+			repoErr:    fmt.Errorf("restoreuser failed: %w", &models.DuplicateError{Field: "email"}),
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:       "users.Restore StatusInternalServerError",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoCall:   1,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoUser:   nil,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.Restore StatusBadRequest",
+			inputID:    "904bc695-6b6cxxxxx82a0-0acc7a747d46",
+			repoCall:   0,
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoUser:   nil,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id/restore")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().RestoreUser(c.Request().Context(), test.repoInput).Times(test.repoCall).Return(test.repoUser, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+				if test.repoErr == nil {
+					var user models.User
+					err := json.Unmarshal(rec.Body.Bytes(), &user)
+					assert.NoError(t, err)
+					assert.Equal(t, *test.repoUser, user)
+				}
+			}
+		})
+	}
+}
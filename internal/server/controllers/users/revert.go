@@ -0,0 +1,57 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// revertRequest holds the audit entry ID of the version a User is reverted to
+type revertRequest struct {
+	Version int64 `json:"version"`
+}
+
+// Revert User Controller sets a User back to a previous version from its history, the password is kept as it is.
+func Revert(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		parsedID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			s.Logger.Error("failed to parse user id", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		request := new(revertRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Version < 1 {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "version is required", Field: "version"})
+		}
+
+		user, err := s.UserRepository.RevertUser(c.Request().Context(), parsedID, request.Version)
+		if err != nil {
+			s.Logger.Error("failed to revert user", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrVersionNotFound) {
+				return c.JSON(http.StatusNotFound, &models.ErrorResponse{Message: err.Error()})
+			}
+			// A version where the User was deleted can't be reverted to, the User must be restored instead
+			if errors.Is(err, models.ErrUserGone) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: "the user was deleted in that version", Field: "version"})
+			}
+			var dupErr *models.DuplicateError
+			if errors.As(err, &dupErr) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: dupErr.Error(), Field: dupErr.Field})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
@@ -0,0 +1,131 @@
+package users_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRevert(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.Revert(s)
+
+	e := echo.New()
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+
+	tt := []struct {
+		name       string
+		inputID    string
+		body       string
+		repoCall   int
+		repoUser   *models.User
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "users.Revert StatusOK",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoUser:   &models.User{ID: userID, FirstName: "John"},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "users.Revert StatusNotFound version",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("revertuser failed: %w", models.ErrVersionNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "users.Revert StatusNotFound user",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("revertuser failed: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "users.Revert StatusConflict deleted version",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("revertuser failed: %w", models.ErrUserGone),
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:       "users.Revert StatusConflict duplicate",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("revertuser failed: %w", &models.DuplicateError{Field: "email"}),
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:       "users.Revert StatusInternalServerError",
+			inputID:    userID.String(),
+			body:       `{"version":3}`,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.Revert StatusBadRequest version",
+			inputID:    userID.String(),
+			body:       `{}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.Revert StatusBadRequest id",
+			inputID:    "123",
+			body:       `{"version":3}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id/revert")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().RevertUser(c.Request().Context(), userID, int64(3)).Times(test.repoCall).Return(test.repoUser, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
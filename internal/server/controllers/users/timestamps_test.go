@@ -0,0 +1,119 @@
+package users_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// assertUTC checks the timestamp is RFC 3339 with a Z suffix and the same instant as expected
+func assertUTC(t *testing.T, expected time.Time, value any) {
+	str, ok := value.(string)
+	if !assert.True(t, ok, "timestamp is not a string: %v", value) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(str, "Z"), "timestamp is not in UTC: %s", str)
+	parsed, err := time.Parse(time.RFC3339Nano, str)
+	if assert.NoError(t, err) {
+		assert.True(t, expected.Equal(parsed), "expected %s, got %s", expected, parsed)
+	}
+}
+
+func TestTimestampsUTC(t *testing.T) {
+	// The server runs in a non-UTC zone, and the database returns the times in yet another one
+	local := time.Local
+	time.Local = time.FixedZone("UTC-3", -3*60*60)
+	defer func() { time.Local = local }()
+	dbZone := time.FixedZone("UTC+9", 9*60*60)
+
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	userID := uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46")
+	createdAt := time.Date(2022, 10, 4, 9, 0, 0, 123456000, dbZone)
+	updatedAt := time.Date(2022, 10, 5, 1, 30, 0, 0, time.Local)
+	verifiedAt := time.Date(2022, 10, 4, 23, 59, 59, 0, dbZone)
+	deletedAt := time.Date(2022, 10, 6, 8, 0, 0, 0, time.Local)
+	repoUser := &models.User{
+		ID:              userID,
+		FirstName:       "John",
+		LastName:        "Tester",
+		Nickname:        "JT",
+		Email:           "john.tester@email.com",
+		Country:         "US",
+		EmailVerifiedAt: &verifiedAt,
+		CreatedAt:       createdAt,
+		UpdatedAt:       updatedAt,
+		DeletedAt:       &deletedAt,
+	}
+
+	tt := []struct {
+		name string
+		body func() []byte
+	}{
+		{
+			name: "users.Get response",
+			body: func() []byte {
+				mockedRepo.EXPECT().FindUser(gomock.Any(), userID).Times(1).Return(repoUser, nil)
+
+				e := echo.New()
+				req := httptest.NewRequest(http.MethodGet, "/api", nil)
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
+				c.SetPath("/users/:id")
+				c.SetParamNames("id")
+				c.SetParamValues(userID.String())
+
+				assert.NoError(t, users.Get(s)(c))
+				assert.Equal(t, http.StatusOK, rec.Code)
+				return rec.Body.Bytes()
+			},
+		},
+		{
+			name: "user event",
+			body: func() []byte {
+				event := &models.UserEvent{Operation: "update", UserID: userID.String(), User: repoUser}
+				body, err := json.Marshal(event)
+				assert.NoError(t, err)
+
+				var parsed struct {
+					User json.RawMessage `json:"user"`
+				}
+				assert.NoError(t, json.Unmarshal(body, &parsed))
+				return parsed.User
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			var response map[string]any
+			if assert.NoError(t, json.Unmarshal(test.body(), &response)) {
+				assertUTC(t, createdAt, response["created_at"])
+				assertUTC(t, updatedAt, response["updated_at"])
+				assertUTC(t, verifiedAt, response["email_verified_at"])
+				assertUTC(t, deletedAt, response["deleted_at"])
+			}
+		})
+	}
+}
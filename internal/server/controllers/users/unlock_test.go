@@ -0,0 +1,102 @@
+package users_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestUnlock(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockLoginLockoutRepository(ctrl)
+	s.LoginLockoutRepository = mockedRepo
+
+	handler := users.Unlock(s)
+
+	e := echo.New()
+
+	tt := []struct {
+		name       string
+		inputID    string
+		repoInput  uuid.UUID
+		repoCall   int
+		wasLocked  bool
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "users.Unlock StatusNoContent",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			wasLocked:  true,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "users.Unlock StatusNoContent not locked",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name:       "users.Unlock StatusNotFound",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			repoErr:    fmt.Errorf("unlockuser returned no rows: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "users.Unlock StatusInternalServerError",
+			inputID:    "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			repoInput:  uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.Unlock StatusBadRequest",
+			inputID:    "904bc695-6b6cxxxxx82a0-0acc7a747d46",
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id/unlock")
+			c.SetParamNames("id")
+			c.SetParamValues(test.inputID)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().UnlockUser(c.Request().Context(), test.repoInput).Times(test.repoCall).Return(test.wasLocked, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ import (
 )
 
 // Update User Controller is responsible for the User Update. (Should be used just for PUT requests, not for PATCH)
+// Every field but the password is replaced, a body holding a password is refused.
 func Update(s *server.Server) func(c echo.Context) error {
 	return func(c echo.Context) error {
 		parsedID, err := uuid.Parse(c.Param("id"))
@@ -31,9 +33,26 @@ func Update(s *server.Server) func(c echo.Context) error {
 			return c.NoContent(http.StatusBadRequest)
 		}
 
+		// The password is only changed with the current one or a reset link, which revoke the pending links and broadcast password_changed
+		if u.Password != "" {
+			s.Logger.Error("password sent to user update")
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "password is changed with POST /api/users/:id/password", Field: "password"})
+		}
+
 		user, err := s.UserRepository.UpdateUser(c.Request().Context(), u)
 		if err != nil {
 			s.Logger.Error("failed to update user", zap.Error(err))
+			if errors.Is(err, models.ErrUserNotFound) {
+				return c.NoContent(http.StatusNotFound)
+			}
+			var valErr *models.ValidationError
+			if errors.As(err, &valErr) {
+				return c.JSON(http.StatusBadRequest, valErr.Response())
+			}
+			var dupErr *models.DuplicateError
+			if errors.As(err, &dupErr) {
+				return c.JSON(http.StatusConflict, &models.ErrorResponse{Message: dupErr.Error(), Field: dupErr.Field})
+			}
 			return c.NoContent(http.StatusInternalServerError)
 		}
 
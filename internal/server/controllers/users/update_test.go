@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -52,7 +53,6 @@ func TestUpdate(t *testing.T) {
 				"first_name":"John",
 				"last_name":"Tester",
 				"nickname":"JT",
-				"password":"ABC123!",
 				"email":"john.tester@email.com",
 				"country":"US"
 			}`,
@@ -62,7 +62,6 @@ func TestUpdate(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Tester",
 				Nickname:  "JT",
-				Password:  "ABC123!",
 				Email:     "john.tester@email.com",
 				Country:   "US",
 				CreatedAt: time.Time{},
@@ -79,7 +78,6 @@ func TestUpdate(t *testing.T) {
 				"first_name":"John",
 				"last_name":"Tester",
 				"nickname":"JT",
-				"password":"ABC123!",
 				"email":"john.tester@email.com",
 				"country":"US"
 			}`,
@@ -89,7 +87,6 @@ func TestUpdate(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Tester",
 				Nickname:  "JT",
-				Password:  "ABC123!",
 				Email:     "john.tester@email.com",
 				Country:   "US",
 				CreatedAt: time.Time{},
@@ -98,6 +95,73 @@ func TestUpdate(t *testing.T) {
 			repoErr:    errors.New("Generic Error"),
 			httpStatus: http.StatusInternalServerError,
 		},
+		{
+			name:    "users.Update StatusNotFound",
+			inputID: "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			inputUser: `{
+				"id": "904bc695-6b6c-418a-82a0-0acc7a747d46",
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"email":"john.tester@email.com",
+				"country":"US"
+			}`,
+			repoCall: 1,
+			repoUser: &models.User{
+				ID:        uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Email:     "john.tester@email.com",
+				Country:   "US",
+				CreatedAt: time.Time{},
+				UpdatedAt: time.Time{},
+			},
+			repoErr:    fmt.Errorf("updateuser returned no rows: %w", models.ErrUserNotFound),
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:    "users.Update StatusConflict",
+			inputID: "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			inputUser: `{
+				"id": "904bc695-6b6c-418a-82a0-0acc7a747d46",
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"email":"john.tester@email.com",
+				"country":"US"
+			}`,
+			repoCall: 1,
+			repoUser: &models.User{
+				ID:        uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"),
+				FirstName: "John",
+				LastName:  "Tester",
+				Nickname:  "JT",
+				Email:     "john.tester@email.com",
+				Country:   "US",
+				CreatedAt: time.Time{},
+				UpdatedAt: time.Time{},
+			},
+			repoErr:    fmt.Errorf("updateuser failed: %w", &models.DuplicateError{Field: "nickname"}),
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:    "users.Update StatusBadRequest password",
+			inputID: "904bc695-6b6c-418a-82a0-0acc7a747d46",
+			inputUser: `{
+				"id": "904bc695-6b6c-418a-82a0-0acc7a747d46",
+				"first_name":"John",
+				"last_name":"Tester",
+				"nickname":"JT",
+				"password":"ABC123!",
+				"email":"john.tester@email.com",
+				"country":"US"
+			}`,
+			repoCall:   0,
+			repoUser:   &models.User{},
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusBadRequest,
+		},
 		{
 			name:    "users.Update StatusBadRequest",
 			inputID: "904bc695-6b6c-418a-82a0-0acc7a747d46",
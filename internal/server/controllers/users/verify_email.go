@@ -0,0 +1,42 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+)
+
+// verifyEmailRequest holds the token sent by email to the User
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// VerifyEmail User Controller consumes an email verification token, a token can only be used once.
+func VerifyEmail(s *server.Server) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		request := new(verifyEmailRequest)
+		if err := c.Bind(request); err != nil {
+			s.Logger.Error("failed to parse body", zap.Error(err))
+			return c.NoContent(http.StatusBadRequest)
+		}
+		if request.Token == "" {
+			return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "token is required", Field: "token"})
+		}
+
+		user, err := s.UserRepository.VerifyEmail(c.Request().Context(), request.Token)
+		if err != nil {
+			s.Logger.Error("failed to verify email", zap.Error(err))
+			if errors.Is(err, models.ErrInvalidToken) {
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: models.ErrInvalidToken.Error(), Field: "token"})
+			}
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
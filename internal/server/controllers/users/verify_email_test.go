@@ -0,0 +1,93 @@
+package users_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	s := server.NewServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s.Logger = zap.NewNop()
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	s.UserRepository = mockedRepo
+
+	handler := users.VerifyEmail(s)
+
+	e := echo.New()
+
+	verifiedAt := time.Date(2022, 10, 9, 16, 25, 3, 0, time.UTC)
+
+	tt := []struct {
+		name       string
+		body       string
+		repoCall   int
+		repoUser   *models.User
+		repoErr    error
+		httpStatus int
+	}{
+		{
+			name:       "users.VerifyEmail StatusOK",
+			body:       `{"token":"abc.def"}`,
+			repoCall:   1,
+			repoUser:   &models.User{ID: uuid.MustParse("904bc695-6b6c-418a-82a0-0acc7a747d46"), EmailVerifiedAt: &verifiedAt},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "users.VerifyEmail StatusBadRequest used token",
+			body:       `{"token":"abc.def"}`,
+			repoCall:   1,
+			repoErr:    fmt.Errorf("verifyemail failed: %w", models.ErrInvalidToken),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "users.VerifyEmail StatusInternalServerError",
+			body:       `{"token":"abc.def"}`,
+			repoCall:   1,
+			repoErr:    errors.New("Generic Error"),
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "users.VerifyEmail StatusBadRequest missing token",
+			body:       `{}`,
+			repoCall:   0,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewReader([]byte(test.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().VerifyEmail(c.Request().Context(), "abc.def").Times(test.repoCall).Return(test.repoUser, test.repoErr)
+
+			// Assertions
+			if assert.NoError(t, handler(c)) {
+				assert.Equal(t, test.httpStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/audit"
+)
+
+// anonymousActor is the actor of the requests made without credentials, like the password resets
+const anonymousActor = "anonymous"
+
+// Audit middleware stores who makes the request in the request context so the repositories can attribute the mutations.
+// The actor only comes from the authentication, never from a header the client chooses. It must run after the RequestID and Authenticate middlewares.
+func Audit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			actor := audit.Actor{
+				Name:      anonymousActor,
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+				SourceIP:  c.RealIP(),
+			}
+			if keyID, ok := c.Get(ContextAPIKeyID).(string); ok && keyID != "" {
+				actor.Name = "apikey:" + keyID
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(audit.NewContext(req.Context(), actor)))
+			return next(c)
+		}
+	}
+}
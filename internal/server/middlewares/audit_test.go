@@ -0,0 +1,111 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/audit"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+func TestAudit(t *testing.T) {
+	tt := []struct {
+		name      string
+		headers   map[string]string
+		apiKeyID  string
+		actor     string
+		requestID string // Empty when a generated one is expected
+	}{
+		{
+			name:      "anonymous without credentials",
+			headers:   map[string]string{echo.HeaderXRequestID: "req-1"},
+			actor:     "anonymous",
+			requestID: "req-1",
+		},
+		{
+			name:      "the actor of a header is ignored",
+			headers:   map[string]string{"X-Actor": "admin@email.com", echo.HeaderXRequestID: "req-1"},
+			actor:     "anonymous",
+			requestID: "req-1",
+		},
+		{
+			name:      "the API key of the request",
+			headers:   map[string]string{"X-Actor": "admin@email.com", echo.HeaderXRequestID: "req-1"},
+			apiKeyID:  "2d1c8c5e-51b0-4a43-8d43-d1b1e3cfa8f6",
+			actor:     "apikey:2d1c8c5e-51b0-4a43-8d43-d1b1e3cfa8f6",
+			requestID: "req-1",
+		},
+		{
+			name:      "a request ID of 100 characters is kept",
+			headers:   map[string]string{echo.HeaderXRequestID: strings.Repeat("r", 100)},
+			actor:     "anonymous",
+			requestID: strings.Repeat("r", 100),
+		},
+		{
+			name:    "a request ID of 101 characters is replaced",
+			headers: map[string]string{echo.HeaderXRequestID: strings.Repeat("r", 101)},
+			actor:   "anonymous",
+		},
+		{
+			name:    "a request ID with spaces is replaced",
+			headers: map[string]string{echo.HeaderXRequestID: "req 1"},
+			actor:   "anonymous",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			var actor audit.Actor
+			e := echo.New()
+			e.Use(middlewares.RequestID())
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if test.apiKeyID != "" {
+						c.Set(middlewares.ContextAPIKeyID, test.apiKeyID)
+					}
+					return next(c)
+				}
+			})
+			e.Use(middlewares.Audit())
+			e.POST("/", func(c echo.Context) error {
+				actor = audit.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			// Assertions
+			assert.Equal(t, test.actor, actor.Name)
+			assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), actor.RequestID)
+			if test.requestID != "" {
+				assert.Equal(t, test.requestID, actor.RequestID)
+			} else {
+				assert.NotEmpty(t, actor.RequestID)
+				assert.NotEqual(t, test.headers[echo.HeaderXRequestID], actor.RequestID)
+			}
+		})
+	}
+}
+
+func TestAuditTruncated(t *testing.T) {
+	ctx := audit.NewContext(httptest.NewRequest(http.MethodGet, "/", nil).Context(), audit.Actor{
+		Name:      strings.Repeat("é", 150),
+		RequestID: strings.Repeat("r", 150),
+		SourceIP:  strings.Repeat("1", 60),
+	})
+
+	actor := audit.FromContext(ctx)
+	assert.Equal(t, strings.Repeat("é", audit.MaxNameLength), actor.Name)
+	assert.Equal(t, strings.Repeat("r", audit.MaxRequestIDLength), actor.RequestID)
+	assert.Equal(t, strings.Repeat("1", audit.MaxSourceIPLength), actor.SourceIP)
+}
@@ -0,0 +1,156 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// Echo context keys set by the authentication once it knows who makes the request
+const (
+	ContextAPIKey   = "api_key" // The *models.APIKey of the request
+	ContextAPIKeyID = "api_key_id"
+	ContextUserID   = "user_id"
+)
+
+// scopedResources are the resources whose routes require a scope from the API keys
+var scopedResources = map[string]bool{
+	"users":   true,
+	"apikeys": true,
+}
+
+// publicRoutes are the routes called without credentials, to log in or to recover an account
+var publicRoutes = map[string]bool{
+	"POST /api/auth/login":                  true,
+	"POST /api/auth/password-reset":         true,
+	"POST /api/auth/password-reset/confirm": true,
+	"POST /api/users/verify-email":          true,
+}
+
+// adminRoutes require the admin scope on top of the scope of their resource
+var adminRoutes = map[string]bool{
+	`POST /api/users\:batchUpdate`: true,
+	`POST /api/users\:batchDelete`: true,
+	"POST /api/users/:id/restore":  true,
+	"POST /api/users/:id/revert":   true,
+	"POST /api/users/:id/unlock":   true,
+}
+
+// selfRoutes change the User of the :id param or its credentials, only that User or an admin may call them
+var selfRoutes = map[string]bool{
+	"PUT /api/users/:id":                     true,
+	"DELETE /api/users/:id":                  true,
+	"POST /api/users/:id/password":           true,
+	"POST /api/users/:id/mfa/totp":           true,
+	"POST /api/users/:id/mfa/totp/confirm":   true,
+	"POST /api/users/:id/mfa/recovery-codes": true,
+}
+
+// Authenticate middleware requires an API key as "Authorization: Bearer <key>" on every route but the public ones, and checks it carries
+// the scope of the route, "<resource>:read" for GET and HEAD requests and "<resource>:write" otherwise, the resource being the first
+// segment after /api. The admin routes, listing the soft-deleted Users and changing another User, its password or its MFA require the admin scope too.
+// API keys are the only credentials so far: the service issues no session nor JWT, so there is none to verify and other bearer tokens are refused.
+func Authenticate(log *zap.Logger, apiKeyRepo models.APIKeyRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Request().Method + " " + c.Path()
+			authorization := c.Request().Header.Get(echo.HeaderAuthorization)
+			if authorization == "" {
+				if publicRoutes[route] {
+					return next(c)
+				}
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: "authorization required"})
+			}
+
+			token := strings.TrimPrefix(authorization, "Bearer ")
+			if token == authorization || !apikeys.IsAPIKey(token) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: "unsupported authorization"})
+			}
+
+			key, err := apiKeyRepo.AuthenticateAPIKey(c.Request().Context(), apikeys.Hash(token))
+			if err != nil {
+				log.Error("failed to authenticate api key", zap.Error(err))
+				if errors.Is(err, models.ErrInvalidAPIKey) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+					return c.JSON(http.StatusUnauthorized, &models.ErrorResponse{Message: models.ErrInvalidAPIKey.Error()})
+				}
+				return c.NoContent(http.StatusInternalServerError)
+			}
+
+			if scope := requiredScope(c); scope != "" && !key.HasScope(scope) {
+				return insufficientScope(c, scope)
+			}
+			if requiresAdmin(c, route, key) && !key.HasScope(models.ScopeAdmin) {
+				return insufficientScope(c, models.ScopeAdmin)
+			}
+
+			c.Set(ContextAPIKey, key)
+			c.Set(ContextAPIKeyID, key.ID.String())
+			c.Set(ContextUserID, key.UserID.String())
+			return next(c)
+		}
+	}
+}
+
+// insufficientScope refuses the request of a key lacking the scope
+func insufficientScope(c echo.Context, scope string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="insufficient_scope", scope="`+scope+`"`)
+	return c.JSON(http.StatusForbidden, &models.ErrorResponse{Message: "api key lacks the scope " + scope})
+}
+
+// requiresAdmin returns whether the request is an admin operation for the key: an admin route, a listing including
+// the soft-deleted Users, or a route changing another User than the one of the key
+func requiresAdmin(c echo.Context, route string, key *models.APIKey) bool {
+	if adminRoutes[route] {
+		return true
+	}
+	if includeDeleted, _ := strconv.ParseBool(c.QueryParam("include_deleted")); includeDeleted {
+		return true
+	}
+	return selfRoutes[route] && c.Param("id") != key.UserID.String()
+}
+
+// CallerAPIKey returns the API key the request is authenticated with, nil on the public routes called without one
+func CallerAPIKey(c echo.Context) *models.APIKey {
+	key, _ := c.Get(ContextAPIKey).(*models.APIKey)
+	return key
+}
+
+// requiredScope returns the scope an API key needs for the route, empty when the route requires none
+func requiredScope(c echo.Context) string {
+	path := strings.TrimPrefix(c.Path(), "/api/")
+	end := strings.IndexAny(path, `/\:`)
+	if end >= 0 {
+		path = path[:end]
+	}
+	if !scopedResources[path] {
+		return ""
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead:
+		return path + ":read"
+	default:
+		return path + ":write"
+	}
+}
+
+// clientKey returns the key of the client making the request, the API key or the User once authenticated or the IP otherwise
+func clientKey(c echo.Context) string {
+	if id, ok := c.Get(ContextAPIKeyID).(string); ok && id != "" {
+		return "apikey:" + id
+	}
+	if id, ok := c.Get(ContextUserID).(string); ok && id != "" {
+		return "user:" + id
+	}
+	return "ip:" + c.RealIP()
+}
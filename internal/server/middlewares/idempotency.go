@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/idempotency"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// Idempotency middleware makes the requests to the routes (e.g. "POST /api/users") sent with an Idempotency-Key header safe to retry.
+// The response of the first request is stored for the ttl and replayed to the requests with the same key and body,
+// a request reusing the key with a different body is answered with 422 Unprocessable Entity.
+// The requests with the same key are serialized, a retry arriving while the first request is still running waits for its response,
+// while a request with another body is refused right away.
+// The keys are scoped by client, so two clients can not replay each other responses.
+// Only the responses below 500 are stored, a request that failed on the server can be retried with the same key.
+func Idempotency(log *zap.Logger, store idempotency.Store, ttl time.Duration, routes ...string) echo.MiddlewareFunc {
+	idempotent := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		idempotent[route] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route := req.Method + " " + c.Path()
+			header := req.Header.Get(HeaderIdempotencyKey)
+			if !idempotent[route] || header == "" {
+				return next(c)
+			}
+			if len(header) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, &models.ErrorResponse{Message: "idempotency key is too long"})
+			}
+
+			// The body is read to fingerprint the request and then restored for the handler
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				log.Error("Failed to read request body", zap.Error(err))
+				return c.NoContent(http.StatusBadRequest)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := fingerprint(req.Method, req.URL.Path, body)
+
+			key := clientKey(c) + " " + route + " " + header
+			record, lease, err := store.Acquire(req.Context(), key, fingerprint)
+			if err != nil {
+				log.Error("idempotency acquire failed", zap.Error(err))
+				return c.NoContent(http.StatusInternalServerError)
+			}
+
+			if record != nil {
+				if record.Fingerprint != fingerprint {
+					return c.JSON(http.StatusUnprocessableEntity, &models.ErrorResponse{Message: "idempotency key was used with a different request"})
+				}
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(record.StatusCode, record.ContentType, record.Body)
+			}
+			defer func() {
+				if err := lease.Release(); err != nil {
+					log.Error("idempotency release failed", zap.Error(err))
+				}
+			}()
+
+			// The response is written to the client and captured at the same time
+			res := c.Response()
+			capture := &captureWriter{ResponseWriter: res.Writer}
+			res.Writer = capture
+			err = next(c)
+			res.Writer = capture.ResponseWriter
+			if err != nil || res.Status >= http.StatusInternalServerError {
+				return err
+			}
+
+			record = &idempotency.Record{
+				Key:         key,
+				Fingerprint: fingerprint,
+				StatusCode:  res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Body:        capture.body.Bytes(),
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+			if err := lease.Save(req.Context(), record); err != nil {
+				// The response was already sent, a retry will simply run the request again
+				log.Error("idempotency save failed", zap.Error(err))
+			}
+			return nil
+		}
+	}
+}
+
+// fingerprint identifies the request the idempotency key was first used with
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// captureWriter keeps a copy of the response body written through it
+type captureWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
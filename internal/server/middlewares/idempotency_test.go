@@ -0,0 +1,151 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/idempotency"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// idempotentServer routes POST /api/users through the Idempotency middleware to the handler
+func idempotentServer(handler echo.HandlerFunc) *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+	api.Use(middlewares.Idempotency(zap.NewNop(), idempotency.NewMemoryStore(time.Minute), time.Hour, "POST /api/users"))
+	api.POST("/users", handler)
+	return e
+}
+
+func idempotentRequest(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(middlewares.HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	var calls int32
+	started, proceed := make(chan struct{}), make(chan struct{})
+	e := idempotentServer(func(c echo.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-proceed
+		return c.JSON(http.StatusCreated, map[string]int32{"call": atomic.LoadInt32(&calls)})
+	})
+
+	body := `{"email": "john.tester@email.com"}`
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() { first <- idempotentRequest(e, "key-1", body) }()
+	<-started
+
+	// The duplicates arrive while the first request is still running
+	var wg sync.WaitGroup
+	duplicates := make([]*httptest.ResponseRecorder, 3)
+	for i := range duplicates {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			duplicates[i] = idempotentRequest(e, "key-1", body)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(proceed)
+	wg.Wait()
+
+	rec := <-first
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(middlewares.HeaderIdempotentReplayed))
+	for _, duplicate := range duplicates {
+		assert.Equal(t, http.StatusCreated, duplicate.Code)
+		assert.Equal(t, "true", duplicate.Header().Get(middlewares.HeaderIdempotentReplayed))
+		assert.Equal(t, rec.Body.String(), duplicate.Body.String())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency(t *testing.T) {
+	tt := []struct {
+		name       string
+		handler    func(calls int32) (int, string)
+		key        string
+		bodies     []string
+		httpStatus []int
+		calls      int32
+	}{
+		{
+			name:       "the retry is replayed",
+			handler:    func(calls int32) (int, string) { return http.StatusCreated, `{"id": 1}` },
+			key:        "key-1",
+			bodies:     []string{`{"email": "a@email.com"}`, `{"email": "a@email.com"}`},
+			httpStatus: []int{http.StatusCreated, http.StatusCreated},
+			calls:      1,
+		},
+		{
+			name:       "another body is refused",
+			handler:    func(calls int32) (int, string) { return http.StatusCreated, `{"id": 1}` },
+			key:        "key-1",
+			bodies:     []string{`{"email": "a@email.com"}`, `{"email": "b@email.com"}`},
+			httpStatus: []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			calls:      1,
+		},
+		{
+			name: "a server failure is not kept",
+			handler: func(calls int32) (int, string) {
+				if calls == 1 {
+					return http.StatusInternalServerError, `{}`
+				}
+				return http.StatusCreated, `{"id": 1}`
+			},
+			key:        "key-1",
+			bodies:     []string{`{"email": "a@email.com"}`, `{"email": "a@email.com"}`},
+			httpStatus: []int{http.StatusInternalServerError, http.StatusCreated},
+			calls:      2,
+		},
+		{
+			name:       "a client failure is kept",
+			handler:    func(calls int32) (int, string) { return http.StatusBadRequest, `{"message": "invalid"}` },
+			key:        "key-1",
+			bodies:     []string{`{"email": ""}`, `{"email": ""}`},
+			httpStatus: []int{http.StatusBadRequest, http.StatusBadRequest},
+			calls:      1,
+		},
+		{
+			name:       "a too long key is refused",
+			handler:    func(calls int32) (int, string) { return http.StatusCreated, `{"id": 1}` },
+			key:        strings.Repeat("k", 256),
+			bodies:     []string{`{"email": "a@email.com"}`},
+			httpStatus: []int{http.StatusBadRequest},
+			calls:      0,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int32
+			e := idempotentServer(func(c echo.Context) error {
+				status, body := test.handler(atomic.AddInt32(&calls, 1))
+				return c.JSONBlob(status, []byte(body))
+			})
+
+			// Assertions
+			for i, body := range test.bodies {
+				rec := idempotentRequest(e, test.key, body)
+				assert.Equal(t, test.httpStatus[i], rec.Code)
+			}
+			assert.Equal(t, test.calls, atomic.LoadInt32(&calls))
+		})
+	}
+}
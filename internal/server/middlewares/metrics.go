@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/metrics"
+)
+
+// Metrics middleware records the latency of every request by method, route and status code.
+// The route is the path template (e.g. /api/users/:id) so the IDs don't blow up the number of series, the unknown paths share one.
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			// The error is only written by the error handler after the middlewares, so its status is read from it
+			status := c.Response().Status
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				} else {
+					status = http.StatusInternalServerError
+				}
+			}
+
+			// A path no route matches is left as is by the router, it is only told by the error of its not found handler
+			route := c.Path()
+			if route == "" || errors.Is(err, echo.ErrNotFound) {
+				route = "unmatched"
+			}
+			metrics.HTTPRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package middlewares_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/metrics"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// observations returns how many requests the latency histogram recorded with the labels
+func observations(t *testing.T, method, route, status string) uint64 {
+	m := &dto.Metric{}
+	histogram := metrics.HTTPRequestDuration.WithLabelValues(method, route, status).(prometheus.Histogram)
+	assert.NoError(t, histogram.Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics(t *testing.T) {
+	e := echo.New()
+	e.Use(middlewares.Metrics())
+	api := e.Group("/api")
+	api.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	api.POST("/users", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusConflict, "user email already exists")
+	})
+	api.DELETE("/users/:id", func(c echo.Context) error {
+		return errors.New("connection refused")
+	})
+
+	tt := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		status string
+	}{
+		{
+			name:   "parametrised route labelled by its template",
+			method: http.MethodGet,
+			path:   "/api/users/47678967-346e-46be-b5da-0ead3e080c74",
+			route:  "/api/users/:id",
+			status: "200",
+		},
+		{
+			name:   "status of a returned HTTPError",
+			method: http.MethodPost,
+			path:   "/api/users",
+			route:  "/api/users",
+			status: "409",
+		},
+		{
+			name:   "any other error is a 500",
+			method: http.MethodDelete,
+			path:   "/api/users/47678967-346e-46be-b5da-0ead3e080c74",
+			route:  "/api/users/:id",
+			status: "500",
+		},
+		{
+			name:   "unmatched path",
+			method: http.MethodGet,
+			path:   "/api/nowhere/47678967-346e-46be-b5da-0ead3e080c74",
+			route:  "unmatched",
+			status: "404",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			before := observations(t, test.method, test.route, test.status)
+
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			// Assertions
+			assert.Equal(t, test.status, strconv.Itoa(rec.Code))
+			assert.Equal(t, before+1, observations(t, test.method, test.route, test.status))
+			if test.path != test.route {
+				// The path itself never becomes a label
+				assert.Equal(t, uint64(0), observations(t, test.method, test.path, test.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/ratelimit"
+)
+
+// Rate limit headers (IETF draft "RateLimit header fields for HTTP")
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// defaultRoute is the route of the limit applied to the routes without one of their own
+const defaultRoute = "*"
+
+// RateLimit middleware takes a token from the bucket of the client for the route, and answers 429 Too Many Requests once it is empty.
+// The limits are keyed by route as "METHOD /path" (e.g. "GET /api/users"), the routes without a limit, or a "*" one, are not limited.
+// It runs before Authenticate, so the client is the source IP of the request: the requests with a missing or wrong key are counted too,
+// and a key can't be guessed faster than the limit. Placed after it, the client would be the API key of the request.
+// A failing store does not block the requests, they are let through and the error is logged.
+func RateLimit(log *zap.Logger, store ratelimit.Store, limits map[string]ratelimit.Limit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Request().Method + " " + c.Path()
+			limit, ok := limits[route]
+			if !ok {
+				if limit, ok = limits[defaultRoute]; !ok {
+					return next(c)
+				}
+				route = defaultRoute
+			}
+
+			result, err := store.Take(c.Request().Context(), route+" "+clientKey(c), limit)
+			if err != nil {
+				log.Error("rate limit failed", zap.Error(err))
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, fmt.Sprint(limit.Burst))
+			header.Set(HeaderRateLimitRemaining, fmt.Sprint(result.Remaining))
+			header.Set(HeaderRateLimitReset, seconds(result.Reset))
+			if !result.Allowed {
+				header.Set(echo.HeaderRetryAfter, seconds(result.RetryAfter))
+				return c.JSON(http.StatusTooManyRequests, &models.ErrorResponse{Message: "rate limit exceeded"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// seconds formats the duration as whole seconds, rounded up so a client waiting for it is never early
+func seconds(d time.Duration) string {
+	return fmt.Sprint(int64(math.Ceil(d.Seconds())))
+}
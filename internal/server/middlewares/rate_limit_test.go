@@ -0,0 +1,130 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/ratelimit"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/middlewares"
+)
+
+// failingStore is a rate limit store that is down
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func rateLimitedServer(store ratelimit.Store, limits map[string]ratelimit.Limit) *echo.Echo {
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	api := e.Group("/api")
+	api.Use(middlewares.RateLimit(zap.NewNop(), store, limits))
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	api.GET("/users", ok)
+	api.GET("/users/:id", ok)
+	return e
+}
+
+func rateLimitedRequest(e *echo.Echo, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimit(t *testing.T) {
+	// 1 request every 10 seconds, 2 at once
+	limits := map[string]ratelimit.Limit{"GET /api/users": {Rate: 0.1, Burst: 2}}
+	e := rateLimitedServer(ratelimit.NewMemoryStore(), limits)
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		rec := rateLimitedRequest(e, "/api/users", "192.0.2.1:1234")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get(middlewares.HeaderRateLimitLimit))
+		assert.Equal(t, fmt.Sprint(remaining), rec.Header().Get(middlewares.HeaderRateLimitRemaining))
+		assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+	}
+
+	// The empty bucket is answered 429 with the whole seconds until the next token
+	rec := rateLimitedRequest(e, "/api/users", "192.0.2.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, "0", rec.Header().Get(middlewares.HeaderRateLimitRemaining))
+	assert.Equal(t, "20", rec.Header().Get(middlewares.HeaderRateLimitReset))
+	assert.JSONEq(t, `{"message": "rate limit exceeded"}`, rec.Body.String())
+
+	// Another IP has a bucket of its own
+	rec = rateLimitedRequest(e, "/api/users", "192.0.2.2:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// A route without a limit, and no "*" one, is not limited
+	rec = rateLimitedRequest(e, "/api/users/47678967-346e-46be-b5da-0ead3e080c74", "192.0.2.1:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(middlewares.HeaderRateLimitLimit))
+}
+
+func TestRateLimitDefaultRoute(t *testing.T) {
+	limits := map[string]ratelimit.Limit{"*": {Rate: 0.1, Burst: 1}}
+	e := rateLimitedServer(ratelimit.NewMemoryStore(), limits)
+
+	// The routes without a limit share the bucket of "*"
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(e, "/api/users", "192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitedRequest(e, "/api/users/47678967-346e-46be-b5da-0ead3e080c74", "192.0.2.1:1234").Code)
+}
+
+func TestRateLimitFailingStore(t *testing.T) {
+	limits := map[string]ratelimit.Limit{"*": {Rate: 0.1, Burst: 1}}
+	e := rateLimitedServer(failingStore{}, limits)
+
+	// The requests are let through while the store is down
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, rateLimitedRequest(e, "/api/users", "192.0.2.1:1234").Code)
+	}
+}
+
+func TestRateLimitBeforeAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockAPIKeyRepository(ctrl)
+
+	// As in main, the limit is taken before the key is checked
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	api := e.Group("/api")
+	api.Use(middlewares.RateLimit(zap.NewNop(), ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"*": {Rate: 0.1, Burst: 2}}))
+	api.Use(middlewares.Authenticate(zap.NewNop(), mockedRepo))
+	api.GET("/users", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	key, _, _, err := apikeys.Generate()
+	assert.NoError(t, err)
+
+	// The wrong keys are counted, once the bucket is empty they are not even checked
+	mockedRepo.EXPECT().AuthenticateAPIKey(gomock.Any(), gomock.Any()).Times(2).Return(nil, models.ErrInvalidAPIKey)
+	for _, httpStatus := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, httpStatus, rec.Code)
+	}
+}
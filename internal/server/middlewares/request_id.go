@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/audit"
+)
+
+// RequestID middleware is using the Echo existing one, it reuses the X-Request-ID sent by the client or generates one.
+// An X-Request-ID too long for the audit or holding anything but printable ASCII is replaced by a generated one.
+func RequestID() echo.MiddlewareFunc {
+	requestID := middleware.RequestID()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		handler := requestID(next)
+		return func(c echo.Context) error {
+			if header := c.Request().Header; !validRequestID(header.Get(echo.HeaderXRequestID)) {
+				header.Del(echo.HeaderXRequestID)
+			}
+			return handler(c)
+		}
+	}
+}
+
+// validRequestID returns whether the request ID sent by the client can be kept as it is
+func validRequestID(id string) bool {
+	if len(id) > audit.MaxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
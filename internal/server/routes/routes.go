@@ -2,18 +2,52 @@ package routes
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/apikeys"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/auth"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/healthz"
 	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/server/controllers/users"
 )
 
+// LoadProbes assigns the probes of the orchestrator and the Prometheus metrics, at the root so they skip the middlewares
+// of the API (e.g. its rate limit)
+func LoadProbes(e *echo.Echo, s *server.Server) {
+	e.GET("/livez", healthz.Live(s))
+	e.GET("/readyz", healthz.Ready(s))
+	e.GET("/startupz", healthz.Startup(s))
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+}
+
 // LoadRoutes is responsible to assign the paths to the methods and also assign the Server to the Controllers
 func LoadRoutes(g *echo.Group, s *server.Server) {
-	g.GET("/healthz", healthz.GetStatus(s))
+	g.POST("/auth/login", auth.Login(s))
+	g.POST("/auth/password-reset", auth.RequestPasswordReset(s))
+	g.POST("/auth/password-reset/confirm", auth.ConfirmPasswordReset(s))
+
+	g.GET("/apikeys", apikeys.Find(s))
+	g.POST("/apikeys", apikeys.Create(s))
+	g.DELETE("/apikeys/:id", apikeys.Revoke(s))
 
-	g.GET("/users", users.Find(s)) // There is no get by ID because it wasn't in the requirements.
+	g.GET("/users", users.Find(s))
 	g.POST("/users", users.Create(s))
+	// The colons are escaped otherwise Echo reads them as path params
+	g.POST("/users\\:batchCreate", users.BatchCreate(s), middleware.BodyLimit(users.BatchBodyLimit))
+	g.GET("/users\\:export", users.Export(s))
+	g.POST("/users\\:batchUpdate", users.BatchUpdate(s))
+	g.POST("/users\\:batchDelete", users.BatchDelete(s))
+	g.POST("/users/verify-email", users.VerifyEmail(s))
+	g.GET("/users/:id", users.Get(s))
 	g.PUT("/users/:id", users.Update(s)) // Should not be used as PATCH! All User fields shold be provided otherwise will be blanked.
 	g.DELETE("/users/:id", users.Remove(s))
+	g.POST("/users/:id/restore", users.Restore(s))
+	g.GET("/users/:id/history", users.History(s))
+	g.POST("/users/:id/revert", users.Revert(s))
+	g.POST("/users/:id/password", users.ChangePassword(s))
+	g.POST("/users/:id/unlock", users.Unlock(s))
+	g.POST("/users/:id/mfa/totp", users.EnrollTOTP(s))
+	g.POST("/users/:id/mfa/totp/confirm", users.ConfirmTOTP(s))
+	g.POST("/users/:id/mfa/recovery-codes", users.RegenerateRecoveryCodes(s))
 }
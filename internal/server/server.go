@@ -9,9 +9,15 @@ import (
 
 // Server Struct is responsible to store the dependencies that will be used in the controllers
 type Server struct {
-	UserRepository   models.UserRepository
-	Logger           *zap.Logger
-	ConnectionTester healthz.ConnectionTester
+	UserRepository         models.UserRepository
+	APIKeyRepository       models.APIKeyRepository
+	LoginLockoutRepository models.LoginLockoutRepository
+	AccountService         models.AccountService
+	MFAService             models.MFAService
+	LoginGuard             models.LoginGuard
+	TxManager              models.TxManager
+	Logger                 *zap.Logger
+	HealthChecker          *healthz.Checker
 }
 
 func NewServer() *Server {
@@ -0,0 +1,98 @@
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a token was not issued by the Signer, was issued for another purpose or has expired
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// nonceSize is the number of random bytes in every token, so two tokens issued at the same time never match
+const nonceSize = 32
+
+// Signer issues and verifies tokens signed with HMAC-SHA256.
+// A token is only proof that it was issued, being single-use is up to whoever stores its Hash.
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) (*Signer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("token signing key must have at least 32 bytes")
+	}
+	return &Signer{key: key}, nil
+}
+
+// Issue returns a token for the subject (e.g. a User ID) that is valid for the purpose until expiresAt
+func (s *Signer) Issue(purpose, subject string, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("token nonce generation failed: %w", err)
+	}
+
+	payload := strings.Join([]string{purpose, subject, strconv.FormatInt(expiresAt.Unix(), 10), hex.EncodeToString(nonce)}, "|")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Verify checks the signature, purpose and expiry of the token and returns its subject
+func (s *Signer) Verify(purpose, token string, now time.Time) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 4 || parts[0] != purpose {
+		return "", ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return "", ErrInvalidToken
+	}
+
+	return parts[1], nil
+}
+
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Hash returns the SHA-256 of the token, tokens are only stored hashed so a leaked table can't be used to verify anything
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Subject returns the subject of the token WITHOUT checking its signature or expiry.
+// It is only meant for checks that happen before the token is verified and consumed.
+func Subject(token string) (string, error) {
+	encoded, _, _ := strings.Cut(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 4 {
+		return "", ErrInvalidToken
+	}
+	return parts[1], nil
+}
@@ -0,0 +1,83 @@
+package tokens_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+)
+
+func TestSigner(t *testing.T) {
+	signer, err := tokens.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	other, err := tokens.NewSigner([]byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(t, err)
+
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	token, err := signer.Issue("verify-email", "47678967-346e-46be-b5da-0ead3e080c74", now.Add(time.Hour))
+	assert.NoError(t, err)
+	encoded, signature, _ := strings.Cut(token, ".")
+	// tamper changes the first character of the value
+	tamper := func(value string) string {
+		if value[0] == 'A' {
+			return "B" + value[1:]
+		}
+		return "A" + value[1:]
+	}
+
+	tt := []struct {
+		name    string
+		signer  *tokens.Signer
+		purpose string
+		token   string
+		now     time.Time
+		valid   bool
+	}{
+		{name: "valid", signer: signer, purpose: "verify-email", token: token, now: now, valid: true},
+		{name: "valid until it expires", signer: signer, purpose: "verify-email", token: token, now: now.Add(time.Hour - time.Second), valid: true},
+		{name: "expired", signer: signer, purpose: "verify-email", token: token, now: now.Add(time.Hour)},
+		{name: "another purpose", signer: signer, purpose: "password-reset", token: token, now: now},
+		{name: "another key", signer: other, purpose: "verify-email", token: token, now: now},
+		{name: "tampered payload", signer: signer, purpose: "verify-email", token: tamper(encoded) + "." + signature, now: now},
+		{name: "tampered signature", signer: signer, purpose: "verify-email", token: encoded + "." + tamper(signature), now: now},
+		{name: "without signature", signer: signer, purpose: "verify-email", token: encoded, now: now},
+		{name: "empty", signer: signer, purpose: "verify-email", token: "", now: now},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			subject, err := test.signer.Verify(test.purpose, test.token, test.now)
+			if !test.valid {
+				assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "47678967-346e-46be-b5da-0ead3e080c74", subject)
+		})
+	}
+}
+
+func TestIssueUnique(t *testing.T) {
+	signer, err := tokens.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	first, err := signer.Issue("verify-email", "47678967-346e-46be-b5da-0ead3e080c74", expiresAt)
+	assert.NoError(t, err)
+	second, err := signer.Issue("verify-email", "47678967-346e-46be-b5da-0ead3e080c74", expiresAt)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, tokens.Hash(first), tokens.Hash(second))
+
+	subject, err := tokens.Subject(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "47678967-346e-46be-b5da-0ead3e080c74", subject)
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := tokens.NewSigner([]byte("too short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried by the ctx of WithinTx, with the funcs to run once it is committed
+type txState struct {
+	sqlTx       *sql.Tx
+	pgxTx       pgx.Tx
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// TxManager implements models.TxManager on a database/sql pool
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	return run(ctx, &txState{sqlTx: tx}, fn, tx.Commit)
+}
+
+// PgxTxManager implements models.TxManager on a pgx pool
+type PgxTxManager struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxTxManager(pool *pgxpool.Pool) *PgxTxManager {
+	return &PgxTxManager{pool: pool}
+}
+
+func (m *PgxTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx) // No-op once committed
+
+	return run(ctx, &txState{pgxTx: tx}, fn, func() error { return tx.Commit(ctx) })
+}
+
+// run calls fn with the transaction in its ctx and commits it when fn succeeds, the rollback is left to the deferred call of the caller
+func run(ctx context.Context, state *txState, fn func(ctx context.Context) error, commit func() error) error {
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	if err := commit(); err != nil {
+		return fmt.Errorf("commit transaction failed: %w", err)
+	}
+
+	state.mu.Lock()
+	hooks := state.afterCommit
+	state.afterCommit = nil
+	state.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// SQLTx returns the database/sql transaction of the ctx, nil outside of TxManager.WithinTx
+func SQLTx(ctx context.Context) *sql.Tx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.sqlTx
+	}
+	return nil
+}
+
+// PgxTx returns the pgx transaction of the ctx, nil outside of PgxTxManager.WithinTx
+func PgxTx(ctx context.Context) pgx.Tx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.pgxTx
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction of the ctx is committed, and never when it is rolled back.
+// Outside of a transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func()) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		fn()
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.afterCommit = append(state.afterCommit, fn)
+}
@@ -0,0 +1,111 @@
+package transaction_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
+)
+
+// fakeConnector opens connections whose transactions only count their commits and rollbacks
+type fakeConnector struct {
+	commitErr error
+	commits   int
+	rollbacks int
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error {
+	c.connector.commits++
+	return c.connector.commitErr
+}
+func (c *fakeConn) Rollback() error {
+	c.connector.rollbacks++
+	return nil
+}
+
+func TestWithinTx(t *testing.T) {
+	tt := []struct {
+		name      string
+		commitErr error
+		fnErr     error
+		err       bool
+		hooks     int
+		commits   int
+		rollbacks int
+	}{
+		{
+			name:    "the hooks run once committed",
+			hooks:   2,
+			commits: 1,
+		},
+		{
+			name:      "a rollback drops the hooks",
+			fnErr:     errors.New("update failed"),
+			err:       true,
+			rollbacks: 1,
+		},
+		{
+			name:      "a failed commit drops the hooks",
+			commitErr: errors.New("connection reset"),
+			err:       true,
+			commits:   1,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			connector := &fakeConnector{commitErr: test.commitErr}
+			db := sql.OpenDB(connector)
+			defer db.Close()
+			txManager := transaction.NewTxManager(db)
+
+			hooks := 0
+			err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+				assert.NotNil(t, transaction.SQLTx(ctx))
+				transaction.AfterCommit(ctx, func() { hooks++ })
+
+				// A nested call joins the transaction, its hooks wait for the outer commit
+				err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+					transaction.AfterCommit(ctx, func() { hooks++ })
+					return nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, 0, hooks)
+				return test.fnErr
+			})
+
+			// Assertions
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.hooks, hooks)
+			assert.Equal(t, test.commits, connector.commits)
+			assert.Equal(t, test.rollbacks, connector.rollbacks)
+		})
+	}
+}
+
+func TestAfterCommitOutsideTx(t *testing.T) {
+	ran := false
+	transaction.AfterCommit(context.Background(), func() { ran = true })
+	assert.True(t, ran)
+}
@@ -0,0 +1,214 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/mailer"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/transaction"
+)
+
+// sendTimeout bounds the creation and delivery of the verification emails of a single call
+const sendTimeout = 30 * time.Second
+
+// Links configures the links emailed to the Users, the token is added to the URL as the token query param
+type Links struct {
+	VerifyEmailURL   string
+	VerifyEmailTTL   time.Duration
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+}
+
+// UserVerifier wraps a UserRepository to send an email verification token every time a User is created or its email changes.
+// Only the methods that may set a new email or consume a token are overridden, every other call goes straight to the wrapped UserRepository.
+// The tokens sent are stored in the UserTokenRepository. It also implements models.AccountService.
+type UserVerifier struct {
+	models.UserRepository
+	tokenRepository models.UserTokenRepository
+	txManager       models.TxManager
+	logger          *zap.Logger
+	signer          *tokens.Signer
+	mailer          mailer.Mailer
+	links           Links
+}
+
+func NewUserVerifier(logger *zap.Logger, txManager models.TxManager, signer *tokens.Signer, m mailer.Mailer, links Links, tokenRepo models.UserTokenRepository, userRepo models.UserRepository) *UserVerifier {
+	return &UserVerifier{
+		UserRepository:  userRepo,
+		tokenRepository: tokenRepo,
+		txManager:       txManager,
+		logger:          logger,
+		signer:          signer,
+		mailer:          m,
+		links:           links,
+	}
+}
+
+// CreateUser is a method from UserVerifier sends the verification email after the User is created successfully.
+func (s *UserVerifier) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	result, err := s.UserRepository.CreateUser(ctx, user)
+	if err != nil {
+		return result, err
+	}
+
+	go s.sendVerifications([]*models.User{result}) // The response should not wait for the email delivery.
+	return result, nil
+}
+
+// CreateUsers is a method from UserVerifier sends the verification email to every User created successfully.
+func (s *UserVerifier) CreateUsers(ctx context.Context, users []*models.User) ([]*models.User, error) {
+	result, err := s.UserRepository.CreateUsers(ctx, users)
+	if err != nil {
+		return result, err
+	}
+
+	go s.sendVerifications(result)
+	return result, nil
+}
+
+// UpdateUser is a method from UserVerifier sends the verification email when the update changed the email.
+// The previous email is read in the transaction of the update, and the email is only sent once it is committed.
+func (s *UserVerifier) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	var result *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		previous := s.currentEmail(ctx, user.ID)
+
+		var err error
+		result, err = s.UserRepository.UpdateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		transaction.AfterCommit(ctx, func() { s.sendIfEmailChanged(previous, result) })
+		return nil
+	})
+	return result, err
+}
+
+// RevertUser is a method from UserVerifier sends the verification email when the version reverted to has another email.
+// Like UpdateUser, the previous email is read in the transaction of the revert.
+func (s *UserVerifier) RevertUser(ctx context.Context, id uuid.UUID, version int64) (*models.User, error) {
+	var result *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		previous := s.currentEmail(ctx, id)
+
+		var err error
+		result, err = s.UserRepository.RevertUser(ctx, id, version)
+		if err != nil {
+			return err
+		}
+
+		transaction.AfterCommit(ctx, func() { s.sendIfEmailChanged(previous, result) })
+		return nil
+	})
+	return result, err
+}
+
+// VerifyEmail is a method from UserVerifier rejects the tokens it did not sign before they reach the UserRepository.
+func (s *UserVerifier) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	if _, err := s.signer.Verify(models.TokenEmailVerification, token, time.Now()); err != nil {
+		return nil, fmt.Errorf("verifyemail failed: %w", models.ErrInvalidToken)
+	}
+	return s.UserRepository.VerifyEmail(ctx, token)
+}
+
+// ResetPassword is a method from UserVerifier rejects the tokens it did not sign before they reach the UserRepository.
+func (s *UserVerifier) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	if _, err := s.signer.Verify(models.TokenPasswordReset, token, time.Now()); err != nil {
+		return nil, fmt.Errorf("resetpassword failed: %w", models.ErrInvalidToken)
+	}
+	return s.UserRepository.ResetPassword(ctx, token, password)
+}
+
+// RequestPasswordReset emails a password reset link to the User with that email.
+// Nothing tells the caller whether the email exists, so the endpoint can't be used to find out who has an account.
+func (s *UserVerifier) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.UserRepository.FindUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("requestpasswordreset failed: %w", err)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+
+		err := s.sendToken(ctx, user, models.TokenPasswordReset, s.links.PasswordResetURL, s.links.PasswordResetTTL, "Reset your password",
+			"Hi %s,\n\nSomeone asked to reset your password, follow the link below to choose a new one. It expires in %s.\nIf it wasn't you, just ignore this email.\n\n%s\n")
+		if err != nil {
+			s.logger.Error("failed to send password reset", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// currentEmail returns the email of the User before it is changed, empty when it can't be read
+func (s *UserVerifier) currentEmail(ctx context.Context, id uuid.UUID) string {
+	user, err := s.UserRepository.FindUser(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return user.Email
+}
+
+func (s *UserVerifier) sendIfEmailChanged(previous string, user *models.User) {
+	// The repository keeps the verification when the email did not change
+	if user.EmailVerifiedAt == nil && !strings.EqualFold(previous, user.Email) {
+		go s.sendVerifications([]*models.User{user})
+	}
+}
+
+// sendVerifications stores a new token for every User and emails it, failures are logged and the User can ask for a new token by updating its email
+func (s *UserVerifier) sendVerifications(users []*models.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	for _, user := range users {
+		if err := s.sendVerification(ctx, user); err != nil {
+			s.logger.Error("failed to send email verification", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}
+}
+
+func (s *UserVerifier) sendVerification(ctx context.Context, user *models.User) error {
+	return s.sendToken(ctx, user, models.TokenEmailVerification, s.links.VerifyEmailURL, s.links.VerifyEmailTTL, "Verify your email",
+		"Hi %s,\n\nPlease verify your email by following the link below, it expires in %s.\n\n%s\n")
+}
+
+// sendToken stores a new token for the purpose and emails the link holding it to the User.
+// The body is a format receiving the first name, the ttl and the link.
+func (s *UserVerifier) sendToken(ctx context.Context, user *models.User, purpose, link string, ttl time.Duration, subject, body string) error {
+	expiresAt := time.Now().Add(ttl)
+	token, err := s.signer.Issue(purpose, user.ID.String(), expiresAt)
+	if err != nil {
+		return err
+	}
+
+	err = s.tokenRepository.CreateUserToken(ctx, &models.UserToken{
+		Hash:      tokens.Hash(token),
+		Purpose:   purpose,
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(body, user.FirstName, ttl, link+"?token="+url.QueryEscape(token)),
+	})
+}
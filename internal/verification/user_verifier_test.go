@@ -0,0 +1,198 @@
+package verification_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/mailer"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/models"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/repositories"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/tokens"
+	"github.com/fellippemendonca/manage_user_go_pg_echo/internal/verification"
+)
+
+// chanMailer hands every email sent to the test
+type chanMailer chan *mailer.Message
+
+func (m chanMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m <- msg
+	return nil
+}
+
+// directTx runs fn without a transaction, so the funcs given to transaction.AfterCommit run right away
+type directTx struct {
+	calls int
+}
+
+func (tx *directTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.calls++
+	return fn(ctx)
+}
+
+func newVerifier(t *testing.T, tokenRepo models.UserTokenRepository, repo models.UserRepository, m mailer.Mailer) *verification.UserVerifier {
+	return newVerifierTx(t, &directTx{}, tokenRepo, repo, m)
+}
+
+func newVerifierTx(t *testing.T, txManager models.TxManager, tokenRepo models.UserTokenRepository, repo models.UserRepository, m mailer.Mailer) *verification.UserVerifier {
+	signer, err := tokens.NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	links := verification.Links{
+		VerifyEmailURL:   "https://app.example.com/verify-email",
+		VerifyEmailTTL:   time.Hour,
+		PasswordResetURL: "https://app.example.com/reset-password",
+		PasswordResetTTL: time.Hour,
+	}
+	return verification.NewUserVerifier(zap.NewNop(), txManager, signer, m, links, tokenRepo, repo)
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	mockedTokenRepo := repositories.NewMockUserTokenRepository(ctrl)
+	mails := make(chanMailer, 1)
+	verifier := newVerifier(t, mockedTokenRepo, mockedRepo, mails)
+
+	user := &models.User{ID: uuid.New(), FirstName: "John", Email: "John.Tester@email.com"}
+
+	tt := []struct {
+		name    string
+		repoErr error
+		sent    bool
+		err     bool
+	}{
+		{
+			name: "sent to the User with the email",
+			sent: true,
+		},
+		{
+			name:    "nothing sent for an unknown email",
+			repoErr: fmt.Errorf("finduserbyemail returned no rows: %w", models.ErrUserNotFound),
+		},
+		{
+			name:    "repository failure",
+			repoErr: errors.New("Generic Error"),
+			err:     true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Mocked User Repository
+			if test.repoErr != nil {
+				mockedRepo.EXPECT().FindUserByEmail(ctx, "john.tester@EMAIL.com").Times(1).Return(nil, test.repoErr)
+			} else {
+				mockedRepo.EXPECT().FindUserByEmail(ctx, "john.tester@EMAIL.com").Times(1).Return(user, nil)
+				mockedTokenRepo.EXPECT().CreateUserToken(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, token *models.UserToken) error {
+					assert.Equal(t, models.TokenPasswordReset, token.Purpose)
+					assert.Equal(t, user.ID, token.UserID)
+					return nil
+				})
+			}
+
+			// Assertions
+			err := verifier.RequestPasswordReset(ctx, "john.tester@EMAIL.com")
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if test.sent {
+				select {
+				case msg := <-mails:
+					assert.Equal(t, user.Email, msg.To)
+					assert.Contains(t, msg.Body, "https://app.example.com/reset-password?token=")
+				case <-time.After(time.Second):
+					t.Fatal("the password reset was not sent")
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedRepo := repositories.NewMockUserRepository(ctrl)
+	mockedTokenRepo := repositories.NewMockUserTokenRepository(ctrl)
+	mails := make(chanMailer, 1)
+	txManager := &directTx{}
+	verifier := newVerifierTx(t, txManager, mockedTokenRepo, mockedRepo, mails)
+
+	id := uuid.New()
+	verifiedAt := time.Now()
+
+	tt := []struct {
+		name     string
+		previous *models.User
+		updated  *models.User
+		repoErr  error
+		sent     bool
+	}{
+		{
+			name:     "sent when the email changed",
+			previous: &models.User{ID: id, Email: "john.tester@email.com", EmailVerifiedAt: &verifiedAt},
+			updated:  &models.User{ID: id, Email: "john.doe@email.com"},
+			sent:     true,
+		},
+		{
+			name:     "nothing sent for the same email in another case",
+			previous: &models.User{ID: id, Email: "john.tester@email.com"},
+			updated:  &models.User{ID: id, Email: "John.Tester@email.com"},
+		},
+		{
+			name:     "nothing sent when the email is still verified",
+			previous: &models.User{ID: id, Email: "john.tester@email.com", EmailVerifiedAt: &verifiedAt},
+			updated:  &models.User{ID: id, Email: "john.tester@email.com", EmailVerifiedAt: &verifiedAt},
+		},
+		{
+			name:     "nothing sent when the update fails",
+			previous: &models.User{ID: id, Email: "john.tester@email.com"},
+			repoErr:  errors.New("Generic Error"),
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			calls := txManager.calls
+
+			// Mocked User Repository
+			mockedRepo.EXPECT().FindUser(ctx, id).Times(1).Return(test.previous, nil)
+			mockedRepo.EXPECT().UpdateUser(ctx, gomock.Any()).Times(1).Return(test.updated, test.repoErr)
+			if test.sent {
+				mockedTokenRepo.EXPECT().CreateUserToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			}
+
+			// Assertions
+			result, err := verifier.UpdateUser(ctx, &models.User{ID: id})
+			assert.Equal(t, calls+1, txManager.calls)
+			if test.repoErr != nil {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.updated, result)
+			}
+			select {
+			case msg := <-mails:
+				assert.True(t, test.sent, "unexpected email")
+				assert.Equal(t, test.updated.Email, msg.To)
+				assert.Contains(t, msg.Body, "https://app.example.com/verify-email?token=")
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, test.sent, "the verification was not sent")
+			}
+		})
+	}
+}
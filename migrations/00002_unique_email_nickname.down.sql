@@ -0,0 +1,2 @@
+DROP INDEX U1.USERS_NICKNAME_UNIQUE_IDX;
+DROP INDEX U1.USERS_EMAIL_UNIQUE_IDX;
@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX USERS_EMAIL_UNIQUE_IDX ON U1.USERS (LOWER(EMAIL));
+
+CREATE UNIQUE INDEX USERS_NICKNAME_UNIQUE_IDX ON U1.USERS (LOWER(NICKNAME));
@@ -0,0 +1,12 @@
+DROP INDEX U1.USERS_DELETED_AT_IDX;
+DROP INDEX U1.USERS_NICKNAME_UNIQUE_IDX;
+DROP INDEX U1.USERS_EMAIL_UNIQUE_IDX;
+
+-- Without the DELETED_AT column soft-deleted Users would come back to life
+DELETE FROM U1.USERS WHERE DELETED_AT IS NOT NULL;
+
+CREATE UNIQUE INDEX USERS_EMAIL_UNIQUE_IDX ON U1.USERS (LOWER(EMAIL));
+
+CREATE UNIQUE INDEX USERS_NICKNAME_UNIQUE_IDX ON U1.USERS (LOWER(NICKNAME));
+
+ALTER TABLE U1.USERS DROP COLUMN DELETED_AT;
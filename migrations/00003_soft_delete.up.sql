@@ -0,0 +1,12 @@
+ALTER TABLE U1.USERS ADD COLUMN DELETED_AT TIMESTAMP WITHOUT TIME ZONE;
+
+-- Soft-deleted Users should not hold on to their email and nickname
+DROP INDEX U1.USERS_EMAIL_UNIQUE_IDX;
+DROP INDEX U1.USERS_NICKNAME_UNIQUE_IDX;
+
+CREATE UNIQUE INDEX USERS_EMAIL_UNIQUE_IDX ON U1.USERS (LOWER(EMAIL)) WHERE DELETED_AT IS NULL;
+
+CREATE UNIQUE INDEX USERS_NICKNAME_UNIQUE_IDX ON U1.USERS (LOWER(NICKNAME)) WHERE DELETED_AT IS NULL;
+
+-- Used by the purger to find Users past the retention window
+CREATE INDEX USERS_DELETED_AT_IDX ON U1.USERS (DELETED_AT) WHERE DELETED_AT IS NOT NULL;
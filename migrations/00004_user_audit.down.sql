@@ -0,0 +1,3 @@
+DROP TRIGGER USER_AUDIT_APPEND_ONLY_TRG ON U1.USER_AUDIT;
+DROP FUNCTION U1.USER_AUDIT_APPEND_ONLY();
+DROP TABLE U1.USER_AUDIT;
@@ -0,0 +1,26 @@
+-- The history of a User is kept after it is purged, so there is no foreign key to U1.USERS
+CREATE TABLE U1.USER_AUDIT (
+    ID BIGSERIAL PRIMARY KEY,
+    USER_ID UUID NOT NULL,
+    OPERATION VARCHAR(20) NOT NULL,
+    ACTOR VARCHAR(100) NOT NULL,
+    REQUEST_ID VARCHAR(100) NOT NULL,
+    SOURCE_IP VARCHAR(45) NOT NULL,
+    DIFF JSONB NOT NULL,
+    PREV_HASH VARCHAR(64) NOT NULL,
+    HASH VARCHAR(64) NOT NULL,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX USER_AUDIT_USER_ID_IDX ON U1.USER_AUDIT (USER_ID, ID);
+
+-- The audit is append-only, entries can't be changed or removed once written
+CREATE FUNCTION U1.USER_AUDIT_APPEND_ONLY() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'U1.USER_AUDIT is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER USER_AUDIT_APPEND_ONLY_TRG
+    BEFORE UPDATE OR DELETE ON U1.USER_AUDIT
+    FOR EACH ROW EXECUTE FUNCTION U1.USER_AUDIT_APPEND_ONLY();
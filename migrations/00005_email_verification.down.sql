@@ -0,0 +1,3 @@
+DROP TABLE U1.USER_TOKENS;
+
+ALTER TABLE U1.USERS DROP COLUMN EMAIL_VERIFIED_AT;
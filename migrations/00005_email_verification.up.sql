@@ -0,0 +1,14 @@
+ALTER TABLE U1.USERS ADD COLUMN EMAIL_VERIFIED_AT TIMESTAMP WITHOUT TIME ZONE;
+
+-- Single-use tokens sent to Users, only their SHA-256 is stored
+CREATE TABLE U1.USER_TOKENS (
+    TOKEN_HASH VARCHAR(64) PRIMARY KEY,
+    PURPOSE VARCHAR(50) NOT NULL,
+    USER_ID UUID NOT NULL REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    EMAIL VARCHAR(100) NOT NULL,
+    EXPIRES_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    USED_AT TIMESTAMP WITHOUT TIME ZONE,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX USER_TOKENS_USER_ID_IDX ON U1.USER_TOKENS (USER_ID);
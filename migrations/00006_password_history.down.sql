@@ -0,0 +1 @@
+DROP TABLE U1.USER_PASSWORD_HISTORY;
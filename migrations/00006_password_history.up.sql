@@ -0,0 +1,9 @@
+-- The previous passwords of every User, hashed with bcrypt, so they can't be reused
+CREATE TABLE U1.USER_PASSWORD_HISTORY (
+    ID BIGSERIAL PRIMARY KEY,
+    USER_ID UUID NOT NULL REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    PASSWORD_HASH VARCHAR(60) NOT NULL,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX USER_PASSWORD_HISTORY_USER_ID_IDX ON U1.USER_PASSWORD_HISTORY (USER_ID, ID);
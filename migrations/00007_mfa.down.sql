@@ -0,0 +1,2 @@
+DROP TABLE U1.USER_RECOVERY_CODES;
+DROP TABLE U1.USER_MFA;
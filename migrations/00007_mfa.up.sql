@@ -0,0 +1,17 @@
+-- TOTP second factor of the Users, the secret is encrypted by the application before it is stored
+CREATE TABLE U1.USER_MFA (
+    USER_ID UUID PRIMARY KEY REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    TOTP_SECRET TEXT NOT NULL,
+    LAST_USED_STEP BIGINT NOT NULL DEFAULT 0,
+    CONFIRMED_AT TIMESTAMP WITHOUT TIME ZONE,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+-- One-time recovery codes, only their SHA-256 is stored
+CREATE TABLE U1.USER_RECOVERY_CODES (
+    USER_ID UUID NOT NULL REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    CODE_HASH VARCHAR(64) NOT NULL,
+    USED_AT TIMESTAMP WITHOUT TIME ZONE,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    PRIMARY KEY (USER_ID, CODE_HASH)
+);
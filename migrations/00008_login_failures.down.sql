@@ -0,0 +1 @@
+DROP TABLE U1.USER_LOGIN_FAILURES;
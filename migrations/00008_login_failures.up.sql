@@ -0,0 +1,7 @@
+-- Failed logins of every User since its last successful one, LOCKED_UNTIL is set once too many fail in a row
+CREATE TABLE U1.USER_LOGIN_FAILURES (
+    USER_ID UUID PRIMARY KEY REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    FAILED_ATTEMPTS INTEGER NOT NULL DEFAULT 0,
+    LAST_FAILED_AT TIMESTAMP WITHOUT TIME ZONE,
+    LOCKED_UNTIL TIMESTAMP WITHOUT TIME ZONE
+);
@@ -0,0 +1 @@
+DROP TABLE U1.RATE_LIMITS;
@@ -0,0 +1,10 @@
+-- Token buckets of the rate limiter shared by every replica, a missing bucket is a full one
+CREATE TABLE U1.RATE_LIMITS (
+    BUCKET_KEY VARCHAR(255) PRIMARY KEY,
+    TOKENS DOUBLE PRECISION NOT NULL,
+    ALLOWED BOOLEAN NOT NULL,
+    UPDATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    FULL_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX RATE_LIMITS_FULL_AT_IDX ON U1.RATE_LIMITS (FULL_AT);
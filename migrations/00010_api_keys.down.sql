@@ -0,0 +1,2 @@
+DROP TABLE U1.API_KEYS;
+ALTER TABLE U1.USERS DROP COLUMN SERVICE_ACCOUNT;
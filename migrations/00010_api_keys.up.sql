@@ -0,0 +1,17 @@
+ALTER TABLE U1.USERS ADD COLUMN SERVICE_ACCOUNT BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- API keys of the machine clients, only their SHA-256 is stored, PREFIX is kept to tell them apart
+CREATE TABLE U1.API_KEYS (
+    ID UUID PRIMARY KEY NOT NULL DEFAULT public.gen_random_uuid(),
+    USER_ID UUID NOT NULL REFERENCES U1.USERS (ID) ON DELETE CASCADE,
+    NAME VARCHAR(100) NOT NULL,
+    PREFIX VARCHAR(20) NOT NULL,
+    KEY_HASH VARCHAR(64) NOT NULL UNIQUE,
+    SCOPES TEXT[] NOT NULL,
+    EXPIRES_AT TIMESTAMP WITHOUT TIME ZONE,
+    LAST_USED_AT TIMESTAMP WITHOUT TIME ZONE,
+    REVOKED_AT TIMESTAMP WITHOUT TIME ZONE,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX API_KEYS_USER_ID_IDX ON U1.API_KEYS (USER_ID);
@@ -0,0 +1,12 @@
+-- Responses of the requests sent with an Idempotency-Key, replayed to the retries until they expire
+CREATE TABLE U1.IDEMPOTENCY_KEYS (
+    IDEMPOTENCY_KEY VARCHAR(512) PRIMARY KEY,
+    FINGERPRINT VARCHAR(64) NOT NULL,
+    STATUS_CODE INTEGER NOT NULL,
+    CONTENT_TYPE VARCHAR(255) NOT NULL,
+    BODY BYTEA NOT NULL,
+    EXPIRES_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    CREATED_AT TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX IDEMPOTENCY_KEYS_EXPIRES_AT_IDX ON U1.IDEMPOTENCY_KEYS (EXPIRES_AT);
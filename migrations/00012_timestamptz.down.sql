@@ -0,0 +1,52 @@
+-- The instants are written back in UTC without their time zone
+
+ALTER TABLE U1.USERS
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc'),
+    ALTER COLUMN UPDATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING UPDATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN UPDATED_AT SET DEFAULT (now() AT TIME ZONE 'utc'),
+    ALTER COLUMN DELETED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING DELETED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN EMAIL_VERIFIED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING EMAIL_VERIFIED_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.USER_AUDIT
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.USER_TOKENS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN USED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
+
+ALTER TABLE U1.USER_PASSWORD_HISTORY
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
+
+ALTER TABLE U1.USER_MFA
+    ALTER COLUMN CONFIRMED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CONFIRMED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
+
+ALTER TABLE U1.USER_RECOVERY_CODES
+    ALTER COLUMN USED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
+
+ALTER TABLE U1.USER_LOGIN_FAILURES
+    ALTER COLUMN LAST_FAILED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING LAST_FAILED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN LOCKED_UNTIL TYPE TIMESTAMP WITHOUT TIME ZONE USING LOCKED_UNTIL AT TIME ZONE 'utc';
+
+ALTER TABLE U1.RATE_LIMITS
+    ALTER COLUMN UPDATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING UPDATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN FULL_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING FULL_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.API_KEYS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN LAST_USED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING LAST_USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN REVOKED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING REVOKED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
+
+ALTER TABLE U1.IDEMPOTENCY_KEYS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMP WITHOUT TIME ZONE USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT (now() AT TIME ZONE 'utc');
@@ -0,0 +1,54 @@
+-- Every timestamp was written in UTC without its time zone, AT TIME ZONE 'utc' reads it back as the same instant
+-- whatever the TimeZone of the session running the migration. The defaults are plain now() from now on.
+-- The audit trail is append-only for UPDATE and DELETE, which ALTER TABLE does not fire, and its hashes use the instant so they still verify.
+
+ALTER TABLE U1.USERS
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now(),
+    ALTER COLUMN UPDATED_AT TYPE TIMESTAMPTZ USING UPDATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN UPDATED_AT SET DEFAULT now(),
+    ALTER COLUMN DELETED_AT TYPE TIMESTAMPTZ USING DELETED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN EMAIL_VERIFIED_AT TYPE TIMESTAMPTZ USING EMAIL_VERIFIED_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.USER_AUDIT
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.USER_TOKENS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMPTZ USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN USED_AT TYPE TIMESTAMPTZ USING USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
+
+ALTER TABLE U1.USER_PASSWORD_HISTORY
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
+
+ALTER TABLE U1.USER_MFA
+    ALTER COLUMN CONFIRMED_AT TYPE TIMESTAMPTZ USING CONFIRMED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
+
+ALTER TABLE U1.USER_RECOVERY_CODES
+    ALTER COLUMN USED_AT TYPE TIMESTAMPTZ USING USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
+
+ALTER TABLE U1.USER_LOGIN_FAILURES
+    ALTER COLUMN LAST_FAILED_AT TYPE TIMESTAMPTZ USING LAST_FAILED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN LOCKED_UNTIL TYPE TIMESTAMPTZ USING LOCKED_UNTIL AT TIME ZONE 'utc';
+
+ALTER TABLE U1.RATE_LIMITS
+    ALTER COLUMN UPDATED_AT TYPE TIMESTAMPTZ USING UPDATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN FULL_AT TYPE TIMESTAMPTZ USING FULL_AT AT TIME ZONE 'utc';
+
+ALTER TABLE U1.API_KEYS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMPTZ USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN LAST_USED_AT TYPE TIMESTAMPTZ USING LAST_USED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN REVOKED_AT TYPE TIMESTAMPTZ USING REVOKED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
+
+ALTER TABLE U1.IDEMPOTENCY_KEYS
+    ALTER COLUMN EXPIRES_AT TYPE TIMESTAMPTZ USING EXPIRES_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT TYPE TIMESTAMPTZ USING CREATED_AT AT TIME ZONE 'utc',
+    ALTER COLUMN CREATED_AT SET DEFAULT now();
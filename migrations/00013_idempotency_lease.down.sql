@@ -0,0 +1,3 @@
+DELETE FROM U1.IDEMPOTENCY_KEYS WHERE LEASE IS NOT NULL;
+
+ALTER TABLE U1.IDEMPOTENCY_KEYS DROP COLUMN LEASE;
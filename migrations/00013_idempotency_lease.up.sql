@@ -0,0 +1,3 @@
+-- A request takes its idempotency key by inserting a pending row holding its LEASE, cleared once the response is saved.
+-- The retries poll the row instead of waiting on a lock held for the whole request.
+ALTER TABLE U1.IDEMPOTENCY_KEYS ADD COLUMN LEASE UUID;
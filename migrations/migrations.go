@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the SQL migrations, so the binary runs them without the files next to it
+//
+//go:embed *.sql
+var FS embed.FS